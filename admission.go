@@ -0,0 +1,150 @@
+package cke
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	podsecurityload "k8s.io/pod-security-admission/admission/api/load"
+	podsecurityv1 "k8s.io/pod-security-admission/admission/api/v1"
+	podsecurityvalidation "k8s.io/pod-security-admission/admission/api/validation"
+)
+
+// Admission plugins whose configurations are validated by CKE.
+const (
+	AdmissionPluginPodSecurity    = "PodSecurity"
+	AdmissionPluginEventRateLimit = "EventRateLimit"
+)
+
+// eventRateLimitAPIVersion is the API version of EventRateLimit configuration.
+// The types are defined in k8s.io/kubernetes, which cannot be imported, so we
+// define the schema here.
+const eventRateLimitAPIVersion = "eventratelimit.admission.k8s.io/v1alpha1"
+
+type eventRateLimitConfiguration struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Limits     []eventRateLimit `json:"limits"`
+}
+
+type eventRateLimit struct {
+	Type      string `json:"type"`
+	QPS       int32  `json:"qps"`
+	Burst     int32  `json:"burst"`
+	CacheSize int32  `json:"cacheSize,omitempty"`
+}
+
+// validateAdmissionPlugins validates AdmissionPlugins.
+// Configurations for plugins other than PodSecurity and EventRateLimit are
+// passed to kube-apiserver as they are.
+func validateAdmissionPlugins(p APIServerParams) error {
+	if len(p.AdmissionPlugins) == 0 {
+		return nil
+	}
+
+	for _, arg := range p.ExtraArguments {
+		if strings.HasPrefix(arg, "--admission-control-config-file") {
+			return fmt.Errorf("%s cannot be used together with admission_plugins", arg)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, plugin := range p.AdmissionPlugins {
+		if plugin.Name == "" {
+			return fmt.Errorf("admission_plugins[%d] has no name", i)
+		}
+		if names[plugin.Name] {
+			return fmt.Errorf("duplicate admission plugin: %s", plugin.Name)
+		}
+		names[plugin.Name] = true
+
+		cfg := plugin.Configuration
+		if cfg == nil {
+			return fmt.Errorf("no configuration for admission plugin %s", plugin.Name)
+		}
+		if cfg.GetAPIVersion() == "" || cfg.GetKind() == "" {
+			return fmt.Errorf("configuration for admission plugin %s must have apiVersion and kind", plugin.Name)
+		}
+
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		switch plugin.Name {
+		case AdmissionPluginPodSecurity:
+			err = validatePodSecurityConfig(data)
+		case AdmissionPluginEventRateLimit:
+			err = validateEventRateLimitConfig(data)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid configuration for admission plugin %s: %w", plugin.Name, err)
+		}
+	}
+	return nil
+}
+
+func validatePodSecurityConfig(data []byte) error {
+	var tm struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &tm); err != nil {
+		return err
+	}
+	if tm.APIVersion != podsecurityv1.SchemeGroupVersion.String() {
+		return fmt.Errorf("unexpected API version: %s", tm.APIVersion)
+	}
+	if tm.Kind != "PodSecurityConfiguration" {
+		return fmt.Errorf("wrong kind: %s", tm.Kind)
+	}
+
+	cfg, err := podsecurityload.LoadFromData(data)
+	if err != nil {
+		return err
+	}
+	return podsecurityvalidation.ValidatePodSecurityConfiguration(cfg).ToAggregate()
+}
+
+func validateEventRateLimitConfig(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	cfg := &eventRateLimitConfiguration{}
+	if err := dec.Decode(cfg); err != nil {
+		return err
+	}
+	if cfg.APIVersion != eventRateLimitAPIVersion {
+		return fmt.Errorf("unexpected API version: %s", cfg.APIVersion)
+	}
+	if cfg.Kind != "Configuration" {
+		return fmt.Errorf("wrong kind: %s", cfg.Kind)
+	}
+	if len(cfg.Limits) == 0 {
+		return errors.New("no limits")
+	}
+
+	types := make(map[string]bool)
+	for i, l := range cfg.Limits {
+		switch l.Type {
+		case "Server", "Namespace", "User", "SourceAndObject":
+		default:
+			return fmt.Errorf("unsupported type in limits[%d]: %s", i, l.Type)
+		}
+		if types[l.Type] {
+			return fmt.Errorf("duplicate limit type: %s", l.Type)
+		}
+		types[l.Type] = true
+
+		if l.QPS <= 0 {
+			return fmt.Errorf("qps in limits[%d] must be positive", i)
+		}
+		if l.Burst <= 0 {
+			return fmt.Errorf("burst in limits[%d] must be positive", i)
+		}
+		if l.CacheSize < 0 {
+			return fmt.Errorf("cacheSize in limits[%d] must not be negative", i)
+		}
+	}
+	return nil
+}
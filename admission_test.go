@@ -0,0 +1,147 @@
+package cke
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func admissionPlugin(name, apiVersion, kind string, fields map[string]any) AdmissionPluginConfig {
+	u := &unstructured.Unstructured{Object: fields}
+	if u.Object == nil {
+		u.Object = make(map[string]any)
+	}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	return AdmissionPluginConfig{Name: name, Configuration: u}
+}
+
+func podSecurityPlugin(fields map[string]any) AdmissionPluginConfig {
+	return admissionPlugin("PodSecurity", "pod-security.admission.config.k8s.io/v1", "PodSecurityConfiguration", fields)
+}
+
+func eventRateLimitPlugin(limits ...any) AdmissionPluginConfig {
+	return admissionPlugin("EventRateLimit", "eventratelimit.admission.k8s.io/v1alpha1", "Configuration", map[string]any{"limits": limits})
+}
+
+func TestValidateAdmissionPlugins(t *testing.T) {
+	validPodSecurity := podSecurityPlugin(map[string]any{
+		"defaults": map[string]any{
+			"enforce":         "baseline",
+			"enforce-version": "latest",
+			"warn":            "restricted",
+		},
+		"exemptions": map[string]any{
+			"namespaces": []any{"kube-system"},
+		},
+	})
+	validEventRateLimit := eventRateLimitPlugin(
+		map[string]any{"type": "Server", "qps": 50, "burst": 100},
+		map[string]any{"type": "Namespace", "qps": 10, "burst": 20, "cacheSize": 2000},
+	)
+
+	tests := []struct {
+		name      string
+		extraArgs []string
+		plugins   []AdmissionPluginConfig
+		wantErr   bool
+	}{
+		{
+			name: "no plugins",
+		},
+		{
+			name:    "valid",
+			plugins: []AdmissionPluginConfig{validPodSecurity, validEventRateLimit},
+		},
+		{
+			name: "unknown plugin",
+			plugins: []AdmissionPluginConfig{
+				admissionPlugin("ImagePolicyWebhook", "apiserver.config.k8s.io/v1", "ImageReviewConfiguration", nil),
+			},
+		},
+		{
+			name:    "duplicate plugin",
+			plugins: []AdmissionPluginConfig{validPodSecurity, validPodSecurity},
+			wantErr: true,
+		},
+		{
+			name:    "no name",
+			plugins: []AdmissionPluginConfig{admissionPlugin("", "v1", "Foo", nil)},
+			wantErr: true,
+		},
+		{
+			name:    "no configuration",
+			plugins: []AdmissionPluginConfig{{Name: "PodSecurity"}},
+			wantErr: true,
+		},
+		{
+			name: "old PodSecurity version",
+			plugins: []AdmissionPluginConfig{
+				admissionPlugin("PodSecurity", "pod-security.admission.config.k8s.io/v1beta1", "PodSecurityConfiguration", nil),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid PodSecurity level",
+			plugins: []AdmissionPluginConfig{
+				podSecurityPlugin(map[string]any{"defaults": map[string]any{"enforce": "strict"}}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid PodSecurity version",
+			plugins: []AdmissionPluginConfig{
+				podSecurityPlugin(map[string]any{"defaults": map[string]any{"audit-version": "1.30"}}),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "no EventRateLimit limits",
+			plugins: []AdmissionPluginConfig{eventRateLimitPlugin()},
+			wantErr: true,
+		},
+		{
+			name: "invalid EventRateLimit type",
+			plugins: []AdmissionPluginConfig{
+				eventRateLimitPlugin(map[string]any{"type": "Pod", "qps": 1, "burst": 1}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero EventRateLimit qps",
+			plugins: []AdmissionPluginConfig{
+				eventRateLimitPlugin(map[string]any{"type": "User", "burst": 1}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown EventRateLimit field",
+			plugins: []AdmissionPluginConfig{
+				eventRateLimitPlugin(map[string]any{"type": "User", "qps": 1, "burst": 1, "cache": 10}),
+			},
+			wantErr: true,
+		},
+		{
+			name:      "conflicting flag",
+			extraArgs: []string{"--admission-control-config-file=/etc/admission.yaml"},
+			plugins:   []AdmissionPluginConfig{validPodSecurity},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := APIServerParams{
+				ServiceParams:    ServiceParams{ExtraArguments: tt.extraArgs},
+				AdmissionPlugins: tt.plugins,
+			}
+			err := validateAdmissionPlugins(p)
+			if tt.wantErr && err == nil {
+				t.Error("error is expected")
+			}
+			if !tt.wantErr && err != nil {
+				t.Error("unexpected error", err)
+			}
+		})
+	}
+}
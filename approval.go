@@ -0,0 +1,128 @@
+package cke
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ApprovalStatus is the status of an approval.
+type ApprovalStatus string
+
+// Approval statuses.
+const (
+	ApprovalPending  = ApprovalStatus("pending")
+	ApprovalApproved = ApprovalStatus("approved")
+	ApprovalRejected = ApprovalStatus("rejected")
+)
+
+// ApprovalPolicy specifies operations that require manual approval.
+// An operation requires approval if its name is listed in Operations,
+// or if it is decided in one of Phases.
+type ApprovalPolicy struct {
+	Operations []string         `json:"operations,omitempty"`
+	Phases     []OperationPhase `json:"phases,omitempty"`
+}
+
+// Validate validates the policy.
+func (p *ApprovalPolicy) Validate() error {
+	for _, o := range p.Operations {
+		if o == "" {
+			return errors.New("empty operation name")
+		}
+	}
+	for _, ph := range p.Phases {
+		if !ph.IsSuspendable() {
+			return fmt.Errorf("phase %s does not run operations", ph)
+		}
+	}
+	return nil
+}
+
+// Requires returns true if any of ops decided in phase requires approval.
+func (p *ApprovalPolicy) Requires(phase OperationPhase, ops []Operator) bool {
+	if p == nil {
+		return false
+	}
+	if slices.Contains(p.Phases, phase) {
+		return true
+	}
+	for _, op := range ops {
+		if slices.Contains(p.Operations, op.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalOperation is an operation waiting for approval.
+type ApprovalOperation struct {
+	Name    string   `json:"name"`
+	Targets []string `json:"targets"`
+}
+
+// Approval represents a request for approval of the operations decided
+// by CKE.  At most one approval exists at a time.
+// A rejected approval is kept to block the same operations until CKE decides
+// different operations.
+type Approval struct {
+	ID         int64               `json:"id,string"`
+	Status     ApprovalStatus      `json:"status"`
+	Phase      OperationPhase      `json:"phase"`
+	Operations []ApprovalOperation `json:"operations"`
+	CreatedAt  time.Time           `json:"created_at"`
+	DecidedBy  string              `json:"decided_by,omitempty"`
+	DecidedAt  time.Time           `json:"decided_at,omitempty"`
+	// Recorded is true when the rejected operations have been recorded.
+	Recorded bool `json:"recorded,omitempty"`
+}
+
+// NewApproval creates a pending approval for ops decided in phase.
+// The ID is assigned when the approval is registered.
+func NewApproval(phase OperationPhase, ops []Operator) *Approval {
+	a := &Approval{
+		Status:     ApprovalPending,
+		Phase:      phase,
+		Operations: make([]ApprovalOperation, len(ops)),
+		CreatedAt:  time.Now().UTC(),
+	}
+	for i, op := range ops {
+		a.Operations[i] = ApprovalOperation{
+			Name:    op.Name(),
+			Targets: op.Targets(),
+		}
+	}
+	return a
+}
+
+// Matches returns true if the approval is for ops decided in phase.
+func (a *Approval) Matches(phase OperationPhase, ops []Operator) bool {
+	if a.Phase != phase || len(a.Operations) != len(ops) {
+		return false
+	}
+	for i, op := range ops {
+		if a.Operations[i].Name != op.Name() {
+			return false
+		}
+		if !slices.Equal(a.Operations[i].Targets, op.Targets()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Decide approves or rejects the pending approval.
+func (a *Approval) Decide(approve bool, user string) error {
+	if a.Status != ApprovalPending {
+		return fmt.Errorf("approval %d has already been %s", a.ID, a.Status)
+	}
+	if approve {
+		a.Status = ApprovalApproved
+	} else {
+		a.Status = ApprovalRejected
+	}
+	a.DecidedBy = user
+	a.DecidedAt = time.Now().UTC()
+	return nil
+}
@@ -0,0 +1,87 @@
+package cke
+
+import (
+	"testing"
+)
+
+type testOp struct {
+	name    string
+	targets []string
+}
+
+func (o testOp) Name() string           { return o.name }
+func (o testOp) NextCommand() Commander { return nil }
+func (o testOp) Targets() []string      { return o.targets }
+
+func TestApprovalPolicy(t *testing.T) {
+	ops := []Operator{
+		testOp{"etcd-add-member", []string{"10.0.0.11"}},
+		testOp{"resource-apply", []string{"ConfigMap/default/foo"}},
+	}
+
+	var nilPolicy *ApprovalPolicy
+	if nilPolicy.Requires(PhaseEtcdMaintain, ops) {
+		t.Error("nil policy should not require approval")
+	}
+
+	p := &ApprovalPolicy{Operations: []string{"etcd-add-member"}}
+	if err := p.Validate(); err != nil {
+		t.Error(err)
+	}
+	if !p.Requires(PhaseEtcdMaintain, ops) {
+		t.Error("etcd-add-member should require approval")
+	}
+	if p.Requires(PhaseK8sMaintain, ops[1:]) {
+		t.Error("resource-apply should not require approval")
+	}
+
+	p = &ApprovalPolicy{Phases: []OperationPhase{PhaseK8sMaintain}}
+	if err := p.Validate(); err != nil {
+		t.Error(err)
+	}
+	if !p.Requires(PhaseK8sMaintain, ops[1:]) {
+		t.Error("k8s-maintain should require approval")
+	}
+
+	p = &ApprovalPolicy{Phases: []OperationPhase{PhaseCompleted}}
+	if err := p.Validate(); err == nil {
+		t.Error("completed phase should be invalid")
+	}
+	p = &ApprovalPolicy{Operations: []string{""}}
+	if err := p.Validate(); err == nil {
+		t.Error("empty operation name should be invalid")
+	}
+}
+
+func TestApproval(t *testing.T) {
+	ops := []Operator{
+		testOp{"etcd-add-member", []string{"10.0.0.11"}},
+	}
+
+	a := NewApproval(PhaseEtcdMaintain, ops)
+	if a.Status != ApprovalPending {
+		t.Error("new approval should be pending")
+	}
+	if !a.Matches(PhaseEtcdMaintain, ops) {
+		t.Error("approval should match the operations")
+	}
+	if a.Matches(PhaseK8sMaintain, ops) {
+		t.Error("approval should not match another phase")
+	}
+	if a.Matches(PhaseEtcdMaintain, []Operator{testOp{"etcd-add-member", []string{"10.0.0.12"}}}) {
+		t.Error("approval should not match other targets")
+	}
+	if a.Matches(PhaseEtcdMaintain, nil) {
+		t.Error("approval should not match empty operations")
+	}
+
+	if err := a.Decide(false, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != ApprovalRejected || a.DecidedBy != "alice" || a.DecidedAt.IsZero() {
+		t.Error("unexpected approval:", a)
+	}
+	if err := a.Decide(true, "bob"); err == nil {
+		t.Error("decided approval should not be decided again")
+	}
+}
@@ -0,0 +1,153 @@
+package cke
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Modes of the audit webhook backend.
+const (
+	AuditWebhookModeBatch          = "batch"
+	AuditWebhookModeBlocking       = "blocking"
+	AuditWebhookModeBlockingStrict = "blocking-strict"
+)
+
+// Default values for AuditWebhookParams.
+const (
+	DefaultAuditWebhookMode                  = AuditWebhookModeBatch
+	DefaultAuditWebhookInitialBackoffSeconds = 10
+	DefaultAuditWebhookBatchBufferSize       = 10000
+	DefaultAuditWebhookBatchMaxSize          = 400
+	DefaultAuditWebhookBatchMaxWaitSeconds   = 30
+)
+
+// AuditWebhookParams is a set of parameters for the audit webhook backend of kube-apiserver.
+type AuditWebhookParams struct {
+	// Server is the URL of the webhook.
+	Server string `json:"server"`
+	// CACert is the PEM encoded CA certificate to verify the webhook server.
+	CACert string `json:"ca_cert"`
+
+	Mode                  string `json:"mode,omitempty"`
+	InitialBackoffSeconds *int   `json:"initial_backoff_seconds,omitempty"`
+	BatchBufferSize       *int   `json:"batch_buffer_size,omitempty"`
+	BatchMaxSize          *int   `json:"batch_max_size,omitempty"`
+	BatchMaxWaitSeconds   *int   `json:"batch_max_wait_seconds,omitempty"`
+}
+
+// GetMode returns the mode of the webhook backend.
+func (w *AuditWebhookParams) GetMode() string {
+	if w.Mode == "" {
+		return DefaultAuditWebhookMode
+	}
+	return w.Mode
+}
+
+// GetInitialBackoffSeconds returns the wait time before retrying the first failed request.
+func (w *AuditWebhookParams) GetInitialBackoffSeconds() int {
+	if w.InitialBackoffSeconds == nil {
+		return DefaultAuditWebhookInitialBackoffSeconds
+	}
+	return *w.InitialBackoffSeconds
+}
+
+// GetBatchBufferSize returns the number of events to buffer before batching.
+func (w *AuditWebhookParams) GetBatchBufferSize() int {
+	if w.BatchBufferSize == nil {
+		return DefaultAuditWebhookBatchBufferSize
+	}
+	return *w.BatchBufferSize
+}
+
+// GetBatchMaxSize returns the maximum number of events in a batch.
+func (w *AuditWebhookParams) GetBatchMaxSize() int {
+	if w.BatchMaxSize == nil {
+		return DefaultAuditWebhookBatchMaxSize
+	}
+	return *w.BatchMaxSize
+}
+
+// GetBatchMaxWaitSeconds returns the time to wait before sending a batch that is not full.
+func (w *AuditWebhookParams) GetBatchMaxWaitSeconds() int {
+	if w.BatchMaxWaitSeconds == nil {
+		return DefaultAuditWebhookBatchMaxWaitSeconds
+	}
+	return *w.BatchMaxWaitSeconds
+}
+
+// AuditEnabled returns true if any audit backend is enabled.
+func (p APIServerParams) AuditEnabled() bool {
+	return p.AuditLogEnabled || p.AuditWebhook != nil
+}
+
+func validateAudit(p APIServerParams) error {
+	for _, arg := range p.ExtraArguments {
+		switch {
+		case p.AuditWebhook != nil && strings.HasPrefix(arg, "--audit-webhook-"):
+			return fmt.Errorf("%s cannot be used together with audit_webhook", arg)
+		case p.AuditLogMaxSize != nil && strings.HasPrefix(arg, "--audit-log-maxsize"),
+			p.AuditLogMaxBackups != nil && strings.HasPrefix(arg, "--audit-log-maxbackup"),
+			p.AuditLogMaxAge != nil && strings.HasPrefix(arg, "--audit-log-maxage"):
+			return fmt.Errorf("%s cannot be used together with audit log rotation options", arg)
+		}
+	}
+
+	rotation := map[string]*int{
+		"audit_log_max_size":    p.AuditLogMaxSize,
+		"audit_log_max_backups": p.AuditLogMaxBackups,
+		"audit_log_max_age":     p.AuditLogMaxAge,
+	}
+	for name, v := range rotation {
+		if v == nil {
+			continue
+		}
+		if !p.AuditLogEnabled || p.AuditLogPath == "" || p.AuditLogPath == "-" {
+			return fmt.Errorf("%s requires audit log to be written to a file", name)
+		}
+		if *v < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+
+	w := p.AuditWebhook
+	if w == nil {
+		return nil
+	}
+	u, err := url.Parse(w.Server)
+	if err != nil {
+		return fmt.Errorf("invalid audit webhook server: %w", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("audit webhook server must be an https URL: %s", w.Server)
+	}
+	block, _ := pem.Decode([]byte(w.CACert))
+	if block == nil {
+		return errors.New("invalid PEM data in audit webhook ca_cert")
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return fmt.Errorf("invalid audit webhook ca_cert: %w", err)
+	}
+
+	switch w.GetMode() {
+	case AuditWebhookModeBatch, AuditWebhookModeBlocking, AuditWebhookModeBlockingStrict:
+	default:
+		return fmt.Errorf("unknown audit webhook mode: %s", w.Mode)
+	}
+	if w.GetInitialBackoffSeconds() <= 0 {
+		return errors.New("initial_backoff_seconds must be positive")
+	}
+	if w.GetBatchBufferSize() <= 0 {
+		return errors.New("batch_buffer_size must be positive")
+	}
+	if w.GetBatchMaxSize() <= 0 {
+		return errors.New("batch_max_size must be positive")
+	}
+	if w.GetBatchMaxWaitSeconds() <= 0 {
+		return errors.New("batch_max_wait_seconds must be positive")
+	}
+	return nil
+}
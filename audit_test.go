@@ -0,0 +1,131 @@
+package cke
+
+import "testing"
+
+func TestValidateAudit(t *testing.T) {
+	ca := testCACert(t)
+	webhook := func(f func(w *AuditWebhookParams)) *AuditWebhookParams {
+		w := &AuditWebhookParams{
+			Server: "https://audit.example.com/events",
+			CACert: ca,
+		}
+		if f != nil {
+			f(w)
+		}
+		return w
+	}
+
+	tests := []struct {
+		name    string
+		params  APIServerParams
+		wantErr bool
+	}{
+		{
+			name: "no audit",
+		},
+		{
+			name: "log rotation",
+			params: APIServerParams{
+				AuditLogEnabled:    true,
+				AuditLogPath:       "/var/log/audit/audit.log",
+				AuditLogMaxSize:    new(100),
+				AuditLogMaxBackups: new(10),
+				AuditLogMaxAge:     new(0),
+			},
+		},
+		{
+			name: "log rotation for stdout",
+			params: APIServerParams{
+				AuditLogEnabled: true,
+				AuditLogMaxSize: new(100),
+			},
+			wantErr: true,
+		},
+		{
+			name: "log rotation without audit log",
+			params: APIServerParams{
+				AuditLogPath:   "/var/log/audit/audit.log",
+				AuditLogMaxAge: new(7),
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative max backups",
+			params: APIServerParams{
+				AuditLogEnabled:    true,
+				AuditLogPath:       "/var/log/audit/audit.log",
+				AuditLogMaxBackups: new(-1),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "webhook",
+			params: APIServerParams{AuditWebhook: webhook(nil)},
+		},
+		{
+			name: "blocking webhook",
+			params: APIServerParams{AuditWebhook: webhook(func(w *AuditWebhookParams) {
+				w.Mode = AuditWebhookModeBlockingStrict
+				w.InitialBackoffSeconds = new(1)
+			})},
+		},
+		{
+			name: "unknown mode",
+			params: APIServerParams{AuditWebhook: webhook(func(w *AuditWebhookParams) {
+				w.Mode = "async"
+			})},
+			wantErr: true,
+		},
+		{
+			name: "http server",
+			params: APIServerParams{AuditWebhook: webhook(func(w *AuditWebhookParams) {
+				w.Server = "http://audit.example.com/events"
+			})},
+			wantErr: true,
+		},
+		{
+			name: "invalid CA",
+			params: APIServerParams{AuditWebhook: webhook(func(w *AuditWebhookParams) {
+				w.CACert = "foo"
+			})},
+			wantErr: true,
+		},
+		{
+			name: "zero batch size",
+			params: APIServerParams{AuditWebhook: webhook(func(w *AuditWebhookParams) {
+				w.BatchMaxSize = new(0)
+			})},
+			wantErr: true,
+		},
+		{
+			name: "conflicting webhook flag",
+			params: APIServerParams{
+				ServiceParams: ServiceParams{ExtraArguments: []string{"--audit-webhook-batch-max-size=10"}},
+				AuditWebhook:  webhook(nil),
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflicting rotation flag",
+			params: APIServerParams{
+				ServiceParams:   ServiceParams{ExtraArguments: []string{"--audit-log-maxsize=10"}},
+				AuditLogEnabled: true,
+				AuditLogPath:    "/var/log/audit/audit.log",
+				AuditLogMaxSize: new(100),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAudit(tt.params)
+			if tt.wantErr && err == nil {
+				t.Error("error is expected")
+			}
+			if !tt.wantErr && err != nil {
+				t.Error("unexpected error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package cke
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// authenticationConfigFlags are the kube-apiserver flags that conflict with
+// --authentication-config.
+var authenticationConfigFlags = []string{
+	"--authentication-config",
+	"--oidc-",
+}
+
+// validateAuthenticationConfig validates AuthenticationConfig.
+// Detailed validation such as CEL expressions is left to kube-apiserver.
+func validateAuthenticationConfig(p APIServerParams) error {
+	cfg, err := p.GetAuthenticationConfig()
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return nil
+	}
+
+	for _, arg := range p.ExtraArguments {
+		for _, flag := range authenticationConfigFlags {
+			if strings.HasPrefix(arg, flag) {
+				return fmt.Errorf("%s cannot be used together with authentication_config", arg)
+			}
+		}
+		if cfg.Anonymous != nil && strings.HasPrefix(arg, "--anonymous-auth") {
+			return fmt.Errorf("%s cannot be used together with anonymous in authentication_config", arg)
+		}
+	}
+
+	issuers := make(map[string]bool)
+	for i, jwt := range cfg.JWT {
+		u, err := url.Parse(jwt.Issuer.URL)
+		if err != nil {
+			return fmt.Errorf("invalid issuer URL in jwt[%d]: %w", i, err)
+		}
+		if u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("issuer URL in jwt[%d] must be an https URL: %s", i, jwt.Issuer.URL)
+		}
+		if issuers[jwt.Issuer.URL] {
+			return fmt.Errorf("duplicate issuer URL: %s", jwt.Issuer.URL)
+		}
+		issuers[jwt.Issuer.URL] = true
+
+		if len(jwt.Issuer.Audiences) == 0 {
+			return fmt.Errorf("no audiences in jwt[%d]", i)
+		}
+
+		username := jwt.ClaimMappings.Username
+		if (username.Claim == "") == (username.Expression == "") {
+			return fmt.Errorf("exactly one of claim or expression must be set to claimMappings.username in jwt[%d]", i)
+		}
+	}
+
+	if len(cfg.JWT) == 0 && cfg.Anonymous == nil {
+		return errors.New("authentication_config has neither jwt nor anonymous")
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package cke
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+)
+
+func authenticationConfig(fields map[string]any) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: fields}
+	u.SetGroupVersionKind(apiserverv1.SchemeGroupVersion.WithKind("AuthenticationConfiguration"))
+	return u
+}
+
+func jwtAuthenticator(issuer string, audiences []any, username map[string]any) map[string]any {
+	return map[string]any{
+		"issuer": map[string]any{
+			"url":       issuer,
+			"audiences": audiences,
+		},
+		"claimMappings": map[string]any{
+			"username": username,
+		},
+	}
+}
+
+func TestValidateAuthenticationConfig(t *testing.T) {
+	valid := jwtAuthenticator("https://issuer.example.com", []any{"cke"}, map[string]any{"claim": "email"})
+
+	tests := []struct {
+		name    string
+		params  APIServerParams
+		wantErr bool
+	}{
+		{
+			name: "no config",
+		},
+		{
+			name: "valid",
+			params: APIServerParams{
+				AuthenticationConfig: authenticationConfig(map[string]any{
+					"jwt": []any{
+						valid,
+						jwtAuthenticator("https://other.example.com/realms/cke", []any{"cke", "kubectl"},
+							map[string]any{"expression": "'oidc:' + claims.sub"}),
+					},
+				}),
+			},
+		},
+		{
+			name: "anonymous only",
+			params: APIServerParams{
+				AuthenticationConfig: authenticationConfig(map[string]any{
+					"anonymous": map[string]any{"enabled": true, "conditions": []any{map[string]any{"path": "/livez"}}},
+				}),
+			},
+		},
+		{
+			name: "empty",
+			params: APIServerParams{
+				AuthenticationConfig: authenticationConfig(map[string]any{}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong version",
+			params: APIServerParams{
+				AuthenticationConfig: func() *unstructured.Unstructured {
+					u := authenticationConfig(map[string]any{"jwt": []any{valid}})
+					u.SetAPIVersion("apiserver.config.k8s.io/v1beta1")
+					return u
+				}(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown field",
+			params: APIServerParams{
+				AuthenticationConfig: authenticationConfig(map[string]any{"jwt": []any{valid}, "oidc": true}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "http issuer",
+			params: APIServerParams{
+				AuthenticationConfig: authenticationConfig(map[string]any{
+					"jwt": []any{jwtAuthenticator("http://issuer.example.com", []any{"cke"}, map[string]any{"claim": "sub"})},
+				}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate issuers",
+			params: APIServerParams{
+				AuthenticationConfig: authenticationConfig(map[string]any{"jwt": []any{valid, valid}}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "no audiences",
+			params: APIServerParams{
+				AuthenticationConfig: authenticationConfig(map[string]any{
+					"jwt": []any{jwtAuthenticator("https://issuer.example.com", nil, map[string]any{"claim": "sub"})},
+				}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "username claim and expression",
+			params: APIServerParams{
+				AuthenticationConfig: authenticationConfig(map[string]any{
+					"jwt": []any{jwtAuthenticator("https://issuer.example.com", []any{"cke"},
+						map[string]any{"claim": "sub", "expression": "claims.sub"})},
+				}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflicting oidc flag",
+			params: APIServerParams{
+				ServiceParams:        ServiceParams{ExtraArguments: []string{"--oidc-issuer-url=https://issuer.example.com"}},
+				AuthenticationConfig: authenticationConfig(map[string]any{"jwt": []any{valid}}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflicting anonymous flag",
+			params: APIServerParams{
+				ServiceParams: ServiceParams{ExtraArguments: []string{"--anonymous-auth=false"}},
+				AuthenticationConfig: authenticationConfig(map[string]any{
+					"jwt":       []any{valid},
+					"anonymous": map[string]any{"enabled": false},
+				}),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAuthenticationConfig(tt.params)
+			if tt.wantErr && err == nil {
+				t.Error("error is expected")
+			}
+			if !tt.wantErr && err != nil {
+				t.Error("unexpected error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package cke
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// authorizationFlags are the kube-apiserver flags that conflict with
+// --authorization-config.
+var authorizationFlags = []string{
+	"--authorization-config",
+	"--authorization-mode",
+	"--authorization-webhook-",
+	"--authorization-policy-file",
+}
+
+// maxAuthorizationWebhookTimeoutSeconds is the maximum timeout allowed by kube-apiserver.
+const maxAuthorizationWebhookTimeoutSeconds = 30
+
+// validateAuthorization validates Authorization.
+// CEL expressions in match conditions are validated by kube-apiserver.
+func validateAuthorization(p APIServerParams) error {
+	a := p.Authorization
+	if a == nil {
+		return nil
+	}
+
+	for _, arg := range p.ExtraArguments {
+		for _, flag := range authorizationFlags {
+			if strings.HasPrefix(arg, flag) {
+				return fmt.Errorf("%s cannot be used together with authorization", arg)
+			}
+		}
+	}
+
+	names := make(map[string]bool)
+	types := make(map[string]int)
+	for i, authz := range a.Authorizers {
+		if msgs := validation.IsDNS1123Subdomain(authz.Name); len(msgs) > 0 {
+			return fmt.Errorf("invalid authorizer name in authorizers[%d]: %s", i, strings.Join(msgs, "; "))
+		}
+		if names[authz.Name] {
+			return fmt.Errorf("duplicate authorizer name: %s", authz.Name)
+		}
+		names[authz.Name] = true
+		types[authz.Type]++
+
+		switch authz.Type {
+		case AuthorizerNode, AuthorizerRBAC:
+			if authz.Webhook != nil {
+				return fmt.Errorf("webhook cannot be specified for %s authorizer %s", authz.Type, authz.Name)
+			}
+		case AuthorizerWebhook:
+			if authz.Webhook == nil {
+				return fmt.Errorf("webhook is required for authorizer %s", authz.Name)
+			}
+			if err := validateAuthorizationWebhook(authz.Webhook); err != nil {
+				return fmt.Errorf("invalid webhook for authorizer %s: %w", authz.Name, err)
+			}
+		default:
+			return fmt.Errorf("unsupported authorizer type: %s", authz.Type)
+		}
+	}
+
+	// kubelets and CKE itself depend on Node and RBAC authorizers.
+	if types[AuthorizerNode] != 1 || types[AuthorizerRBAC] != 1 {
+		return errors.New("authorizers must have exactly one Node and one RBAC authorizer")
+	}
+	return nil
+}
+
+func validateAuthorizationWebhook(w *AuthorizationWebhook) error {
+	u, err := url.Parse(w.Server)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("server must be an https URL: %s", w.Server)
+	}
+
+	if w.CACert == "" {
+		return errors.New("ca_cert is empty")
+	}
+	block, _ := pem.Decode([]byte(w.CACert))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return errors.New("ca_cert is not a PEM encoded certificate")
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return fmt.Errorf("invalid ca_cert: %w", err)
+	}
+
+	timeout := w.GetTimeoutSeconds()
+	if timeout <= 0 || timeout > maxAuthorizationWebhookTimeoutSeconds {
+		return fmt.Errorf("timeout_seconds must be between 1 and %d", maxAuthorizationWebhookTimeoutSeconds)
+	}
+	if w.GetAuthorizedTTLSeconds() <= 0 {
+		return errors.New("authorized_ttl_seconds must be positive")
+	}
+	if w.GetUnauthorizedTTLSeconds() <= 0 {
+		return errors.New("unauthorized_ttl_seconds must be positive")
+	}
+
+	switch w.GetFailurePolicy() {
+	case "NoOpinion", "Deny":
+	default:
+		return fmt.Errorf("unsupported failure_policy: %s", w.FailurePolicy)
+	}
+
+	for i, cond := range w.MatchConditions {
+		if strings.TrimSpace(cond) == "" {
+			return fmt.Errorf("match_conditions[%d] is empty", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package cke
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func testCACert(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestValidateAuthorization(t *testing.T) {
+	ca := testCACert(t)
+	webhook := func(f func(w *AuthorizationWebhook)) Authorizer {
+		w := &AuthorizationWebhook{
+			Server: "https://authz.example.com/authorize",
+			CACert: ca,
+		}
+		if f != nil {
+			f(w)
+		}
+		return Authorizer{Type: AuthorizerWebhook, Name: "external", Webhook: w}
+	}
+	node := Authorizer{Type: AuthorizerNode, Name: "node"}
+	rbac := Authorizer{Type: AuthorizerRBAC, Name: "rbac"}
+
+	tests := []struct {
+		name        string
+		extraArgs   []string
+		authorizers []Authorizer
+		wantErr     bool
+	}{
+		{
+			name:        "webhook before RBAC",
+			authorizers: []Authorizer{node, webhook(nil), rbac},
+		},
+		{
+			name: "full webhook parameters",
+			authorizers: []Authorizer{node, rbac, webhook(func(w *AuthorizationWebhook) {
+				w.TimeoutSeconds = new(30)
+				w.AuthorizedTTLSeconds = new(60)
+				w.UnauthorizedTTLSeconds = new(10)
+				w.FailurePolicy = "Deny"
+				w.MatchConditions = []string{"has(request.resourceAttributes)"}
+			})},
+		},
+		{
+			name:        "no RBAC",
+			authorizers: []Authorizer{node, webhook(nil)},
+			wantErr:     true,
+		},
+		{
+			name:        "two Node authorizers",
+			authorizers: []Authorizer{node, {Type: AuthorizerNode, Name: "node2"}, rbac},
+			wantErr:     true,
+		},
+		{
+			name:        "duplicate names",
+			authorizers: []Authorizer{node, rbac, webhook(nil), webhook(nil)},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid name",
+			authorizers: []Authorizer{node, rbac, {Type: AuthorizerWebhook, Name: "Ext_Authz", Webhook: webhook(nil).Webhook}},
+			wantErr:     true,
+		},
+		{
+			name:        "unsupported type",
+			authorizers: []Authorizer{node, rbac, {Type: "ABAC", Name: "abac"}},
+			wantErr:     true,
+		},
+		{
+			name:        "webhook for RBAC",
+			authorizers: []Authorizer{node, {Type: AuthorizerRBAC, Name: "rbac", Webhook: webhook(nil).Webhook}},
+			wantErr:     true,
+		},
+		{
+			name:        "no webhook",
+			authorizers: []Authorizer{node, rbac, {Type: AuthorizerWebhook, Name: "external"}},
+			wantErr:     true,
+		},
+		{
+			name:        "http server",
+			authorizers: []Authorizer{node, rbac, webhook(func(w *AuthorizationWebhook) { w.Server = "http://authz.example.com" })},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid CA",
+			authorizers: []Authorizer{node, rbac, webhook(func(w *AuthorizationWebhook) { w.CACert = "foo" })},
+			wantErr:     true,
+		},
+		{
+			name:        "too long timeout",
+			authorizers: []Authorizer{node, rbac, webhook(func(w *AuthorizationWebhook) { w.TimeoutSeconds = new(31) })},
+			wantErr:     true,
+		},
+		{
+			name:        "zero TTL",
+			authorizers: []Authorizer{node, rbac, webhook(func(w *AuthorizationWebhook) { w.AuthorizedTTLSeconds = new(0) })},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid failure policy",
+			authorizers: []Authorizer{node, rbac, webhook(func(w *AuthorizationWebhook) { w.FailurePolicy = "Allow" })},
+			wantErr:     true,
+		},
+		{
+			name:        "conflicting flag",
+			extraArgs:   []string{"--authorization-mode=Node,RBAC,Webhook"},
+			authorizers: []Authorizer{node, rbac},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := APIServerParams{
+				ServiceParams: ServiceParams{ExtraArguments: tt.extraArgs},
+				Authorization: &AuthorizationParams{Authorizers: tt.authorizers},
+			}
+			err := validateAuthorization(p)
+			if tt.wantErr && err == nil {
+				t.Error("error is expected")
+			}
+			if !tt.wantErr && err != nil {
+				t.Error("unexpected error", err)
+			}
+		})
+	}
+
+	if err := validateAuthorization(APIServerParams{}); err != nil {
+		t.Error("no authorization should be valid", err)
+	}
+}
@@ -1,6 +1,7 @@
 package cke
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,6 +18,8 @@ import (
 	v1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+	kcmv1alpha1 "k8s.io/kube-controller-manager/config/v1alpha1"
 	proxyv1alpha1 "k8s.io/kube-proxy/config/v1alpha1"
 	schedulerv1 "k8s.io/kube-scheduler/config/v1"
 	kubeletv1beta1 "k8s.io/kubelet/config/v1beta1"
@@ -25,13 +28,14 @@ import (
 
 // Node represents a node in Kubernetes.
 type Node struct {
-	Address      string            `json:"address"`
-	Hostname     string            `json:"hostname"`
-	User         string            `json:"user"`
-	ControlPlane bool              `json:"control_plane"`
-	Annotations  map[string]string `json:"annotations"`
-	Labels       map[string]string `json:"labels"`
-	Taints       []corev1.Taint    `json:"taints"`
+	Address          string            `json:"address"`
+	SecondaryAddress string            `json:"secondary_address,omitempty"`
+	Hostname         string            `json:"hostname"`
+	User             string            `json:"user"`
+	ControlPlane     bool              `json:"control_plane"`
+	Annotations      map[string]string `json:"annotations"`
+	Labels           map[string]string `json:"labels"`
+	Taints           []corev1.Taint    `json:"taints"`
 }
 
 // Nodename returns a hostname or address if hostname is empty
@@ -114,6 +118,122 @@ type APIServerParams struct {
 	AuditLogEnabled bool   `json:"audit_log_enabled"`
 	AuditLogPolicy  string `json:"audit_log_policy"`
 	AuditLogPath    string `json:"audit_log_path"`
+
+	AuditLogMaxSize    *int                `json:"audit_log_max_size,omitempty"`
+	AuditLogMaxBackups *int                `json:"audit_log_max_backups,omitempty"`
+	AuditLogMaxAge     *int                `json:"audit_log_max_age,omitempty"`
+	AuditWebhook       *AuditWebhookParams `json:"audit_webhook,omitempty"`
+
+	AuthenticationConfig *unstructured.Unstructured `json:"authentication_config,omitempty"`
+	Authorization        *AuthorizationParams       `json:"authorization,omitempty"`
+	AdmissionPlugins     []AdmissionPluginConfig    `json:"admission_plugins,omitempty"`
+	EncryptionResources  []string                   `json:"encryption_resources,omitempty"`
+	KMS                  *KMSParams                 `json:"kms,omitempty"`
+}
+
+// AdmissionPluginConfig is a configuration of an admission plugin of kube-apiserver.
+type AdmissionPluginConfig struct {
+	Name          string                     `json:"name"`
+	Configuration *unstructured.Unstructured `json:"configuration"`
+}
+
+// Authorizer types of kube-apiserver.
+const (
+	AuthorizerNode    = "Node"
+	AuthorizerRBAC    = "RBAC"
+	AuthorizerWebhook = "Webhook"
+)
+
+// Default values for authorization webhooks.
+const (
+	DefaultAuthorizationWebhookTimeoutSeconds         = 3
+	DefaultAuthorizationWebhookAuthorizedTTLSeconds   = 300
+	DefaultAuthorizationWebhookUnauthorizedTTLSeconds = 30
+	DefaultAuthorizationWebhookFailurePolicy          = "NoOpinion"
+)
+
+// AuthorizationParams is a set of parameters for authorization of kube-apiserver.
+type AuthorizationParams struct {
+	Authorizers []Authorizer `json:"authorizers"`
+}
+
+// Authorizer is an authorizer of kube-apiserver.
+// Authorizers are consulted in the order of the list.
+type Authorizer struct {
+	Type    string                `json:"type"`
+	Name    string                `json:"name"`
+	Webhook *AuthorizationWebhook `json:"webhook,omitempty"`
+}
+
+// AuthorizationWebhook is a set of parameters for a webhook authorizer.
+type AuthorizationWebhook struct {
+	Server                 string   `json:"server"`
+	CACert                 string   `json:"ca_cert"`
+	TimeoutSeconds         *int     `json:"timeout_seconds,omitempty"`
+	AuthorizedTTLSeconds   *int     `json:"authorized_ttl_seconds,omitempty"`
+	UnauthorizedTTLSeconds *int     `json:"unauthorized_ttl_seconds,omitempty"`
+	FailurePolicy          string   `json:"failure_policy,omitempty"`
+	MatchConditions        []string `json:"match_conditions,omitempty"`
+}
+
+// GetTimeoutSeconds returns the timeout of webhook requests.
+func (w *AuthorizationWebhook) GetTimeoutSeconds() int {
+	if w.TimeoutSeconds == nil {
+		return DefaultAuthorizationWebhookTimeoutSeconds
+	}
+	return *w.TimeoutSeconds
+}
+
+// GetAuthorizedTTLSeconds returns the duration to cache authorized responses.
+func (w *AuthorizationWebhook) GetAuthorizedTTLSeconds() int {
+	if w.AuthorizedTTLSeconds == nil {
+		return DefaultAuthorizationWebhookAuthorizedTTLSeconds
+	}
+	return *w.AuthorizedTTLSeconds
+}
+
+// GetUnauthorizedTTLSeconds returns the duration to cache unauthorized responses.
+func (w *AuthorizationWebhook) GetUnauthorizedTTLSeconds() int {
+	if w.UnauthorizedTTLSeconds == nil {
+		return DefaultAuthorizationWebhookUnauthorizedTTLSeconds
+	}
+	return *w.UnauthorizedTTLSeconds
+}
+
+// GetFailurePolicy returns the failure policy of the webhook.
+func (w *AuthorizationWebhook) GetFailurePolicy() string {
+	if w.FailurePolicy == "" {
+		return DefaultAuthorizationWebhookFailurePolicy
+	}
+	return w.FailurePolicy
+}
+
+// GetAuthenticationConfig decodes AuthenticationConfig.
+// It returns nil if AuthenticationConfig is not given.
+func (p APIServerParams) GetAuthenticationConfig() (*apiserverv1.AuthenticationConfiguration, error) {
+	if p.AuthenticationConfig == nil {
+		return nil, nil
+	}
+
+	if p.AuthenticationConfig.GetAPIVersion() != apiserverv1.SchemeGroupVersion.String() {
+		return nil, fmt.Errorf("unexpected authentication config API version: %s", p.AuthenticationConfig.GetAPIVersion())
+	}
+	if p.AuthenticationConfig.GetKind() != "AuthenticationConfiguration" {
+		return nil, fmt.Errorf("wrong kind for authentication config: %s", p.AuthenticationConfig.GetKind())
+	}
+
+	data, err := json.Marshal(p.AuthenticationConfig)
+	if err != nil {
+		return nil, err
+	}
+	// kube-apiserver decodes the file strictly, so do we.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	cfg := &apiserverv1.AuthenticationConfiguration{}
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid authentication config: %w", err)
+	}
+	return cfg, nil
 }
 
 // CNIConfFile is a config file for CNI plugin deployed on worker nodes by CKE.
@@ -160,6 +280,39 @@ func (p SchedulerParams) MergeConfig(base *schedulerv1.KubeSchedulerConfiguratio
 	return &cfg, nil
 }
 
+// ControllerManagerParams is a set of extra parameters for kube-controller-manager.
+type ControllerManagerParams struct {
+	ServiceParams `json:",inline"`
+	Config        *unstructured.Unstructured `json:"config,omitempty"`
+}
+
+// MergeConfig merges the input struct `base`.
+func (p ControllerManagerParams) MergeConfig(base *kcmv1alpha1.KubeControllerManagerConfiguration) (*kcmv1alpha1.KubeControllerManagerConfiguration, error) {
+	cfg := *base.DeepCopy()
+	if p.Config == nil {
+		return &cfg, nil
+	}
+
+	if p.Config.GetAPIVersion() != kcmv1alpha1.SchemeGroupVersion.String() {
+		return nil, fmt.Errorf("unexpected kube-controller-manager API version: %s", p.Config.GetAPIVersion())
+	}
+	if p.Config.GetKind() != "KubeControllerManagerConfiguration" {
+		return nil, fmt.Errorf("wrong kind for kube-controller-manager config: %s", p.Config.GetKind())
+	}
+
+	data, err := json.Marshal(p.Config)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.TypeMeta = metav1.TypeMeta{}
+	return &cfg, nil
+}
+
 // ProxyParams is a set of extra parameters for kube-proxy.
 type ProxyParams struct {
 	ServiceParams `json:",inline"`
@@ -167,13 +320,14 @@ type ProxyParams struct {
 	Config        *unstructured.Unstructured `json:"config,omitempty"`
 }
 
-// GetMode returns the proxy mode.
-func (p ProxyParams) GetMode() string {
-	mode := p.Config.UnstructuredContent()["Mode"].(string)
-	if len(mode) == 0 {
-		return string(ProxyModeIPVS)
+// GetMode returns the mode in which kube-proxy runs.
+// `p` must be validated beforehand.
+func (p ProxyParams) GetMode() proxyv1alpha1.ProxyMode {
+	cfg, err := p.MergeConfig(&proxyv1alpha1.KubeProxyConfiguration{})
+	if err != nil {
+		panic(err)
 	}
-	return mode
+	return EffectiveProxyMode(cfg)
 }
 
 // MergeConfig merges the input struct with `base`.
@@ -212,15 +366,24 @@ func (p ProxyParams) MergeConfig(base *proxyv1alpha1.KubeProxyConfiguration) (*p
 type ProxyMode string
 
 const (
-	ProxyModeUserspace proxyv1alpha1.ProxyMode = "userspace"
-	ProxyModeIptables  proxyv1alpha1.ProxyMode = "iptables"
-	ProxyModeIPVS      proxyv1alpha1.ProxyMode = "ipvs"
+	ProxyModeIptables proxyv1alpha1.ProxyMode = "iptables"
+	ProxyModeIPVS     proxyv1alpha1.ProxyMode = "ipvs"
+	ProxyModeNFTables proxyv1alpha1.ProxyMode = "nftables"
 )
 
+// EffectiveProxyMode returns the mode in which kube-proxy runs with cfg.
+// kube-proxy runs in iptables mode if the mode is not specified.
+func EffectiveProxyMode(cfg *proxyv1alpha1.KubeProxyConfiguration) proxyv1alpha1.ProxyMode {
+	if len(cfg.Mode) == 0 {
+		return ProxyModeIptables
+	}
+	return cfg.Mode
+}
+
 // ValidateProxyMode validates ProxyMode
 func ValidateProxyMode(mode proxyv1alpha1.ProxyMode) error {
 	switch mode {
-	case ProxyModeUserspace, ProxyModeIptables, ProxyModeIPVS:
+	case ProxyModeIptables, ProxyModeIPVS, ProxyModeNFTables:
 		return nil
 	}
 
@@ -346,14 +509,14 @@ type TrustedRESTMapping struct {
 
 // Options is a set of optional parameters for k8s components.
 type Options struct {
-	Etcd              EtcdParams      `json:"etcd"`
-	Rivers            ServiceParams   `json:"rivers"`
-	EtcdRivers        ServiceParams   `json:"etcd-rivers"`
-	APIServer         APIServerParams `json:"kube-api"`
-	ControllerManager ServiceParams   `json:"kube-controller-manager"`
-	Scheduler         SchedulerParams `json:"kube-scheduler"`
-	Proxy             ProxyParams     `json:"kube-proxy"`
-	Kubelet           KubeletParams   `json:"kubelet"`
+	Etcd              EtcdParams              `json:"etcd"`
+	Rivers            ServiceParams           `json:"rivers"`
+	EtcdRivers        ServiceParams           `json:"etcd-rivers"`
+	APIServer         APIServerParams         `json:"kube-api"`
+	ControllerManager ControllerManagerParams `json:"kube-controller-manager"`
+	Scheduler         SchedulerParams         `json:"kube-scheduler"`
+	Proxy             ProxyParams             `json:"kube-proxy"`
+	Kubelet           KubeletParams           `json:"kubelet"`
 }
 
 // Cluster is a set of configurations for a etcd/Kubernetes cluster.
@@ -363,12 +526,16 @@ type Cluster struct {
 	TaintCP             bool                 `json:"taint_control_plane"`
 	CPTolerations       []string             `json:"control_plane_tolerations"`
 	ServiceSubnet       string               `json:"service_subnet"`
+	ServiceSubnets      []string             `json:"service_subnets,omitempty"`
 	DNSServers          []string             `json:"dns_servers"`
 	DNSService          string               `json:"dns_service"`
 	Reboot              Reboot               `json:"reboot"`
 	Repair              Repair               `json:"repair"`
 	Sabakan             Sabakan              `json:"sabakan"`
 	Options             Options              `json:"options"`
+	FeatureGates        map[string]bool      `json:"feature_gates,omitempty"`
+	Images              *ImageOverrides      `json:"images,omitempty"`
+	NodeGroups          []NodeGroup          `json:"node_groups,omitempty"`
 	TrustedRESTMappings []TrustedRESTMapping `json:"trusted_rest_mappings,omitempty"`
 }
 
@@ -378,7 +545,7 @@ func (c *Cluster) Validate(isTmpl bool) error {
 		return errors.New("cluster name is empty")
 	}
 
-	_, _, err := net.ParseCIDR(c.ServiceSubnet)
+	err := validateServiceSubnets(c)
 	if err != nil {
 		return err
 	}
@@ -390,11 +557,13 @@ func (c *Cluster) Validate(isTmpl bool) error {
 		if err != nil {
 			return err
 		}
-		if _, ok := nodeAddressSet[n.Address]; ok {
-			return errors.New("duplicate node address: " + n.Address)
-		}
-		if !isTmpl {
-			nodeAddressSet[n.Address] = struct{}{}
+		for _, a := range n.Addresses() {
+			if _, ok := nodeAddressSet[a]; ok {
+				return errors.New("duplicate node address: " + a)
+			}
+			if !isTmpl {
+				nodeAddressSet[a] = struct{}{}
+			}
 		}
 	}
 
@@ -432,6 +601,21 @@ func (c *Cluster) Validate(isTmpl bool) error {
 		return err
 	}
 
+	err = validateNodeGroups(c)
+	if err != nil {
+		return err
+	}
+
+	err = validateFeatureGates(c)
+	if err != nil {
+		return err
+	}
+
+	err = validateImageOverrides(c.Images)
+	if err != nil {
+		return err
+	}
+
 	err = validateTrustedRESTMappings(c.TrustedRESTMappings)
 	if err != nil {
 		return err
@@ -471,6 +655,9 @@ func validateNode(n *Node, isTmpl bool, fldPath *field.Path) error {
 			return errors.New("invalid IP address: " + n.Address)
 		}
 	}
+	if err := validateSecondaryAddress(n, isTmpl); err != nil {
+		return err
+	}
 
 	if len(n.User) == 0 {
 		return errors.New("user name is empty")
@@ -636,18 +823,20 @@ func validateRepair(repair Repair) error {
 	return nil
 }
 
-func validateOptions(opts Options) error {
-	v := func(binds []Mount) error {
-		for _, m := range binds {
-			if !filepath.IsAbs(m.Source) {
-				return errors.New("source path must be absolute: " + m.Source)
-			}
-			if !filepath.IsAbs(m.Destination) {
-				return errors.New("destination path must be absolute: " + m.Destination)
-			}
+func validateMounts(binds []Mount) error {
+	for _, m := range binds {
+		if !filepath.IsAbs(m.Source) {
+			return errors.New("source path must be absolute: " + m.Source)
+		}
+		if !filepath.IsAbs(m.Destination) {
+			return errors.New("destination path must be absolute: " + m.Destination)
 		}
-		return nil
 	}
+	return nil
+}
+
+func validateOptions(opts Options) error {
+	v := validateMounts
 
 	err := v(opts.Etcd.ExtraBinds)
 	if err != nil {
@@ -724,10 +913,14 @@ func validateOptions(opts Options) error {
 		}
 	}
 
-	if opts.APIServer.AuditLogEnabled && len(opts.APIServer.AuditLogPolicy) == 0 {
+	if opts.APIServer.AuditEnabled() && len(opts.APIServer.AuditLogPolicy) == 0 {
 		return errors.New("audit_log_policy should not be empty")
 	}
 
+	if err := validateAudit(opts.APIServer); err != nil {
+		return err
+	}
+
 	if len(opts.APIServer.AuditLogPolicy) != 0 {
 		policy := make(map[string]any)
 		err = yaml.Unmarshal([]byte(opts.APIServer.AuditLogPolicy), &policy)
@@ -736,6 +929,30 @@ func validateOptions(opts Options) error {
 		}
 	}
 
+	if err := validateAuthenticationConfig(opts.APIServer); err != nil {
+		return err
+	}
+
+	if err := validateAuthorization(opts.APIServer); err != nil {
+		return err
+	}
+
+	if err := validateAdmissionPlugins(opts.APIServer); err != nil {
+		return err
+	}
+
+	if err := validateEncryptionResources(opts.APIServer.EncryptionResources); err != nil {
+		return err
+	}
+
+	if err := validateKMS(opts.APIServer); err != nil {
+		return err
+	}
+
+	if err := validateControllerManagerConfig(opts.ControllerManager); err != nil {
+		return err
+	}
+
 	if _, err := opts.Scheduler.MergeConfig(&schedulerv1.KubeSchedulerConfiguration{}); err != nil {
 		return err
 	}
@@ -0,0 +1,354 @@
+package cke
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Actions that CKE will take on components after a cluster configuration change.
+const (
+	DiffActionBoot     = "boot"
+	DiffActionRestart  = "restart"
+	DiffActionStop     = "stop"
+	DiffActionNextBoot = "next-boot"
+)
+
+// FieldDiff is a changed field.  Path is a dot-separated list of JSON keys.
+// Old or New is empty if the field is added or removed.
+type FieldDiff struct {
+	Path string `json:"path"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// String implements fmt.Stringer.
+func (d FieldDiff) String() string {
+	o, n := d.Old, d.New
+	if o == "" {
+		o = "(none)"
+	}
+	if n == "" {
+		n = "(none)"
+	}
+	return fmt.Sprintf("%s: %s -> %s", d.Path, o, n)
+}
+
+// NodeDiff is a set of changes of a node that exists in both configurations.
+type NodeDiff struct {
+	Address string      `json:"address"`
+	Changes []FieldDiff `json:"changes"`
+}
+
+// ComponentDiff is a set of option changes of a component.
+type ComponentDiff struct {
+	Component string      `json:"component"`
+	Changes   []FieldDiff `json:"changes"`
+}
+
+// ComponentAction is an action that CKE will take on a component on the nodes.
+//
+// For kubelet, DiffActionRestart means that the running kubelet is updated
+// in-place by KubeletRestartOp.  DiffActionNextBoot means that in_place_update
+// is disabled and the change is applied when kubelet is booted next time.
+type ComponentAction struct {
+	Component string   `json:"component"`
+	Action    string   `json:"action"`
+	Nodes     []string `json:"nodes"`
+}
+
+// ClusterDiff is the semantic difference between two cluster configurations.
+type ClusterDiff struct {
+	AddedNodes   []string          `json:"added_nodes,omitempty"`
+	RemovedNodes []string          `json:"removed_nodes,omitempty"`
+	Nodes        []NodeDiff        `json:"nodes,omitempty"`
+	Settings     []FieldDiff       `json:"settings,omitempty"`
+	Options      []ComponentDiff   `json:"options,omitempty"`
+	Actions      []ComponentAction `json:"actions,omitempty"`
+}
+
+// Empty returns true if there are no differences.
+func (d *ClusterDiff) Empty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 && len(d.Nodes) == 0 &&
+		len(d.Settings) == 0 && len(d.Options) == 0 && len(d.Actions) == 0
+}
+
+// DiffClusters compares two cluster configurations and predicts the actions
+// that CKE will take to reconcile the cluster from current to proposed.
+//
+// The prediction assumes that the cluster is fully reconciled with current.
+func DiffClusters(current, proposed *Cluster) *ClusterDiff {
+	d := &ClusterDiff{}
+
+	curNodes := make(map[string]*Node)
+	for _, n := range current.Nodes {
+		curNodes[n.Address] = n
+	}
+	newNodes := make(map[string]*Node)
+	for _, n := range proposed.Nodes {
+		newNodes[n.Address] = n
+	}
+
+	for _, n := range proposed.Nodes {
+		cn, ok := curNodes[n.Address]
+		if !ok {
+			d.AddedNodes = append(d.AddedNodes, n.Address)
+			continue
+		}
+		if changes := diffJSON(cn, n); len(changes) > 0 {
+			d.Nodes = append(d.Nodes, NodeDiff{Address: n.Address, Changes: changes})
+		}
+	}
+	for _, n := range current.Nodes {
+		if _, ok := newNodes[n.Address]; !ok {
+			d.RemovedNodes = append(d.RemovedNodes, n.Address)
+		}
+	}
+
+	curSettings, newSettings := *current, *proposed
+	curSettings.Nodes, newSettings.Nodes = nil, nil
+	curSettings.Options, newSettings.Options = Options{}, Options{}
+	d.Settings = diffJSON(&curSettings, &newSettings)
+
+	optChanges := diffJSON(current.Options, proposed.Options)
+	for _, c := range optChanges {
+		component, rest, _ := strings.Cut(c.Path, ".")
+		c.Path = rest
+		if len(d.Options) == 0 || d.Options[len(d.Options)-1].Component != component {
+			d.Options = append(d.Options, ComponentDiff{Component: component})
+		}
+		last := &d.Options[len(d.Options)-1]
+		last.Changes = append(last.Changes, c)
+	}
+
+	d.Actions = predictActions(current, proposed, curNodes)
+	return d
+}
+
+func predictActions(current, proposed *Cluster, curNodes map[string]*Node) []ComponentAction {
+	var actions []ComponentAction
+	add := func(component, action string, nodes []string) {
+		if len(nodes) > 0 {
+			actions = append(actions, ComponentAction{Component: component, Action: action, Nodes: nodes})
+		}
+	}
+
+	cpChanged := !slices.Equal(controlPlaneAddresses(current), controlPlaneAddresses(proposed))
+	nameChanged := current.Name != proposed.Name
+	subnetChanged := !slices.Equal(current.GetServiceSubnets(), proposed.GetServiceSubnets())
+	gatesChanged := !maps.Equal(current.FeatureGates, proposed.FeatureGates)
+	imageChanged := func(img Image) bool {
+		return current.Images.Resolve(img) != proposed.Images.Resolve(img)
+	}
+
+	var newNodes, keptNodes, newCPs, keptCPs, demotedCPs, readdressedCPs []string
+	for _, n := range proposed.Nodes {
+		cn, ok := curNodes[n.Address]
+		switch {
+		case !ok:
+			newNodes = append(newNodes, n.Address)
+			if n.ControlPlane {
+				newCPs = append(newCPs, n.Address)
+			}
+			continue
+		case n.ControlPlane && cn.ControlPlane:
+			keptCPs = append(keptCPs, n.Address)
+			if cn.SecondaryAddress != n.SecondaryAddress {
+				readdressedCPs = append(readdressedCPs, n.Address)
+			}
+		case n.ControlPlane:
+			newCPs = append(newCPs, n.Address)
+		case cn.ControlPlane:
+			demotedCPs = append(demotedCPs, n.Address)
+		}
+		keptNodes = append(keptNodes, n.Address)
+	}
+
+	restartCPs := func(changed bool) []string {
+		if changed {
+			return keptCPs
+		}
+		return nil
+	}
+
+	add("etcd", DiffActionBoot, newCPs)
+	if imageChanged(EtcdImage) || !reflect.DeepEqual(current.Options.Etcd, proposed.Options.Etcd) {
+		add("etcd", DiffActionRestart, keptCPs)
+	} else {
+		// etcd advertises the secondary address as a client URL.
+		add("etcd", DiffActionRestart, readdressedCPs)
+	}
+	add("etcd", DiffActionStop, demotedCPs)
+
+	add("etcd-rivers", DiffActionBoot, newCPs)
+	add("etcd-rivers", DiffActionRestart, restartCPs(cpChanged || imageChanged(ToolsImage) || !current.Options.EtcdRivers.Equal(proposed.Options.EtcdRivers)))
+
+	add("rivers", DiffActionBoot, newNodes)
+	if cpChanged || imageChanged(ToolsImage) || !current.Options.Rivers.Equal(proposed.Options.Rivers) {
+		add("rivers", DiffActionRestart, keptNodes)
+	}
+
+	// The KMS plugin may also be kept running by the encryption status, which is not known here.
+	curKMS, newKMS := current.Options.APIServer.KMS, proposed.Options.APIServer.KMS
+	switch {
+	case newKMS == nil:
+		if curKMS != nil {
+			add("kms-plugin", DiffActionStop, demotedCPs)
+		}
+	case curKMS == nil:
+		add("kms-plugin", DiffActionBoot, append(slices.Clone(newCPs), keptCPs...))
+	default:
+		add("kms-plugin", DiffActionBoot, newCPs)
+		add("kms-plugin", DiffActionRestart, restartCPs(imageChanged(ToolsImage) || !curKMS.ServiceParams.Equal(newKMS.ServiceParams)))
+		add("kms-plugin", DiffActionStop, demotedCPs)
+	}
+
+	k8sImageChanged := imageChanged(KubernetesImage)
+	curDomain := clusterDomain(current)
+	newDomain := clusterDomain(proposed)
+
+	add("kube-apiserver", DiffActionBoot, newCPs)
+	add("kube-apiserver", DiffActionRestart, restartCPs(k8sImageChanged || subnetChanged || gatesChanged || curDomain != newDomain ||
+		!reflect.DeepEqual(current.Options.APIServer, proposed.Options.APIServer)))
+	add("kube-apiserver", DiffActionStop, demotedCPs)
+
+	add("kube-controller-manager", DiffActionBoot, newCPs)
+	add("kube-controller-manager", DiffActionRestart, restartCPs(k8sImageChanged || nameChanged || subnetChanged || gatesChanged ||
+		!reflect.DeepEqual(current.Options.ControllerManager, proposed.Options.ControllerManager)))
+	add("kube-controller-manager", DiffActionStop, demotedCPs)
+
+	add("kube-scheduler", DiffActionBoot, newCPs)
+	add("kube-scheduler", DiffActionRestart, restartCPs(k8sImageChanged || gatesChanged || !reflect.DeepEqual(current.Options.Scheduler, proposed.Options.Scheduler)))
+	add("kube-scheduler", DiffActionStop, demotedCPs)
+
+	var kubeletUpdated, proxyUpdated []string
+	for _, n := range proposed.Nodes {
+		cn, ok := curNodes[n.Address]
+		if !ok {
+			continue
+		}
+		if k8sImageChanged || cn.Hostname != n.Hostname || cn.SecondaryAddress != n.SecondaryAddress ||
+			!jsonEqual(current.KubeletParamsFor(cn), proposed.KubeletParamsFor(n)) {
+			kubeletUpdated = append(kubeletUpdated, n.Address)
+		}
+		if k8sImageChanged || cn.Hostname != n.Hostname || cn.SecondaryAddress != n.SecondaryAddress ||
+			!jsonEqual(current.ProxyParamsFor(cn), proposed.ProxyParamsFor(n)) {
+			proxyUpdated = append(proxyUpdated, n.Address)
+		}
+	}
+
+	add("kubelet", DiffActionBoot, newNodes)
+	if proposed.Options.Kubelet.InPlaceUpdate {
+		add("kubelet", DiffActionRestart, kubeletUpdated)
+	} else {
+		add("kubelet", DiffActionNextBoot, kubeletUpdated)
+	}
+
+	switch {
+	case proposed.Options.Proxy.Disable && !current.Options.Proxy.Disable:
+		add("kube-proxy", DiffActionStop, keptNodes)
+	case proposed.Options.Proxy.Disable:
+	case current.Options.Proxy.Disable:
+		add("kube-proxy", DiffActionBoot, append(slices.Clone(newNodes), keptNodes...))
+	default:
+		add("kube-proxy", DiffActionBoot, newNodes)
+		add("kube-proxy", DiffActionRestart, proxyUpdated)
+	}
+
+	return actions
+}
+
+func controlPlaneAddresses(c *Cluster) []string {
+	var addrs []string
+	for _, n := range c.Nodes {
+		if n.ControlPlane {
+			addrs = append(addrs, n.Address)
+		}
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// clusterDomain returns the cluster domain configured in KubeletConfiguration, or empty if not set.
+func clusterDomain(c *Cluster) string {
+	if c.Options.Kubelet.Config == nil {
+		return ""
+	}
+	domain, _, _ := unstructured.NestedString(c.Options.Kubelet.Config.Object, "clusterDomain")
+	return domain
+}
+
+func jsonEqual(a, b any) bool {
+	return len(diffJSON(a, b)) == 0
+}
+
+// diffJSON compares the JSON representations of a and b.
+// Objects are compared recursively, and other values including arrays are compared as a whole.
+func diffJSON(a, b any) []FieldDiff {
+	oldFields := make(map[string]string)
+	newFields := make(map[string]string)
+	flattenJSON(toJSONValue(a), "", oldFields)
+	flattenJSON(toJSONValue(b), "", newFields)
+
+	paths := make(map[string]bool)
+	for k := range oldFields {
+		paths[k] = true
+	}
+	for k := range newFields {
+		paths[k] = true
+	}
+
+	var diffs []FieldDiff
+	for p := range paths {
+		if oldFields[p] != newFields[p] {
+			diffs = append(diffs, FieldDiff{Path: p, Old: oldFields[p], New: newFields[p]})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+func toJSONValue(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func flattenJSON(v any, prefix string, out map[string]string) {
+	if m, ok := v.(map[string]any); ok && len(m) > 0 {
+		for k, child := range m {
+			p := k
+			if prefix != "" {
+				p = prefix + "." + k
+			}
+			flattenJSON(child, p, out)
+		}
+		return
+	}
+
+	// null, empty objects, and empty arrays are regarded as unset.
+	switch v := v.(type) {
+	case nil:
+		return
+	case map[string]any:
+		return
+	case []any:
+		if len(v) == 0 {
+			return
+		}
+	}
+	data, _ := json.Marshal(v)
+	out[prefix] = string(data)
+}
@@ -0,0 +1,274 @@
+package cke
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newDiffCluster() *Cluster {
+	return &Cluster{
+		Name:          "test",
+		ServiceSubnet: "10.0.0.0/14",
+		Nodes: []*Node{
+			{Address: "10.0.0.1", User: "cybozu", ControlPlane: true},
+			{Address: "10.0.0.2", User: "cybozu", ControlPlane: true},
+			{Address: "10.0.0.3", User: "cybozu", ControlPlane: true},
+			{Address: "10.0.0.4", User: "cybozu", Labels: map[string]string{"role": "storage"}},
+			{Address: "10.0.0.5", User: "cybozu"},
+		},
+		Options: Options{
+			Kubelet: KubeletParams{
+				ServiceParams: ServiceParams{ExtraArguments: []string{"--v=2"}},
+			},
+		},
+	}
+}
+
+func TestDiffClusters(t *testing.T) {
+	tests := []struct {
+		name         string
+		modify       func(c *Cluster)
+		wantNodes    []NodeDiff
+		wantSettings []FieldDiff
+		wantOptions  []ComponentDiff
+		wantActions  []ComponentAction
+		wantAdded    []string
+		wantRemoved  []string
+	}{
+		{
+			name:   "no changes",
+			modify: func(c *Cluster) {},
+		},
+		{
+			name: "node attributes",
+			modify: func(c *Cluster) {
+				c.Nodes[3].Labels["role"] = "compute"
+				c.Nodes[4].Taints = []corev1.Taint{{Key: "foo", Effect: corev1.TaintEffectNoSchedule}}
+			},
+			wantNodes: []NodeDiff{
+				{Address: "10.0.0.4", Changes: []FieldDiff{{Path: "labels.role", Old: `"storage"`, New: `"compute"`}}},
+				{Address: "10.0.0.5", Changes: []FieldDiff{{Path: "taints", New: `[{"effect":"NoSchedule","key":"foo"}]`}}},
+			},
+		},
+		{
+			name: "hostname",
+			modify: func(c *Cluster) {
+				c.Nodes[4].Hostname = "node5"
+			},
+			wantNodes: []NodeDiff{
+				{Address: "10.0.0.5", Changes: []FieldDiff{{Path: "hostname", Old: `""`, New: `"node5"`}}},
+			},
+			wantActions: []ComponentAction{
+				{Component: "kubelet", Action: DiffActionNextBoot, Nodes: []string{"10.0.0.5"}},
+				{Component: "kube-proxy", Action: DiffActionRestart, Nodes: []string{"10.0.0.5"}},
+			},
+		},
+		{
+			name: "add and remove nodes",
+			modify: func(c *Cluster) {
+				c.Nodes = append(c.Nodes[:4], &Node{Address: "10.0.0.6", User: "cybozu"})
+			},
+			wantAdded:   []string{"10.0.0.6"},
+			wantRemoved: []string{"10.0.0.5"},
+			wantActions: []ComponentAction{
+				{Component: "rivers", Action: DiffActionBoot, Nodes: []string{"10.0.0.6"}},
+				{Component: "kubelet", Action: DiffActionBoot, Nodes: []string{"10.0.0.6"}},
+				{Component: "kube-proxy", Action: DiffActionBoot, Nodes: []string{"10.0.0.6"}},
+			},
+		},
+		{
+			name: "control plane change",
+			modify: func(c *Cluster) {
+				c.Nodes[2].ControlPlane = false
+				c.Nodes[3].ControlPlane = true
+			},
+			wantNodes: []NodeDiff{
+				{Address: "10.0.0.3", Changes: []FieldDiff{{Path: "control_plane", Old: "true", New: "false"}}},
+				{Address: "10.0.0.4", Changes: []FieldDiff{{Path: "control_plane", Old: "false", New: "true"}}},
+			},
+			wantActions: []ComponentAction{
+				{Component: "etcd", Action: DiffActionBoot, Nodes: []string{"10.0.0.4"}},
+				{Component: "etcd", Action: DiffActionStop, Nodes: []string{"10.0.0.3"}},
+				{Component: "etcd-rivers", Action: DiffActionBoot, Nodes: []string{"10.0.0.4"}},
+				{Component: "etcd-rivers", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2"}},
+				{Component: "rivers", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+				{Component: "kube-apiserver", Action: DiffActionBoot, Nodes: []string{"10.0.0.4"}},
+				{Component: "kube-apiserver", Action: DiffActionStop, Nodes: []string{"10.0.0.3"}},
+				{Component: "kube-controller-manager", Action: DiffActionBoot, Nodes: []string{"10.0.0.4"}},
+				{Component: "kube-controller-manager", Action: DiffActionStop, Nodes: []string{"10.0.0.3"}},
+				{Component: "kube-scheduler", Action: DiffActionBoot, Nodes: []string{"10.0.0.4"}},
+				{Component: "kube-scheduler", Action: DiffActionStop, Nodes: []string{"10.0.0.3"}},
+			},
+		},
+		{
+			name: "service subnet",
+			modify: func(c *Cluster) {
+				c.ServiceSubnet = "10.4.0.0/16"
+			},
+			wantSettings: []FieldDiff{{Path: "service_subnet", Old: `"10.0.0.0/14"`, New: `"10.4.0.0/16"`}},
+			wantActions: []ComponentAction{
+				{Component: "kube-apiserver", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kube-controller-manager", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+			},
+		},
+		{
+			name: "dual-stack",
+			modify: func(c *Cluster) {
+				c.ServiceSubnet = ""
+				c.ServiceSubnets = []string{"10.0.0.0/14", "fd00::/108"}
+				c.Nodes[0].SecondaryAddress = "fd01::1"
+				c.Nodes[4].SecondaryAddress = "fd01::5"
+			},
+			wantNodes: []NodeDiff{
+				{Address: "10.0.0.1", Changes: []FieldDiff{{Path: "secondary_address", New: `"fd01::1"`}}},
+				{Address: "10.0.0.5", Changes: []FieldDiff{{Path: "secondary_address", New: `"fd01::5"`}}},
+			},
+			wantSettings: []FieldDiff{
+				{Path: "service_subnet", Old: `"10.0.0.0/14"`, New: `""`},
+				{Path: "service_subnets", New: `["10.0.0.0/14","fd00::/108"]`},
+			},
+			wantActions: []ComponentAction{
+				{Component: "etcd", Action: DiffActionRestart, Nodes: []string{"10.0.0.1"}},
+				{Component: "kube-apiserver", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kube-controller-manager", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kubelet", Action: DiffActionNextBoot, Nodes: []string{"10.0.0.1", "10.0.0.5"}},
+				{Component: "kube-proxy", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.5"}},
+			},
+		},
+		{
+			name: "feature gates",
+			modify: func(c *Cluster) {
+				c.FeatureGates = map[string]bool{"InPlacePodVerticalScaling": false}
+			},
+			wantSettings: []FieldDiff{
+				{Path: "feature_gates.InPlacePodVerticalScaling", New: "false"},
+			},
+			wantActions: []ComponentAction{
+				{Component: "kube-apiserver", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kube-controller-manager", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kube-scheduler", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kubelet", Action: DiffActionNextBoot, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+				{Component: "kube-proxy", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+			},
+		},
+		{
+			name: "kubelet in-place update",
+			modify: func(c *Cluster) {
+				c.Options.Kubelet.ExtraArguments = []string{"--v=4"}
+				c.Options.Kubelet.InPlaceUpdate = true
+			},
+			wantOptions: []ComponentDiff{
+				{Component: "kubelet", Changes: []FieldDiff{
+					{Path: "extra_args", Old: `["--v=2"]`, New: `["--v=4"]`},
+					{Path: "in_place_update", Old: "false", New: "true"},
+				}},
+			},
+			wantActions: []ComponentAction{
+				{Component: "kubelet", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+			},
+		},
+		{
+			name: "node group",
+			modify: func(c *Cluster) {
+				c.NodeGroups = []NodeGroup{{
+					Name:    "storage",
+					Nodes:   []string{"10.0.0.4"},
+					Kubelet: &KubeletOverride{ServiceParams: ServiceParams{ExtraArguments: []string{"--v=4"}}},
+				}}
+			},
+			wantSettings: []FieldDiff{
+				{Path: "node_groups", New: `[{"kubelet":{"extra_args":["--v=4"],"extra_binds":null,"extra_env":null},"name":"storage","nodes":["10.0.0.4"]}]`},
+			},
+			wantActions: []ComponentAction{
+				{Component: "kubelet", Action: DiffActionNextBoot, Nodes: []string{"10.0.0.4"}},
+			},
+		},
+		{
+			name: "image override",
+			modify: func(c *Cluster) {
+				c.Images = &ImageOverrides{Overrides: map[string]string{
+					KubernetesImage.Name(): "ghcr.io/cybozu/kubernetes@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				}}
+			},
+			wantSettings: []FieldDiff{
+				{Path: "images.overrides." + KubernetesImage.Name(), New: `"ghcr.io/cybozu/kubernetes@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"`},
+			},
+			wantActions: []ComponentAction{
+				{Component: "kube-apiserver", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kube-controller-manager", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kube-scheduler", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kubelet", Action: DiffActionNextBoot, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+				{Component: "kube-proxy", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+			},
+		},
+		{
+			name: "registry mirror and KMS",
+			modify: func(c *Cluster) {
+				c.Images = &ImageOverrides{RegistryMirrors: map[string]string{"ghcr.io": "mirror.example.com/ghcr"}}
+				timeout := 5
+				c.Options.APIServer.KMS = &KMSParams{TimeoutSeconds: &timeout}
+			},
+			wantSettings: []FieldDiff{
+				{Path: "images.registry_mirrors.ghcr.io", New: `"mirror.example.com/ghcr"`},
+			},
+			wantOptions: []ComponentDiff{
+				{Component: "kube-api", Changes: []FieldDiff{{Path: "kms.timeout_seconds", New: "5"}}},
+			},
+			wantActions: []ComponentAction{
+				{Component: "etcd", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "etcd-rivers", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "rivers", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+				{Component: "kms-plugin", Action: DiffActionBoot, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kube-apiserver", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kube-controller-manager", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kube-scheduler", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+				{Component: "kubelet", Action: DiffActionNextBoot, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+				{Component: "kube-proxy", Action: DiffActionRestart, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+			},
+		},
+		{
+			name: "disable kube-proxy",
+			modify: func(c *Cluster) {
+				c.Options.Proxy.Disable = true
+			},
+			wantOptions: []ComponentDiff{
+				{Component: "kube-proxy", Changes: []FieldDiff{{Path: "disable", New: "true"}}},
+			},
+			wantActions: []ComponentAction{
+				{Component: "kube-proxy", Action: DiffActionStop, Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proposed := newDiffCluster()
+			tt.modify(proposed)
+			d := DiffClusters(newDiffCluster(), proposed)
+
+			if !cmp.Equal(d.AddedNodes, tt.wantAdded) {
+				t.Error("unexpected added nodes", cmp.Diff(tt.wantAdded, d.AddedNodes))
+			}
+			if !cmp.Equal(d.RemovedNodes, tt.wantRemoved) {
+				t.Error("unexpected removed nodes", cmp.Diff(tt.wantRemoved, d.RemovedNodes))
+			}
+			if !cmp.Equal(d.Nodes, tt.wantNodes) {
+				t.Error("unexpected node changes", cmp.Diff(tt.wantNodes, d.Nodes))
+			}
+			if !cmp.Equal(d.Settings, tt.wantSettings) {
+				t.Error("unexpected settings changes", cmp.Diff(tt.wantSettings, d.Settings))
+			}
+			if !cmp.Equal(d.Options, tt.wantOptions) {
+				t.Error("unexpected options changes", cmp.Diff(tt.wantOptions, d.Options))
+			}
+			if !cmp.Equal(d.Actions, tt.wantActions) {
+				t.Error("unexpected actions", cmp.Diff(tt.wantActions, d.Actions))
+			}
+			if d.Empty() != (tt.name == "no changes") {
+				t.Error("unexpected Empty()", d.Empty())
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package cke
+
+import "time"
+
+// MaxClusterHistory is the maximum number of cluster configurations kept in the history.
+const MaxClusterHistory = 50
+
+// ClusterHistoryEntry is a cluster configuration stored in the history.
+type ClusterHistoryEntry struct {
+	Revision int64     `json:"revision,string"`
+	Time     time.Time `json:"time"`
+	Author   string    `json:"author,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Cluster  *Cluster  `json:"cluster"`
+}
@@ -302,6 +302,12 @@ rules:
 	if proxyConfig.HealthzBindAddress != "0.0.0.0" {
 		t.Error(`proxyConfig.HealthzBindAddress != 0.0.0.0`)
 	}
+	if mode := c.Options.Proxy.GetMode(); mode != ProxyModeIptables {
+		t.Error(`c.Options.Proxy.GetMode() != ProxyModeIptables`, mode)
+	}
+	if mode := (ProxyParams{}).GetMode(); mode != ProxyModeIptables {
+		t.Error(`default proxy mode should be iptables`, mode)
+	}
 
 	if c.Options.Kubelet.CRIEndpoint != "/var/run/k8s-containerd.sock" {
 		t.Error(`c.Options.Kubelet.ContainerRuntimeEndpoint != "/var/run/k8s-containerd.sock"`)
@@ -423,6 +429,63 @@ func testClusterValidate(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"dual-stack service subnets",
+			Cluster{
+				Name:           "testcluster",
+				ServiceSubnets: []string{"10.0.0.0/14", "fd00::/108"},
+				Options: Options{
+					Kubelet: KubeletParams{
+						CRIEndpoint: "/var/run/k8s-containerd.sock",
+					},
+				},
+			},
+			false,
+		},
+		{
+			"service subnets of the same family",
+			Cluster{
+				Name:           "testcluster",
+				ServiceSubnets: []string{"10.0.0.0/14", "10.4.0.0/16"},
+				Options: Options{
+					Kubelet: KubeletParams{
+						CRIEndpoint: "/var/run/k8s-containerd.sock",
+					},
+				},
+			},
+			true,
+		},
+		{
+			"both service_subnet and service_subnets",
+			Cluster{
+				Name:           "testcluster",
+				ServiceSubnet:  "10.0.0.0/14",
+				ServiceSubnets: []string{"10.0.0.0/14", "fd00::/108"},
+				Options: Options{
+					Kubelet: KubeletParams{
+						CRIEndpoint: "/var/run/k8s-containerd.sock",
+					},
+				},
+			},
+			true,
+		},
+		{
+			"duplicate secondary address",
+			Cluster{
+				Name:          "testcluster",
+				ServiceSubnet: "10.0.0.0/14",
+				Nodes: []*Node{
+					{Address: "10.0.0.1", SecondaryAddress: "fd01::1", User: "cybozu"},
+					{Address: "10.0.0.2", SecondaryAddress: "fd01::1", User: "cybozu"},
+				},
+				Options: Options{
+					Kubelet: KubeletParams{
+						CRIEndpoint: "/var/run/k8s-containerd.sock",
+					},
+				},
+			},
+			true,
+		},
 		{
 			"invalid DNS server address",
 			Cluster{
@@ -528,6 +591,50 @@ rules:
 			},
 			true,
 		},
+		{
+			"nftables proxy mode",
+			Cluster{
+				Name:          "testcluster",
+				ServiceSubnet: "10.0.0.0/14",
+				Options: Options{
+					Proxy: ProxyParams{
+						Config: &unstructured.Unstructured{
+							Object: map[string]any{
+								"apiVersion": "kubeproxy.config.k8s.io/v1alpha1",
+								"kind":       "KubeProxyConfiguration",
+								"mode":       "nftables",
+							},
+						},
+					},
+					Kubelet: KubeletParams{
+						CRIEndpoint: "/var/run/k8s-containerd.sock",
+					},
+				},
+			},
+			false,
+		},
+		{
+			"userspace proxy mode",
+			Cluster{
+				Name:          "testcluster",
+				ServiceSubnet: "10.0.0.0/14",
+				Options: Options{
+					Proxy: ProxyParams{
+						Config: &unstructured.Unstructured{
+							Object: map[string]any{
+								"apiVersion": "kubeproxy.config.k8s.io/v1alpha1",
+								"kind":       "KubeProxyConfiguration",
+								"mode":       "userspace",
+							},
+						},
+					},
+					Kubelet: KubeletParams{
+						CRIEndpoint: "/var/run/k8s-containerd.sock",
+					},
+				},
+			},
+			true,
+		},
 		{
 			"invalid domain",
 			Cluster{
@@ -859,6 +966,36 @@ func testClusterValidateNode(t *testing.T) {
 			isTmpl:  false,
 			wantErr: true,
 		},
+		{
+			name: "secondary address",
+			node: Node{
+				Address:          "10.0.0.1",
+				SecondaryAddress: "fd01::1",
+				User:             "testuser",
+			},
+			isTmpl:  false,
+			wantErr: false,
+		},
+		{
+			name: "secondary address of the same family",
+			node: Node{
+				Address:          "10.0.0.1",
+				SecondaryAddress: "10.1.0.1",
+				User:             "testuser",
+			},
+			isTmpl:  false,
+			wantErr: true,
+		},
+		{
+			name: "invalid secondary address",
+			node: Node{
+				Address:          "10.0.0.1",
+				SecondaryAddress: "fd01::x",
+				User:             "testuser",
+			},
+			isTmpl:  false,
+			wantErr: true,
+		},
 		{
 			name: "bad taint key",
 			node: Node{
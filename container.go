@@ -29,6 +29,9 @@ type ContainerEngine interface {
 	RunWithInput(img Image, binds []Mount, command, input string, args ...string) error
 	/// RunWithOutput runs a container as a foreground process and get stdout and stderr.
 	RunWithOutput(img Image, binds []Mount, command string, args ...string) ([]byte, []byte, error)
+	// RunPrivileged runs a privileged container as a foreground process.
+	// /run is mounted as tmpfs for lock files.
+	RunPrivileged(img Image, binds []Mount, command string, args ...string) error
 	// RunSystem runs the named container as a system service.
 	RunSystem(name string, img Image, opts []string, params, extra ServiceParams) error
 	// Exists returns if named system container exists.
@@ -49,33 +52,56 @@ type ContainerEngine interface {
 	VolumeExists(name string) (bool, error)
 }
 
+// OutputError is returned by ContainerEngine when a command run by the engine fails.
+// It keeps the output of the command apart from the error.
+type OutputError struct {
+	Err    error
+	Stdout []byte
+	Stderr []byte
+}
+
+// Error implements error interface.
+func (e OutputError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e OutputError) Unwrap() error {
+	return e.Err
+}
+
 type ckeLabel struct {
 	BuiltInParams ServiceParams `json:"builtin"`
 	ExtraParams   ServiceParams `json:"extra"`
 }
 
 // Docker is an implementation of ContainerEngine.
-func Docker(agent Agent) ContainerEngine {
-	return docker{agent}
+// The images are replaced as specified by images, which may be nil.
+func Docker(agent Agent, images *ImageOverrides) ContainerEngine {
+	return docker{agent, images}
 }
 
 type docker struct {
-	agent Agent
+	agent  Agent
+	images *ImageOverrides
 }
 
 func (c docker) PullImage(img Image) error {
-	stdout, stderr, err := c.agent.Run("docker image list --format '{{.Repository}}:{{.Tag}}'")
+	name := c.images.Resolve(img).Name()
+
+	// images pinned by digest are listed as "<repository>@<digest>".
+	stdout, stderr, err := c.agent.Run("docker image list --format '{{.Repository}}:{{.Tag}} {{.Repository}}@{{.Digest}}'")
 	if err != nil {
 		return fmt.Errorf("%w, stdout: %s, stderr: %s", err, stdout, stderr)
 	}
 
-	if slices.Contains(strings.Split(string(stdout), "\n"), img.Name()) {
+	if slices.Contains(strings.Fields(string(stdout)), name) {
 		return nil
 	}
 
-	stdout, stderr, err = c.agent.Run("docker image pull " + img.Name())
+	stdout, stderr, err = c.agent.Run("docker image pull " + name)
 	if err != nil {
-		return fmt.Errorf("%w, stdout: %s, stderr: %s", err, stdout, stderr)
+		return OutputError{err, stdout, stderr}
 	}
 	return nil
 }
@@ -97,11 +123,14 @@ func (c docker) Run(img Image, binds []Mount, command string, args ...string) er
 		}
 		runArgs = append(runArgs, fmt.Sprintf("--volume=%s:%s:%s", m.Source, m.Destination, o))
 	}
-	runArgs = append(runArgs, img.Name(), command)
+	runArgs = append(runArgs, c.images.Resolve(img).Name(), command)
 	runArgs = append(runArgs, args...)
 
-	_, _, err := c.agent.Run(strings.Join(runArgs, " "))
-	return err
+	stdout, stderr, err := c.agent.Run(strings.Join(runArgs, " "))
+	if err != nil {
+		return OutputError{err, stdout, stderr}
+	}
+	return nil
 }
 
 func (c docker) RunWithInput(img Image, binds []Mount, command, input string, args ...string) error {
@@ -122,10 +151,14 @@ func (c docker) RunWithInput(img Image, binds []Mount, command, input string, ar
 		}
 		runArgs = append(runArgs, fmt.Sprintf("--volume=%s:%s:%s", m.Source, m.Destination, o))
 	}
-	runArgs = append(runArgs, img.Name(), command)
+	runArgs = append(runArgs, c.images.Resolve(img).Name(), command)
 	runArgs = append(runArgs, args...)
 
-	return c.agent.RunWithInput(strings.Join(runArgs, " "), input)
+	stdout, stderr, err := c.agent.RunWithTimeout(strings.Join(runArgs, " "), input, DefaultRunTimeout)
+	if err != nil {
+		return OutputError{err, stdout, stderr}
+	}
+	return nil
 }
 
 func (c docker) RunWithOutput(img Image, binds []Mount, command string, args ...string) ([]byte, []byte, error) {
@@ -145,7 +178,7 @@ func (c docker) RunWithOutput(img Image, binds []Mount, command string, args ...
 		}
 		runArgs = append(runArgs, fmt.Sprintf("--volume=%s:%s:%s", m.Source, m.Destination, o))
 	}
-	runArgs = append(runArgs, img.Name(), command)
+	runArgs = append(runArgs, c.images.Resolve(img).Name(), command)
 	runArgs = append(runArgs, args...)
 
 	stdout, stderr, err := c.agent.Run(strings.Join(runArgs, " "))
@@ -153,6 +186,36 @@ func (c docker) RunWithOutput(img Image, binds []Mount, command string, args ...
 	return stdout, stderr, err
 }
 
+func (c docker) RunPrivileged(img Image, binds []Mount, command string, args ...string) error {
+	runArgs := []string{
+		"docker",
+		"run",
+		"--log-driver=journald",
+		"--rm",
+		"--network=host",
+		"--uts=host",
+		"--read-only",
+		"--privileged",
+		"--tmpfs=/run",
+	}
+	for _, m := range binds {
+		o := "rw"
+		if m.ReadOnly {
+			o = "ro"
+		}
+		runArgs = append(runArgs, fmt.Sprintf("--volume=%s:%s:%s", m.Source, m.Destination, o))
+	}
+	runArgs = append(runArgs, c.images.Resolve(img).Name(), command)
+	runArgs = append(runArgs, args...)
+
+	cmdline := strings.Join(runArgs, " ")
+	stdout, stderr, err := c.agent.Run(cmdline)
+	if err != nil {
+		return fmt.Errorf("%w, cmdline: %s, stdout: %s, stderr: %s", err, cmdline, stdout, stderr)
+	}
+	return nil
+}
+
 func (c docker) RunSystem(name string, img Image, opts []string, params, extra ServiceParams) error {
 	id, err := c.getID(name)
 	if err != nil {
@@ -217,7 +280,7 @@ func (c docker) RunSystem(name string, img Image, opts []string, params, extra S
 	}
 	args = append(args, "--label-file="+labelFile)
 
-	args = append(args, img.Name())
+	args = append(args, c.images.Resolve(img).Name())
 
 	args = append(args, params.ExtraArguments...)
 	args = append(args, extra.ExtraArguments...)
@@ -0,0 +1,169 @@
+package cke
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	kcmv1alpha1 "k8s.io/kube-controller-manager/config/v1alpha1"
+)
+
+// kube-controller-manager does not load KubeControllerManagerConfiguration
+// from a file.  CKE converts the fields into the corresponding command-line flags.
+//
+// The keys are the lower-cased JSON paths of the fields in KubeControllerManagerConfiguration.
+// Fields managed by CKE, such as ClusterName or ServiceCIDR, are not listed.
+var controllerManagerConfigFlags = map[string]string{
+	"generic.minresyncperiod":                  "min-resync-period",
+	"generic.clientconnection.qps":             "kube-api-qps",
+	"generic.clientconnection.burst":           "kube-api-burst",
+	"generic.clientconnection.contenttype":     "kube-api-content-type",
+	"generic.controllerstartinterval":          "controller-start-interval",
+	"generic.controllers":                      "controllers",
+	"generic.leaderelection.leaderelect":       "leader-elect",
+	"generic.leaderelection.leaseduration":     "leader-elect-lease-duration",
+	"generic.leaderelection.renewdeadline":     "leader-elect-renew-deadline",
+	"generic.leaderelection.retryperiod":       "leader-elect-retry-period",
+	"generic.leaderelection.resourcelock":      "leader-elect-resource-lock",
+	"generic.leaderelection.resourcename":      "leader-elect-resource-name",
+	"generic.leaderelection.resourcenamespace": "leader-elect-resource-namespace",
+
+	"kubecloudshared.allocatenodecidrs":    "allocate-node-cidrs",
+	"kubecloudshared.cidrallocatortype":    "cidr-allocator-type",
+	"kubecloudshared.clustercidr":          "cluster-cidr",
+	"kubecloudshared.configurecloudroutes": "configure-cloud-routes",
+	"kubecloudshared.nodemonitorperiod":    "node-monitor-period",
+
+	"attachdetachcontroller.disableattachdetachreconcilersync": "disable-attach-detach-reconcile-sync",
+	"attachdetachcontroller.reconcilersyncloopperiod":          "attach-detach-reconcile-sync-period",
+	"attachdetachcontroller.disableforcedetachontimeout":       "disable-force-detach-on-timeout",
+
+	"csrsigningcontroller.clustersigningduration": "cluster-signing-duration",
+
+	"cronjobcontroller.concurrentcronjobsyncs":                                 "concurrent-cron-job-syncs",
+	"daemonsetcontroller.concurrentdaemonsetsyncs":                             "concurrent-daemonset-syncs",
+	"deploymentcontroller.concurrentdeploymentsyncs":                           "concurrent-deployment-syncs",
+	"endpointcontroller.concurrentendpointsyncs":                               "concurrent-endpoint-syncs",
+	"endpointcontroller.endpointupdatesbatchperiod":                            "endpoint-updates-batch-period",
+	"endpointslicecontroller.concurrentserviceendpointsyncs":                   "concurrent-service-endpoint-syncs",
+	"endpointslicecontroller.endpointupdatesbatchperiod":                       "endpointslice-updates-batch-period",
+	"endpointslicecontroller.maxendpointsperslice":                             "max-endpoints-per-slice",
+	"endpointslicemirroringcontroller.mirroringconcurrentserviceendpointsyncs": "mirroring-concurrent-service-endpoint-syncs",
+	"endpointslicemirroringcontroller.mirroringendpointupdatesbatchperiod":     "mirroring-endpointslice-updates-batch-period",
+	"endpointslicemirroringcontroller.mirroringmaxendpointspersubset":          "mirroring-max-endpoints-per-subset",
+	"ephemeralvolumecontroller.concurrentephemeralvolumesyncs":                 "concurrent-ephemeralvolume-syncs",
+	"garbagecollectorcontroller.concurrentgcsyncs":                             "concurrent-gc-syncs",
+	"garbagecollectorcontroller.enablegarbagecollector":                        "enable-garbage-collector",
+	"jobcontroller.concurrentjobsyncs":                                         "concurrent-job-syncs",
+	"namespacecontroller.concurrentnamespacesyncs":                             "concurrent-namespace-syncs",
+	"namespacecontroller.namespacesyncperiod":                                  "namespace-sync-period",
+	"replicasetcontroller.concurrentrssyncs":                                   "concurrent-replicaset-syncs",
+	"replicationcontroller.concurrentrcsyncs":                                  "concurrent_rc_syncs",
+	"resourcequotacontroller.concurrentresourcequotasyncs":                     "concurrent-resource-quota-syncs",
+	"resourcequotacontroller.resourcequotasyncperiod":                          "resource-quota-sync-period",
+	"sacontroller.concurrentsatokensyncs":                                      "concurrent-serviceaccount-token-syncs",
+	"servicecontroller.concurrentservicesyncs":                                 "concurrent-service-syncs",
+	"statefulsetcontroller.concurrentstatefulsetsyncs":                         "concurrent-statefulset-syncs",
+	"ttlafterfinishedcontroller.concurrentttlsyncs":                            "concurrent-ttl-after-finished-syncs",
+	"legacysatokencleaner.cleanupperiod":                                       "legacy-service-account-token-clean-up-period",
+
+	"hpacontroller.concurrenthorizontalpodautoscalersyncs":              "concurrent-horizontal-pod-autoscaler-syncs",
+	"hpacontroller.horizontalpodautoscalersyncperiod":                   "horizontal-pod-autoscaler-sync-period",
+	"hpacontroller.horizontalpodautoscalertolerance":                    "horizontal-pod-autoscaler-tolerance",
+	"hpacontroller.horizontalpodautoscalerdownscalestabilizationwindow": "horizontal-pod-autoscaler-downscale-stabilization",
+	"hpacontroller.horizontalpodautoscalercpuinitializationperiod":      "horizontal-pod-autoscaler-cpu-initialization-period",
+	"hpacontroller.horizontalpodautoscalerinitialreadinessdelay":        "horizontal-pod-autoscaler-initial-readiness-delay",
+
+	"nodeipamcontroller.nodecidrmasksize":     "node-cidr-mask-size",
+	"nodeipamcontroller.nodecidrmasksizeipv4": "node-cidr-mask-size-ipv4",
+	"nodeipamcontroller.nodecidrmasksizeipv6": "node-cidr-mask-size-ipv6",
+
+	"nodelifecyclecontroller.largeclustersizethreshold": "large-cluster-size-threshold",
+	"nodelifecyclecontroller.nodeevictionrate":          "node-eviction-rate",
+	"nodelifecyclecontroller.nodemonitorgraceperiod":    "node-monitor-grace-period",
+	"nodelifecyclecontroller.nodestartupgraceperiod":    "node-startup-grace-period",
+	"nodelifecyclecontroller.secondarynodeevictionrate": "secondary-node-eviction-rate",
+	"nodelifecyclecontroller.unhealthyzonethreshold":    "unhealthy-zone-threshold",
+
+	"persistentvolumebindercontroller.pvclaimbindersyncperiod": "pvclaimbinder-sync-period",
+
+	"podgccontroller.terminatedpodgcthreshold": "terminated-pod-gc-threshold",
+}
+
+// ConfigArguments returns the command-line arguments converted from Config.
+// The arguments are sorted by the field paths.
+// Fields that are not supported are ignored; they are rejected by Cluster.Validate.
+func (p ControllerManagerParams) ConfigArguments() []string {
+	if p.Config == nil {
+		return nil
+	}
+
+	fields := make(map[string]string)
+	flattenControllerManagerConfig(p.Config.Object, "", fields)
+
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		if _, ok := controllerManagerConfigFlags[path]; ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	args := make([]string, len(paths))
+	for i, path := range paths {
+		args[i] = fmt.Sprintf("--%s=%s", controllerManagerConfigFlags[path], fields[path])
+	}
+	return args
+}
+
+func flattenControllerManagerConfig(obj map[string]any, prefix string, fields map[string]string) {
+	for k, v := range obj {
+		if prefix == "" && (k == "apiVersion" || k == "kind") {
+			continue
+		}
+		path := strings.ToLower(k)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		switch v := v.(type) {
+		case map[string]any:
+			flattenControllerManagerConfig(v, path, fields)
+		case []any:
+			values := make([]string, len(v))
+			for i, e := range v {
+				values[i] = fmt.Sprint(e)
+			}
+			fields[path] = strings.Join(values, ",")
+		case float64:
+			fields[path] = strconv.FormatFloat(v, 'f', -1, 64)
+		case nil:
+		default:
+			fields[path] = fmt.Sprint(v)
+		}
+	}
+}
+
+func validateControllerManagerConfig(p ControllerManagerParams) error {
+	if _, err := p.MergeConfig(&kcmv1alpha1.KubeControllerManagerConfiguration{}); err != nil {
+		return err
+	}
+	if p.Config == nil {
+		return nil
+	}
+
+	fields := make(map[string]string)
+	flattenControllerManagerConfig(p.Config.Object, "", fields)
+	var unsupported []string
+	for path := range fields {
+		if _, ok := controllerManagerConfigFlags[path]; !ok {
+			unsupported = append(unsupported, path)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return fmt.Errorf("unsupported fields in kube-controller-manager config: %s", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package cke
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kcmv1alpha1 "k8s.io/kube-controller-manager/config/v1alpha1"
+)
+
+func controllerManagerConfig(fields map[string]any) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: fields}
+	u.SetGroupVersionKind(kcmv1alpha1.SchemeGroupVersion.WithKind("KubeControllerManagerConfiguration"))
+	return u
+}
+
+func TestControllerManagerConfigFlags(t *testing.T) {
+	// every field in the table must exist in KubeControllerManagerConfiguration.
+	data, err := json.Marshal(kcmv1alpha1.KubeControllerManagerConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := make(map[string]any)
+	if err := json.Unmarshal(data, &obj); err != nil {
+		t.Fatal(err)
+	}
+	// pointers and slices are null in the zero value, so collect all the keys.
+	paths := make(map[string]bool)
+	var walk func(obj map[string]any, prefix string)
+	walk = func(obj map[string]any, prefix string) {
+		for k, v := range obj {
+			path := strings.ToLower(k)
+			if prefix != "" {
+				path = prefix + "." + path
+			}
+			if m, ok := v.(map[string]any); ok {
+				walk(m, path)
+				continue
+			}
+			paths[path] = true
+		}
+	}
+	walk(obj, "")
+	for path := range controllerManagerConfigFlags {
+		if !paths[path] {
+			t.Errorf("%s does not exist in KubeControllerManagerConfiguration", path)
+		}
+	}
+}
+
+func TestControllerManagerConfigArguments(t *testing.T) {
+	p := ControllerManagerParams{}
+	if args := p.ConfigArguments(); len(args) != 0 {
+		t.Error("no arguments should be returned without config", args)
+	}
+
+	p.Config = controllerManagerConfig(map[string]any{
+		"Generic": map[string]any{
+			"Controllers": []any{"*", "bootstrapsigner"},
+			"ClientConnection": map[string]any{
+				"qps":   float64(50.5),
+				"burst": int64(100),
+			},
+		},
+		"nodeLifecycleController": map[string]any{
+			"NodeMonitorGracePeriod": "50s",
+		},
+		"GarbageCollectorController": map[string]any{
+			"EnableGarbageCollector": false,
+		},
+	})
+	if err := validateControllerManagerConfig(p); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"--enable-garbage-collector=false",
+		"--kube-api-burst=100",
+		"--kube-api-qps=50.5",
+		"--controllers=*,bootstrapsigner",
+		"--node-monitor-grace-period=50s",
+	}
+	if args := p.ConfigArguments(); !cmp.Equal(args, expected) {
+		t.Error("unexpected arguments", cmp.Diff(expected, args))
+	}
+}
+
+func TestValidateControllerManagerConfig(t *testing.T) {
+	p := ControllerManagerParams{
+		Config: controllerManagerConfig(map[string]any{
+			"KubeCloudShared": map[string]any{"ClusterName": "foo"},
+			"SAController":    map[string]any{"RootCAFile": "/ca.crt"},
+		}),
+	}
+	err := validateControllerManagerConfig(p)
+	if err == nil {
+		t.Fatal("fields managed by CKE should be rejected")
+	}
+	if !strings.Contains(err.Error(), "kubecloudshared.clustername, sacontroller.rootcafile") {
+		t.Error("unexpected error message", err)
+	}
+
+	p.Config = controllerManagerConfig(map[string]any{
+		"Generic": map[string]any{"ControllerStartInterval": 10},
+	})
+	if err := validateControllerManagerConfig(p); err == nil {
+		t.Error("invalid type should be rejected")
+	}
+
+	p.Config = controllerManagerConfig(nil)
+	p.Config.SetKind("KubeSchedulerConfiguration")
+	if err := validateControllerManagerConfig(p); err == nil {
+		t.Error("wrong kind should be rejected")
+	}
+}
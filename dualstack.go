@@ -0,0 +1,77 @@
+package cke
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+)
+
+// GetServiceSubnets returns the CIDR subnets for k8s Service.
+// If service_subnets is not given, this returns service_subnet.
+func (c *Cluster) GetServiceSubnets() []string {
+	if len(c.ServiceSubnets) > 0 {
+		return c.ServiceSubnets
+	}
+	return []string{c.ServiceSubnet}
+}
+
+// Addresses returns the primary address and the secondary address, if any, of the node.
+func (n *Node) Addresses() []string {
+	if n.SecondaryAddress == "" {
+		return []string{n.Address}
+	}
+	return []string{n.Address, n.SecondaryAddress}
+}
+
+// IPAddressType returns the address type of EndpointSlice for ip.
+func IPAddressType(ip string) discoveryv1.AddressType {
+	if net.ParseIP(ip).To4() != nil {
+		return discoveryv1.AddressTypeIPv4
+	}
+	return discoveryv1.AddressTypeIPv6
+}
+
+func validateServiceSubnets(c *Cluster) error {
+	if len(c.ServiceSubnets) == 0 {
+		_, _, err := net.ParseCIDR(c.ServiceSubnet)
+		return err
+	}
+
+	if c.ServiceSubnet != "" {
+		return errors.New("service_subnet and service_subnets cannot be specified at the same time")
+	}
+	if len(c.ServiceSubnets) > 2 {
+		return errors.New("service_subnets can contain at most two subnets")
+	}
+	for _, subnet := range c.ServiceSubnets {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			return err
+		}
+	}
+	if len(c.ServiceSubnets) == 2 {
+		ip1, _, _ := net.ParseCIDR(c.ServiceSubnets[0])
+		ip2, _, _ := net.ParseCIDR(c.ServiceSubnets[1])
+		if IPAddressType(ip1.String()) == IPAddressType(ip2.String()) {
+			return errors.New("service_subnets must be of different IP families")
+		}
+	}
+	return nil
+}
+
+func validateSecondaryAddress(n *Node, isTmpl bool) error {
+	if n.SecondaryAddress == "" {
+		return nil
+	}
+	if isTmpl {
+		return errors.New("secondary_address is not empty: " + n.SecondaryAddress)
+	}
+	if net.ParseIP(n.SecondaryAddress) == nil {
+		return errors.New("invalid IP address: " + n.SecondaryAddress)
+	}
+	if IPAddressType(n.Address) == IPAddressType(n.SecondaryAddress) {
+		return fmt.Errorf("secondary_address must be of a different IP family from address: %s", n.SecondaryAddress)
+	}
+	return nil
+}
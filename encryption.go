@@ -0,0 +1,285 @@
+package cke
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+	"k8s.io/apimachinery/pkg/util/validation"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+)
+
+// Encryption providers for Kubernetes resources at rest.
+const (
+	EncryptionProviderAESCBC    = "aescbc"
+	EncryptionProviderAESGCM    = "aesgcm"
+	EncryptionProviderSecretbox = "secretbox"
+	EncryptionProviderKMS       = "kms"
+)
+
+// EncryptionProviders is the list of supported encryption providers.
+var EncryptionProviders = []string{
+	EncryptionProviderAESCBC,
+	EncryptionProviderAESGCM,
+	EncryptionProviderSecretbox,
+	EncryptionProviderKMS,
+}
+
+// DefaultEncryptionResources is the list of resources encrypted at rest by default.
+var DefaultEncryptionResources = []string{"secrets"}
+
+// EncryptionRotationPhase represents the progress of an encryption key rotation.
+type EncryptionRotationPhase string
+
+// Phases of an encryption key rotation.
+const (
+	// EncryptionRotationAddKey waits for all API servers to be able to decrypt data with the new key.
+	EncryptionRotationAddKey = EncryptionRotationPhase("add-key")
+	// EncryptionRotationPromoteKey waits for all API servers to encrypt data with the new key.
+	EncryptionRotationPromoteKey = EncryptionRotationPhase("promote-key")
+	// EncryptionRotationReencrypt re-encrypts all the resources with the new key.
+	EncryptionRotationReencrypt = EncryptionRotationPhase("reencrypt")
+	// EncryptionRotationDropKeys removes the old keys.
+	EncryptionRotationDropKeys = EncryptionRotationPhase("drop-keys")
+	// EncryptionRotationCompleted means the rotation has been completed.
+	EncryptionRotationCompleted = EncryptionRotationPhase("completed")
+)
+
+// EncryptionKeyInfo identifies an encryption key.
+// The key material is stored in Vault.
+type EncryptionKeyInfo struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+}
+
+// EncryptionStatus represents the encryption keys configured for API servers.
+type EncryptionStatus struct {
+	// Keys are the encryption keys in the order of precedence.
+	// The first key is used to encrypt data, and all keys are used to decrypt data.
+	Keys []EncryptionKeyInfo `json:"keys"`
+
+	// Rotation is the last or ongoing key rotation, if any.
+	Rotation *EncryptionKeyRotation `json:"rotation,omitempty"`
+}
+
+// EncryptionKeyRotation represents the progress of an encryption key rotation.
+type EncryptionKeyRotation struct {
+	Phase  EncryptionRotationPhase `json:"phase"`
+	NewKey EncryptionKeyInfo       `json:"new_key"`
+
+	// Reencrypted is the list of resources that have been re-encrypted with the new key.
+	Reencrypted []string `json:"reencrypted,omitempty"`
+
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// InProgress returns true if the rotation is not completed yet.
+func (r *EncryptionKeyRotation) InProgress() bool {
+	return r != nil && r.Phase != EncryptionRotationCompleted
+}
+
+// StartRotation adds newKey as a decryption-only key and starts a key rotation.
+func (s *EncryptionStatus) StartRotation(newKey EncryptionKeyInfo, now time.Time) error {
+	if s.Rotation.InProgress() {
+		return fmt.Errorf("key rotation is in progress: %s", s.Rotation.Phase)
+	}
+	if !slices.Contains(EncryptionProviders, newKey.Provider) {
+		return fmt.Errorf("unsupported encryption provider: %s", newKey.Provider)
+	}
+	for _, k := range s.Keys {
+		if k.Name == newKey.Name {
+			return fmt.Errorf("duplicate encryption key name: %s", newKey.Name)
+		}
+	}
+
+	s.Keys = append(s.Keys, newKey)
+	s.Rotation = &EncryptionKeyRotation{
+		Phase:     EncryptionRotationAddKey,
+		NewKey:    newKey,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	return nil
+}
+
+// PromoteKey makes the new key the encryption key.
+func (s *EncryptionStatus) PromoteKey(now time.Time) {
+	keys := []EncryptionKeyInfo{s.Rotation.NewKey}
+	for _, k := range s.Keys {
+		if k != s.Rotation.NewKey {
+			keys = append(keys, k)
+		}
+	}
+	s.Keys = keys
+	s.Rotation.Phase = EncryptionRotationPromoteKey
+	s.Rotation.UpdatedAt = now
+}
+
+// StartReencrypt starts re-encryption of resources.
+func (s *EncryptionStatus) StartReencrypt(now time.Time) {
+	s.Rotation.Phase = EncryptionRotationReencrypt
+	s.Rotation.UpdatedAt = now
+}
+
+// FinishReencrypt records that resource has been re-encrypted.
+// If all the resources have been re-encrypted, the rotation proceeds to drop the old keys.
+func (s *EncryptionStatus) FinishReencrypt(resource string, resources []string, now time.Time) {
+	if !slices.Contains(s.Rotation.Reencrypted, resource) {
+		s.Rotation.Reencrypted = append(s.Rotation.Reencrypted, resource)
+	}
+	s.Rotation.UpdatedAt = now
+	for _, r := range resources {
+		if !slices.Contains(s.Rotation.Reencrypted, r) {
+			return
+		}
+	}
+	s.Rotation.Phase = EncryptionRotationDropKeys
+}
+
+// DropKeys removes the keys other than the new key, and completes the rotation.
+// It returns the removed keys.
+func (s *EncryptionStatus) DropKeys(now time.Time) []EncryptionKeyInfo {
+	var dropped []EncryptionKeyInfo
+	for _, k := range s.Keys {
+		if k != s.Rotation.NewKey {
+			dropped = append(dropped, k)
+		}
+	}
+	s.Keys = []EncryptionKeyInfo{s.Rotation.NewKey}
+	s.Rotation.Phase = EncryptionRotationCompleted
+	s.Rotation.UpdatedAt = now
+	return dropped
+}
+
+// GetEncryptionResources returns the resources to be encrypted at rest.
+func (p APIServerParams) GetEncryptionResources() []string {
+	if len(p.EncryptionResources) == 0 {
+		return DefaultEncryptionResources
+	}
+	return p.EncryptionResources
+}
+
+func validateEncryptionResources(resources []string) error {
+	seen := make(map[string]bool)
+	for _, r := range resources {
+		if seen[r] {
+			return fmt.Errorf("duplicate encryption resource: %s", r)
+		}
+		seen[r] = true
+
+		// wildcards are not supported because they cannot be re-encrypted one by one.
+		if msgs := validation.IsDNS1123Subdomain(r); len(msgs) > 0 {
+			return fmt.Errorf("invalid encryption resource %q: %s", r, strings.Join(msgs, "; "))
+		}
+	}
+	return nil
+}
+
+// EncryptionKeySecrets maps provider names to the key secrets of the provider.
+type EncryptionKeySecrets map[string][]apiserverv1.Key
+
+// ReadEncryptionKeySecrets reads the key secrets from Vault.
+// The secrets of each provider are stored in the same format as AESConfiguration.
+// If no secrets are stored, this returns an empty map.
+func ReadEncryptionKeySecrets(vc *vault.Client) (EncryptionKeySecrets, error) {
+	secret, err := vc.Logical().Read(K8sSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(EncryptionKeySecrets)
+	if secret == nil || secret.Data == nil {
+		return secrets, nil
+	}
+	for _, provider := range EncryptionProviders {
+		data, ok := secret.Data[provider]
+		if !ok {
+			continue
+		}
+		cfg := new(apiserverv1.AESConfiguration)
+		if err := json.Unmarshal([]byte(data.(string)), cfg); err != nil {
+			return nil, err
+		}
+		secrets[provider] = cfg.Keys
+	}
+	return secrets, nil
+}
+
+// WriteEncryptionKeySecrets writes the key secrets into Vault.
+func WriteEncryptionKeySecrets(vc *vault.Client, secrets EncryptionKeySecrets) error {
+	data := make(map[string]any)
+	for provider, keys := range secrets {
+		if len(keys) == 0 {
+			continue
+		}
+		cfg, err := json.Marshal(apiserverv1.AESConfiguration{Keys: keys})
+		if err != nil {
+			return err
+		}
+		data[provider] = string(cfg)
+	}
+	_, err := vc.Logical().Write(K8sSecret, data)
+	return err
+}
+
+// Find returns the secret of the key.
+func (s EncryptionKeySecrets) Find(k EncryptionKeyInfo) (apiserverv1.Key, error) {
+	for _, key := range s[k.Provider] {
+		if key.Name == k.Name {
+			return key, nil
+		}
+	}
+	return apiserverv1.Key{}, fmt.Errorf("no secret for encryption key %s/%s", k.Provider, k.Name)
+}
+
+// Generate generates a new key for the provider.
+// The key is named after the current time.
+//
+// KMS keys have no secrets because the plugin encrypts data with the transit key
+// in Vault.  Their names must not contain colons.
+func (s EncryptionKeySecrets) Generate(provider string, now time.Time) (EncryptionKeyInfo, error) {
+	if !slices.Contains(EncryptionProviders, provider) {
+		return EncryptionKeyInfo{}, fmt.Errorf("unsupported encryption provider: %s", provider)
+	}
+	if provider == EncryptionProviderKMS {
+		return EncryptionKeyInfo{Name: "vault-" + now.UTC().Format("20060102T150405Z"), Provider: provider}, nil
+	}
+
+	// all the providers accept 32-byte keys.
+	// ref: https://kubernetes.io/docs/tasks/administer-cluster/encrypt-data/#providers
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return EncryptionKeyInfo{}, err
+	}
+
+	k := EncryptionKeyInfo{Name: now.UTC().Format(time.RFC3339), Provider: provider}
+	s[provider] = append(s[provider], apiserverv1.Key{
+		Name:   k.Name,
+		Secret: base64.StdEncoding.EncodeToString(secret),
+	})
+	return k, nil
+}
+
+// LegacyKeys returns the keys created by "ckecli vault enckey" before
+// the keys are managed by the rotation workflow.  The first key is the
+// encryption key.
+func (s EncryptionKeySecrets) LegacyKeys() []EncryptionKeyInfo {
+	var keys []EncryptionKeyInfo
+	for _, key := range s[EncryptionProviderAESCBC] {
+		keys = append(keys, EncryptionKeyInfo{Name: key.Name, Provider: EncryptionProviderAESCBC})
+	}
+	return keys
+}
+
+// Remove removes the secret of the key.
+func (s EncryptionKeySecrets) Remove(k EncryptionKeyInfo) {
+	s[k.Provider] = slices.DeleteFunc(s[k.Provider], func(key apiserverv1.Key) bool {
+		return key.Name == k.Name
+	})
+}
@@ -0,0 +1,136 @@
+package cke
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEncryptionKeyRotation(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	oldKey := EncryptionKeyInfo{Name: "old", Provider: EncryptionProviderAESCBC}
+	newKey := EncryptionKeyInfo{Name: "new", Provider: EncryptionProviderSecretbox}
+	resources := []string{"secrets", "configmaps"}
+
+	st := &EncryptionStatus{Keys: []EncryptionKeyInfo{oldKey}}
+	if st.Rotation.InProgress() {
+		t.Fatal("rotation should not be in progress")
+	}
+
+	if err := st.StartRotation(EncryptionKeyInfo{Name: "foo", Provider: "identity"}, now); err == nil {
+		t.Error("unsupported provider should be rejected")
+	}
+	if err := st.StartRotation(EncryptionKeyInfo{Name: "old", Provider: EncryptionProviderAESGCM}, now); err == nil {
+		t.Error("duplicate key name should be rejected")
+	}
+
+	if err := st.StartRotation(newKey, now); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(st.Keys, []EncryptionKeyInfo{oldKey, newKey}) {
+		t.Error("new key should be added as the last key", st.Keys)
+	}
+	if st.Rotation.Phase != EncryptionRotationAddKey || !st.Rotation.InProgress() {
+		t.Error("unexpected phase", st.Rotation.Phase)
+	}
+	if err := st.StartRotation(EncryptionKeyInfo{Name: "other", Provider: EncryptionProviderAESCBC}, now); err == nil {
+		t.Error("rotation should not be started while another is in progress")
+	}
+
+	st.PromoteKey(now)
+	if !slices.Equal(st.Keys, []EncryptionKeyInfo{newKey, oldKey}) {
+		t.Error("new key should be the first key", st.Keys)
+	}
+	if st.Rotation.Phase != EncryptionRotationPromoteKey {
+		t.Error("unexpected phase", st.Rotation.Phase)
+	}
+
+	st.StartReencrypt(now)
+	st.FinishReencrypt("secrets", resources, now)
+	st.FinishReencrypt("secrets", resources, now)
+	if st.Rotation.Phase != EncryptionRotationReencrypt {
+		t.Error("unexpected phase", st.Rotation.Phase)
+	}
+	if !slices.Equal(st.Rotation.Reencrypted, []string{"secrets"}) {
+		t.Error("unexpected re-encrypted resources", st.Rotation.Reencrypted)
+	}
+	st.FinishReencrypt("configmaps", resources, now.Add(time.Minute))
+	if st.Rotation.Phase != EncryptionRotationDropKeys {
+		t.Error("unexpected phase", st.Rotation.Phase)
+	}
+
+	dropped := st.DropKeys(now.Add(2 * time.Minute))
+	if !slices.Equal(dropped, []EncryptionKeyInfo{oldKey}) {
+		t.Error("unexpected dropped keys", dropped)
+	}
+	if !slices.Equal(st.Keys, []EncryptionKeyInfo{newKey}) {
+		t.Error("only the new key should remain", st.Keys)
+	}
+	if st.Rotation.InProgress() {
+		t.Error("rotation should be completed")
+	}
+	if !st.Rotation.StartedAt.Equal(now) || !st.Rotation.UpdatedAt.Equal(now.Add(2*time.Minute)) {
+		t.Error("unexpected timestamps", st.Rotation.StartedAt, st.Rotation.UpdatedAt)
+	}
+}
+
+func TestEncryptionKeySecrets(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	secrets := make(EncryptionKeySecrets)
+
+	if _, err := secrets.Generate("identity", now); err == nil {
+		t.Error("unsupported provider should be rejected")
+	}
+
+	k, err := secrets.Generate(EncryptionProviderAESGCM, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Name != "2026-01-02T03:04:05Z" || k.Provider != EncryptionProviderAESGCM {
+		t.Error("unexpected key", k)
+	}
+	key, err := secrets.Find(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key.Secret) != 44 {
+		t.Error("secret should be 32 bytes encoded in base64", key.Secret)
+	}
+	if _, err := secrets.Find(EncryptionKeyInfo{Name: k.Name, Provider: EncryptionProviderAESCBC}); err == nil {
+		t.Error("key of another provider should not be found")
+	}
+	if len(secrets.LegacyKeys()) != 0 {
+		t.Error("legacy keys are aescbc keys only")
+	}
+
+	secrets.Remove(k)
+	if _, err := secrets.Find(k); err == nil {
+		t.Error("removed key should not be found")
+	}
+}
+
+func TestValidateEncryptionResources(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []string
+		wantErr   bool
+	}{
+		{"empty", nil, false},
+		{"valid", []string{"secrets", "configmaps", "widgets.example.com"}, false},
+		{"duplicate", []string{"secrets", "secrets"}, true},
+		{"wildcard", []string{"*.apps"}, true},
+		{"uppercase", []string{"Secrets"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEncryptionResources(tt.resources)
+			if tt.wantErr && err == nil {
+				t.Error("error is expected")
+			}
+			if !tt.wantErr && err != nil {
+				t.Error("unexpected error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package cke
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	proxyv1alpha1 "k8s.io/kube-proxy/config/v1alpha1"
+)
+
+// featureGatesVersion is the Kubernetes minor version of KubernetesImage.
+// knownFeatureGates must be updated together with KubernetesImage and the k8s.io modules.
+// TestKnownFeatureGates fails if either of them is of another version.
+const featureGatesVersion = "1.35"
+
+// knownFeatureGates is the list of feature gates of featureGatesVersion.
+// This is the union of the gates of all the components managed by CKE.
+// ref: https://kubernetes.io/docs/reference/command-line-tools-reference/feature-gates/
+var knownFeatureGates = []string{
+	"APIResponseCompression",
+	"APIServerIdentity",
+	"APIServerTracing",
+	"APIServingWithRoutine",
+	"AggregatedDiscoveryRemoveBetaType",
+	"AllAlpha",
+	"AllBeta",
+	"AllowDNSOnlyNodeCSR",
+	"AllowInsecureKubeletCertificateSigningRequests",
+	"AllowOverwriteTerminationGracePeriodSeconds",
+	"AllowParsingUserUIDFromCertAuth",
+	"AllowUnsafeMalformedObjectDeletion",
+	"AnonymousAuthConfigurableEndpoints",
+	"AnyVolumeDataSource",
+	"AuthorizeWithSelectors",
+	"BtreeWatchCache",
+	"CBORServingAndStorage",
+	"CPUCFSQuotaPeriod",
+	"CPUManagerPolicyAlphaOptions",
+	"CPUManagerPolicyBetaOptions",
+	"CPUManagerPolicyOptions",
+	"CSIVolumeHealth",
+	"ClearingNominatedNodeNameAfterBinding",
+	"ClientsAllowCBOR",
+	"ClientsPreferCBOR",
+	"ClusterTrustBundle",
+	"ClusterTrustBundleProjection",
+	"ComponentFlagz",
+	"ComponentStatusz",
+	"ConcurrentWatchObjectDecode",
+	"ConsistentListFromCache",
+	"ConstrainedImpersonation",
+	"ContainerCheckpoint",
+	"ContainerRestartRules",
+	"ContainerStopSignals",
+	"ContextualLogging",
+	"CoordinatedLeaderElection",
+	"CrossNamespaceVolumeDataSource",
+	"DRAAdminAccess",
+	"DRAConsumableCapacity",
+	"DRADeviceBindingConditions",
+	"DRADeviceTaints",
+	"DRAExtendedResource",
+	"DRAPartitionableDevices",
+	"DRAPrioritizedList",
+	"DRAResourceClaimDeviceStatus",
+	"DRASchedulerFilterTimeout",
+	"DeclarativeValidation",
+	"DeclarativeValidationTakeover",
+	"DeploymentReplicaSetTerminatingReplicas",
+	"DetectCacheInconsistency",
+	"DisableAllocatorDualWrite",
+	"DisableCPUQuotaWithExclusiveCPUs",
+	"DynamicResourceAllocation",
+	"EnvFiles",
+	"ExecProbeTimeout",
+	"GracefulNodeShutdown",
+	"GracefulNodeShutdownBasedOnPodPriority",
+	"HPAConfigurableTolerance",
+	"HPAScaleToZero",
+	"HonorPVReclaimPolicy",
+	"HostnameOverride",
+	"ImageMaximumGCAge",
+	"ImageVolume",
+	"InOrderInformers",
+	"InOrderInformersBatchProcess",
+	"InPlacePodVerticalScaling",
+	"InPlacePodVerticalScalingExclusiveCPUs",
+	"InPlacePodVerticalScalingExclusiveMemory",
+	"InformerResourceVersion",
+	"JobManagedBy",
+	"JobPodReplacementPolicy",
+	"JobSuccessPolicy",
+	"KMSv1",
+	"KubeletCgroupDriverFromCRI",
+	"KubeletCrashLoopBackOffMax",
+	"KubeletEnsureSecretPulledImages",
+	"KubeletFineGrainedAuthz",
+	"KubeletInUserNamespace",
+	"KubeletPSI",
+	"KubeletPodResourcesDynamicResources",
+	"KubeletPodResourcesGet",
+	"KubeletSeparateDiskGC",
+	"KubeletServiceAccountTokenForCredentialProviders",
+	"KubeletTracing",
+	"ListFromCacheSnapshot",
+	"LocalStorageCapacityIsolationFSQuotaMonitoring",
+	"LoggingAlphaOptions",
+	"LoggingBetaOptions",
+	"MatchLabelKeysInPodAffinity",
+	"MatchLabelKeysInPodTopologySpread",
+	"MatchLabelKeysInPodTopologySpreadSelectorMerge",
+	"MaxUnavailableStatefulSet",
+	"MemoryQoS",
+	"MultiCIDRServiceAllocator",
+	"MutableCSINodeAllocatableCount",
+	"MutatingAdmissionPolicy",
+	"NFTablesProxyMode",
+	"NodeInclusionPolicyInPodTopologySpread",
+	"NodeLogQuery",
+	"NodeSwap",
+	"OpenAPIEnums",
+	"OrderedNamespaceDeletion",
+	"PodAndContainerStatsFromCRI",
+	"PodCertificateRequest",
+	"PodDeletionCost",
+	"PodLevelResources",
+	"PodLifecycleSleepAction",
+	"PodLifecycleSleepActionAllowZero",
+	"PodLogsQuerySplitStreams",
+	"PodObservedGenerationTracking",
+	"PodReadyToStartContainersCondition",
+	"PodTopologyLabelsAdmission",
+	"PortForwardWebsockets",
+	"PreferSameTrafficDistribution",
+	"ProcMountType",
+	"QOSReserved",
+	"RecoverVolumeExpansionFailure",
+	"RecursiveReadOnlyMounts",
+	"ReduceDefaultCrashLoopBackOffDecay",
+	"RelaxedDNSSearchValidation",
+	"RelaxedEnvironmentVariableValidation",
+	"ReloadKubeletServerCertificateFile",
+	"RemoteRequestHeaderUID",
+	"ResilientWatchCacheInitialization",
+	"ResourceHealthStatus",
+	"RetryGenerateName",
+	"RotateKubeletServerCertificate",
+	"RuntimeClassInImageCriAPI",
+	"SELinuxChangePolicy",
+	"SELinuxMount",
+	"SELinuxMountReadWriteOncePod",
+	"SchedulerAsyncAPICalls",
+	"SchedulerAsyncPreemption",
+	"SchedulerPopFromBackoffQ",
+	"SchedulerQueueingHints",
+	"SeparateCacheWatchRPC",
+	"SeparateTaintEvictionController",
+	"ServiceAccountNodeAudienceRestriction",
+	"ServiceAccountTokenJTI",
+	"ServiceAccountTokenNodeBinding",
+	"ServiceAccountTokenNodeBindingValidation",
+	"ServiceAccountTokenPodNodeInfo",
+	"ServiceTrafficDistribution",
+	"SidecarContainers",
+	"SizeBasedListCostEstimate",
+	"StatefulSetAutoDeletePVC",
+	"StorageCapacityScoring",
+	"StorageVersionAPI",
+	"StorageVersionHash",
+	"StorageVersionMigrator",
+	"StreamingCollectionEncodingToJSON",
+	"StreamingCollectionEncodingToProtobuf",
+	"StructuredAuthenticationConfiguration",
+	"StructuredAuthenticationConfigurationEgressSelector",
+	"StructuredAuthenticationConfigurationJWKSMetrics",
+	"StructuredAuthorizationConfiguration",
+	"SupplementalGroupsPolicy",
+	"SystemdWatchdog",
+	"TokenRequestServiceAccountUIDValidation",
+	"TopologyManagerPolicyAlphaOptions",
+	"TopologyManagerPolicyBetaOptions",
+	"TopologyManagerPolicyOptions",
+	"TranslateStreamCloseWebsocketRequests",
+	"UnauthenticatedHTTP2DOSMitigation",
+	"UnknownVersionInteroperabilityProxy",
+	"UserNamespacesHostNetworkSupport",
+	"UserNamespacesSupport",
+	"VolumeAttributesClass",
+	"WatchCacheInitializationPostStartHook",
+	"WatchFromStorageWithoutResourceVersion",
+	"WatchList",
+	"WatchListClient",
+}
+
+// featureGateCoordinatedLeaderElection is always enabled for kube-apiserver
+// for stable rolling restart of API server processes.
+const featureGateCoordinatedLeaderElection = "CoordinatedLeaderElection"
+
+// featureGatesFlag is the command-line flag of Kubernetes components to set feature gates.
+const featureGatesFlag = "--feature-gates"
+
+// FeatureGatesArg returns the value of --feature-gates flag for gates.
+// The gates are sorted by name to make the value stable.
+func FeatureGatesArg(gates map[string]bool) string {
+	kv := make([]string, 0, len(gates))
+	for _, name := range slices.Sorted(maps.Keys(gates)) {
+		kv = append(kv, fmt.Sprintf("%s=%t", name, gates[name]))
+	}
+	return strings.Join(kv, ",")
+}
+
+// BaseProxyParams returns the kube-proxy parameters without the overrides of node groups.
+// The cluster-level feature gates are applied.
+func (c *Cluster) BaseProxyParams() ProxyParams {
+	p := c.Options.Proxy
+	p.Config = c.applyFeatureGates(p.Config, proxyv1alpha1.SchemeGroupVersion.String(), "KubeProxyConfiguration")
+	return p
+}
+
+// applyFeatureGates returns a copy of cfg with the cluster-level feature gates set to .featureGates.
+// The cluster-level gates take precedence over the ones in cfg.
+func (c *Cluster) applyFeatureGates(cfg *unstructured.Unstructured, apiVersion, kind string) *unstructured.Unstructured {
+	if len(c.FeatureGates) == 0 {
+		return cfg
+	}
+
+	gates := make(map[string]any, len(c.FeatureGates))
+	for name, enabled := range c.FeatureGates {
+		gates[name] = enabled
+	}
+	override := &unstructured.Unstructured{Object: map[string]any{"featureGates": gates}}
+	override.SetAPIVersion(apiVersion)
+	override.SetKind(kind)
+	return mergeUnstructured(cfg, override)
+}
+
+func validateFeatureGates(c *Cluster) error {
+	if len(c.FeatureGates) == 0 {
+		return nil
+	}
+
+	for name, enabled := range c.FeatureGates {
+		if !slices.Contains(knownFeatureGates, name) {
+			return fmt.Errorf("unknown feature gate for Kubernetes %s: %s", featureGatesVersion, name)
+		}
+		if name == featureGateCoordinatedLeaderElection && !enabled {
+			return fmt.Errorf("feature gate %s cannot be disabled", name)
+		}
+	}
+
+	check := func(component string, args []string) error {
+		for _, arg := range args {
+			if strings.HasPrefix(arg, featureGatesFlag) {
+				return fmt.Errorf("%s: %s conflicts with feature_gates", component, featureGatesFlag)
+			}
+		}
+		return nil
+	}
+	if err := check("kube-api", c.Options.APIServer.ExtraArguments); err != nil {
+		return err
+	}
+	if err := check("kube-controller-manager", c.Options.ControllerManager.ExtraArguments); err != nil {
+		return err
+	}
+	if err := check("kube-scheduler", c.Options.Scheduler.ExtraArguments); err != nil {
+		return err
+	}
+	if err := check("kube-proxy", c.Options.Proxy.ExtraArguments); err != nil {
+		return err
+	}
+	if err := check("kubelet", c.Options.Kubelet.ExtraArguments); err != nil {
+		return err
+	}
+	for i := range c.NodeGroups {
+		g := &c.NodeGroups[i]
+		if g.Kubelet != nil {
+			if err := check("node group "+g.Name+": kubelet", g.Kubelet.ExtraArguments); err != nil {
+				return err
+			}
+		}
+		if g.Proxy != nil {
+			if err := check("node group "+g.Name+": kube-proxy", g.Proxy.ExtraArguments); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package cke
+
+import (
+	"runtime/debug"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	_ "k8s.io/apiserver/pkg/features"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	proxyv1alpha1 "k8s.io/kube-proxy/config/v1alpha1"
+	kubeletv1beta1 "k8s.io/kubelet/config/v1beta1"
+)
+
+func TestKnownFeatureGates(t *testing.T) {
+	_, tag, _ := strings.Cut(KubernetesImage.Name(), ":")
+	if !strings.HasPrefix(tag, featureGatesVersion+".") {
+		t.Errorf("knownFeatureGates is for %s, but KubernetesImage is %s", featureGatesVersion, KubernetesImage)
+	}
+
+	// k8s.io libraries v0.X.Y are of Kubernetes 1.X.Y.
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		t.Fatal("no build info")
+	}
+	_, minor, _ := strings.Cut(featureGatesVersion, ".")
+	pinned := map[string]bool{"k8s.io/apiserver": false, "k8s.io/component-base": false}
+	for _, dep := range info.Deps {
+		if _, ok := pinned[dep.Path]; !ok {
+			continue
+		}
+		pinned[dep.Path] = true
+		if !strings.HasPrefix(dep.Version, "v0."+minor+".") {
+			t.Errorf("knownFeatureGates is for %s, but %s is %s", featureGatesVersion, dep.Path, dep.Version)
+		}
+	}
+	for path, found := range pinned {
+		if !found {
+			t.Error("no version of", path, "in the build info")
+		}
+	}
+
+	if !slices.IsSorted(knownFeatureGates) {
+		t.Error("knownFeatureGates is not sorted")
+	}
+
+	// the gates of the vendored apiserver library should be a subset.
+	for name := range utilfeature.DefaultMutableFeatureGate.GetAll() {
+		if !slices.Contains(knownFeatureGates, string(name)) {
+			t.Error("missing feature gate of kube-apiserver:", name)
+		}
+	}
+}
+
+func TestValidateFeatureGates(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Cluster)
+		wantErr bool
+	}{
+		{
+			name:   "no gates",
+			modify: func(c *Cluster) {},
+		},
+		{
+			name: "valid",
+			modify: func(c *Cluster) {
+				c.FeatureGates = map[string]bool{"InPlacePodVerticalScaling": false, "MutatingAdmissionPolicy": true}
+				c.Options.APIServer.ExtraArguments = []string{"--v=2"}
+			},
+		},
+		{
+			name: "unknown gate",
+			modify: func(c *Cluster) {
+				c.FeatureGates = map[string]bool{"NoSuchFeature": true}
+			},
+			wantErr: true,
+		},
+		{
+			name: "disable coordinated leader election",
+			modify: func(c *Cluster) {
+				c.FeatureGates = map[string]bool{"CoordinatedLeaderElection": false}
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflicting flag",
+			modify: func(c *Cluster) {
+				c.FeatureGates = map[string]bool{"InPlacePodVerticalScaling": false}
+				c.Options.Scheduler.ExtraArguments = []string{"--feature-gates=SchedulerQueueingHints=false"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflicting flag in node group",
+			modify: func(c *Cluster) {
+				c.FeatureGates = map[string]bool{"InPlacePodVerticalScaling": false}
+				c.NodeGroups = []NodeGroup{{
+					Name:    "storage",
+					Kubelet: &KubeletOverride{ServiceParams: ServiceParams{ExtraArguments: []string{"--feature-gates=NodeSwap=true"}}},
+				}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "flag without gates",
+			modify: func(c *Cluster) {
+				c.Options.Scheduler.ExtraArguments = []string{"--feature-gates=SchedulerQueueingHints=false"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Cluster{}
+			tt.modify(c)
+			err := validateFeatureGates(c)
+			if tt.wantErr && err == nil {
+				t.Error("error is expected")
+			}
+			if !tt.wantErr && err != nil {
+				t.Error("unexpected error", err)
+			}
+		})
+	}
+}
+
+func TestFeatureGatesConfig(t *testing.T) {
+	c := newNodeGroupCluster()
+	c.Options.Kubelet.Config.Object["featureGates"] = map[string]any{"NodeSwap": true, "KubeletTracing": true}
+	c.FeatureGates = map[string]bool{"KubeletTracing": false, "InPlacePodVerticalScaling": false}
+
+	cfg, err := c.KubeletParamsFor(c.Nodes[1]).MergeConfig(&kubeletv1beta1.KubeletConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{"NodeSwap": true, "KubeletTracing": false, "InPlacePodVerticalScaling": false}
+	if !cmp.Equal(cfg.FeatureGates, expected) {
+		t.Error("cluster-level gates should take precedence", cmp.Diff(cfg.FeatureGates, expected))
+	}
+
+	// kube-proxy has no config in the cluster options.
+	c.Options.Proxy.Config = nil
+	pc, err := c.BaseProxyParams().MergeConfig(&proxyv1alpha1.KubeProxyConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(pc.FeatureGates, c.FeatureGates) {
+		t.Error("gates should be set to kube-proxy config", pc.FeatureGates)
+	}
+
+	if _, ok := c.Options.Kubelet.Config.Object["featureGates"].(map[string]any)["InPlacePodVerticalScaling"]; ok {
+		t.Error("options config is modified")
+	}
+
+	if arg := FeatureGatesArg(c.FeatureGates); arg != "InPlacePodVerticalScaling=false,KubeletTracing=false" {
+		t.Error("unexpected arg", arg)
+	}
+}
@@ -0,0 +1,37 @@
+package cke
+
+import (
+	"errors"
+	"time"
+)
+
+// Freeze represents a global maintenance freeze.
+// While a freeze is active, CKE decides operations and reports the phase
+// but does not run any operations.
+type Freeze struct {
+	Reason    string    `json:"reason"`
+	Owner     string    `json:"owner"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is the time when the freeze is lifted automatically.
+	// If zero, the freeze lasts until cleared.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Validate validates the freeze.
+func (f *Freeze) Validate() error {
+	if f.Reason == "" {
+		return errors.New("reason is empty")
+	}
+	if f.Owner == "" {
+		return errors.New("owner is empty")
+	}
+	return nil
+}
+
+// IsActive returns true if the freeze is in effect at now.
+func (f *Freeze) IsActive(now time.Time) bool {
+	if f == nil {
+		return false
+	}
+	return f.ExpiresAt.IsZero() || now.Before(f.ExpiresAt)
+}
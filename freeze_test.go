@@ -0,0 +1,36 @@
+package cke
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreeze(t *testing.T) {
+	now := time.Now()
+
+	var nilFreeze *Freeze
+	if nilFreeze.IsActive(now) {
+		t.Error("nil freeze should not be active")
+	}
+
+	f := &Freeze{}
+	if err := f.Validate(); err == nil {
+		t.Error("empty freeze should be invalid")
+	}
+
+	f = &Freeze{Reason: "incident", Owner: "alice"}
+	if err := f.Validate(); err != nil {
+		t.Error(err)
+	}
+	if !f.IsActive(now) {
+		t.Error("freeze without expiry should be active")
+	}
+
+	f.ExpiresAt = now.Add(time.Hour)
+	if !f.IsActive(now) {
+		t.Error("freeze should be active before expiry")
+	}
+	if f.IsActive(now.Add(2 * time.Hour)) {
+		t.Error("freeze should not be active after expiry")
+	}
+}
@@ -30,9 +30,13 @@ require (
 	k8s.io/apimachinery v0.35.5
 	k8s.io/apiserver v0.35.5
 	k8s.io/client-go v0.35.5
+	k8s.io/component-base v0.35.5
+	k8s.io/kms v0.35.5
+	k8s.io/kube-controller-manager v0.35.5
 	k8s.io/kube-proxy v0.35.5
 	k8s.io/kube-scheduler v0.35.5
 	k8s.io/kubelet v0.35.5
+	k8s.io/pod-security-admission v0.35.5
 	sigs.k8s.io/yaml v1.6.0
 )
 
@@ -307,7 +311,8 @@ require (
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	honnef.co/go/tools v0.7.0 // indirect
-	k8s.io/component-base v0.35.5 // indirect
+	k8s.io/cloud-provider v0.35.5 // indirect
+	k8s.io/controller-manager v0.35.5 // indirect
 	k8s.io/klog/v2 v2.130.1 // indirect
 	k8s.io/kube-openapi v0.0.0-20250910181357-589584f1c912 // indirect
 	k8s.io/utils v0.0.0-20260507154919-ff6756f316d2 // indirect
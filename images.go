@@ -1,5 +1,13 @@
 package cke
 
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
 // Image is the type of container images.
 type Image string
 
@@ -12,7 +20,7 @@ func (i Image) Name() string {
 const (
 	EtcdImage            = Image("ghcr.io/cybozu/etcd:3.6.11.1")
 	KubernetesImage      = Image("ghcr.io/cybozu/kubernetes:1.35.5.1")
-	ToolsImage           = Image("ghcr.io/cybozu-go/cke-tools:1.35.0")
+	ToolsImage           = Image("ghcr.io/cybozu-go/cke-tools:1.35.1")
 	PauseImage           = Image("ghcr.io/cybozu/pause:3.10.1.5")
 	CoreDNSImage         = Image("ghcr.io/cybozu/coredns:1.14.2.1")
 	UnboundImage         = Image("ghcr.io/cybozu/unbound:1.25.1.1")
@@ -31,3 +39,113 @@ func AllImages() []string {
 		UnboundExporterImage.Name(),
 	}
 }
+
+var (
+	registryHostPattern   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?(:[0-9]+)?$`)
+	repositoryPathPattern = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*(/[a-z0-9]+([._-][a-z0-9]+)*)*$`)
+	digestPattern         = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+)
+
+// ImageOverrides replaces the container images used by CKE, for instance,
+// to pull them from an internal registry.
+type ImageOverrides struct {
+	// RegistryMirrors maps registry hosts to the mirrors.
+	// A mirror is a registry host optionally followed by a path prefix.
+	RegistryMirrors map[string]string `json:"registry_mirrors,omitempty"`
+
+	// Overrides maps the default image names to the replacements pinned by digest.
+	// Overrides take precedence over RegistryMirrors.
+	Overrides map[string]string `json:"overrides,omitempty"`
+}
+
+// Resolve returns the image to be used in place of img.
+// o may be nil.
+func (o *ImageOverrides) Resolve(img Image) Image {
+	if o == nil {
+		return img
+	}
+	if r, ok := o.Overrides[img.Name()]; ok {
+		return Image(r)
+	}
+	host, rest, ok := strings.Cut(img.Name(), "/")
+	if !ok {
+		return img
+	}
+	if mirror, ok := o.RegistryMirrors[host]; ok {
+		return Image(mirror + "/" + rest)
+	}
+	return img
+}
+
+// ResolveResource returns d whose images are replaced as Resolve does.
+// The images are replaced in both d.Definition and d.Image, so that
+// the resource is updated when the overrides are changed.
+// o may be nil.
+func (o *ImageOverrides) ResolveResource(d ResourceDefinition) ResourceDefinition {
+	if o == nil || d.Image == "" {
+		return d
+	}
+	images := strings.Split(d.Image, ",")
+	for i, img := range images {
+		resolved := o.Resolve(Image(img)).Name()
+		if resolved == img {
+			continue
+		}
+		d.Definition = bytes.ReplaceAll(d.Definition, []byte(img), []byte(resolved))
+		images[i] = resolved
+	}
+	d.Image = strings.Join(images, ",")
+	return d
+}
+
+// splitImageName splits an image name into the repository, the tag, and the digest.
+func splitImageName(name string) (repo, tag, digest string) {
+	repo, digest, _ = strings.Cut(name, "@")
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo, tag = repo[:i], repo[i+1:]
+	}
+	return repo, tag, digest
+}
+
+// validateRepository validates a repository name including the registry host.
+func validateRepository(repo string) error {
+	host, path, ok := strings.Cut(repo, "/")
+	if !ok || !registryHostPattern.MatchString(host) || !repositoryPathPattern.MatchString(path) {
+		return fmt.Errorf("invalid repository: %s", repo)
+	}
+	return nil
+}
+
+func validateImageOverrides(o *ImageOverrides) error {
+	if o == nil {
+		return nil
+	}
+
+	for host, mirror := range o.RegistryMirrors {
+		if !registryHostPattern.MatchString(host) {
+			return fmt.Errorf("invalid registry host: %s", host)
+		}
+		if !registryHostPattern.MatchString(mirror) {
+			if err := validateRepository(mirror); err != nil {
+				return fmt.Errorf("invalid mirror for %s: %s", host, mirror)
+			}
+		}
+	}
+
+	// overrides are keyed by the default image names including the tags, so that
+	// stale overrides are rejected when CKE updates the default images.
+	for name, replacement := range o.Overrides {
+		if !slices.Contains(AllImages(), name) {
+			return fmt.Errorf("image to override is not used by this version of CKE: %s", name)
+		}
+
+		repo, _, digest := splitImageName(replacement)
+		if err := validateRepository(repo); err != nil {
+			return fmt.Errorf("invalid replacement for %s: %w", name, err)
+		}
+		if !digestPattern.MatchString(digest) {
+			return fmt.Errorf("replacement for %s is not pinned by digest: %s", name, replacement)
+		}
+	}
+	return nil
+}
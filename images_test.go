@@ -0,0 +1,158 @@
+package cke
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestImageOverridesResolve(t *testing.T) {
+	var nilOverrides *ImageOverrides
+	if img := nilOverrides.Resolve(EtcdImage); img != EtcdImage {
+		t.Error("nil overrides should not change images", img)
+	}
+
+	o := &ImageOverrides{
+		RegistryMirrors: map[string]string{"ghcr.io": "registry.example.com/ghcr"},
+		Overrides: map[string]string{
+			KubernetesImage.Name(): "registry.example.com/kubernetes:patched@" + testDigest,
+		},
+	}
+	if img := o.Resolve(EtcdImage); img.Name() != "registry.example.com/ghcr/"+strings.TrimPrefix(EtcdImage.Name(), "ghcr.io/") {
+		t.Error("mirror is not applied", img)
+	}
+	if img := o.Resolve(KubernetesImage); img.Name() != "registry.example.com/kubernetes:patched@"+testDigest {
+		t.Error("override should take precedence", img)
+	}
+	if img := o.Resolve(Image("quay.io/foo/bar:1.0")); img != "quay.io/foo/bar:1.0" {
+		t.Error("image of other registry is changed", img)
+	}
+}
+
+func TestImageOverridesResolveResource(t *testing.T) {
+	d := ResourceDefinition{
+		Key:        "DaemonSet/kube-system/node-dns",
+		Image:      UnboundImage.Name() + "," + UnboundExporterImage.Name(),
+		Definition: []byte("image: " + UnboundImage.Name() + "\nimage: " + UnboundExporterImage.Name() + "\n"),
+	}
+
+	var nilOverrides *ImageOverrides
+	if r := nilOverrides.ResolveResource(d); !cmp.Equal(r, d) {
+		t.Error("nil overrides should not change resources", cmp.Diff(r, d))
+	}
+
+	o := &ImageOverrides{
+		Overrides: map[string]string{
+			UnboundExporterImage.Name(): "registry.example.com/unbound_exporter@" + testDigest,
+		},
+	}
+	r := o.ResolveResource(d)
+	expectedImage := UnboundImage.Name() + ",registry.example.com/unbound_exporter@" + testDigest
+	if r.Image != expectedImage {
+		t.Error("unexpected image", r.Image)
+	}
+	expectedDef := "image: " + UnboundImage.Name() + "\nimage: registry.example.com/unbound_exporter@" + testDigest + "\n"
+	if string(r.Definition) != expectedDef {
+		t.Error("unexpected definition", string(r.Definition))
+	}
+	if string(d.Definition) == expectedDef {
+		t.Error("the original definition is modified")
+	}
+
+	noImage := ResourceDefinition{Key: "Service/kube-system/cluster-dns", Definition: []byte("kind: Service\n")}
+	if r := o.ResolveResource(noImage); !cmp.Equal(r, noImage) {
+		t.Error("resource without images should not change", cmp.Diff(r, noImage))
+	}
+}
+
+func TestValidateImageOverrides(t *testing.T) {
+	repo, _, _ := splitImageName(EtcdImage.Name())
+
+	tests := []struct {
+		name      string
+		overrides *ImageOverrides
+		wantErr   bool
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "valid",
+			overrides: &ImageOverrides{
+				RegistryMirrors: map[string]string{
+					"ghcr.io":   "registry.example.com:5000",
+					"docker.io": "registry.example.com/docker",
+					"quay.io":   "quay-mirror.example.com",
+				},
+				Overrides: map[string]string{
+					EtcdImage.Name():    "registry.example.com/etcd@" + testDigest,
+					CoreDNSImage.Name(): "registry.example.com/coredns:1.0@" + testDigest,
+				},
+			},
+		},
+		{
+			name: "invalid registry host",
+			overrides: &ImageOverrides{
+				RegistryMirrors: map[string]string{"ghcr.io/cybozu": "registry.example.com"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid mirror",
+			overrides: &ImageOverrides{
+				RegistryMirrors: map[string]string{"ghcr.io": "registry.example.com/GHCR"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown image",
+			overrides: &ImageOverrides{
+				Overrides: map[string]string{"ghcr.io/cybozu/nginx:1.0": "registry.example.com/nginx@" + testDigest},
+			},
+			wantErr: true,
+		},
+		{
+			name: "stale override",
+			overrides: &ImageOverrides{
+				Overrides: map[string]string{repo + ":0.0.0.1": "registry.example.com/etcd@" + testDigest},
+			},
+			wantErr: true,
+		},
+		{
+			name: "override without tag",
+			overrides: &ImageOverrides{
+				Overrides: map[string]string{repo: "registry.example.com/etcd@" + testDigest},
+			},
+			wantErr: true,
+		},
+		{
+			name: "replacement without digest",
+			overrides: &ImageOverrides{
+				Overrides: map[string]string{EtcdImage.Name(): "registry.example.com/etcd:3.6"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid replacement",
+			overrides: &ImageOverrides{
+				Overrides: map[string]string{EtcdImage.Name(): "etcd@" + testDigest},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateImageOverrides(tt.overrides)
+			if tt.wantErr && err == nil {
+				t.Error("error is expected")
+			}
+			if !tt.wantErr && err != nil {
+				t.Error("unexpected error", err)
+			}
+		})
+	}
+}
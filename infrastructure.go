@@ -148,6 +148,7 @@ var kubeHTTP KubeHTTP
 type ckeInfrastructure struct {
 	agents  map[string]Agent
 	storage Storage
+	images  *ImageOverrides
 
 	etcdOnce sync.Once
 	etcdErr  error
@@ -237,7 +238,7 @@ func NewInfrastructure(ctx context.Context, c *Cluster, s Storage) (Infrastructu
 	}
 
 	// This assignment of the `agent` must be placed last.
-	inf := &ckeInfrastructure{agents: agents, storage: s}
+	inf := &ckeInfrastructure{agents: agents, storage: s, images: c.Images}
 	agents = nil
 	return inf, nil
 }
@@ -247,7 +248,7 @@ func (i *ckeInfrastructure) Agent(addr string) Agent {
 }
 
 func (i *ckeInfrastructure) Engine(addr string) ContainerEngine {
-	return Docker(i.agents[addr])
+	return Docker(i.agents[addr], i.images)
 }
 
 func (i *ckeInfrastructure) Vault() (*vault.Client, error) {
@@ -0,0 +1,97 @@
+package cke
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// DefaultKMSTimeoutSeconds is the default value of KMSParams.TimeoutSeconds.
+const DefaultKMSTimeoutSeconds = 3
+
+// KMSSecret is the path of AppRole credentials of the KMS plugin in Vault.
+const KMSSecret = CKESecret + "/kms"
+
+// KMSTransitPath is the mount path of the transit secrets engine for the KMS plugin.
+const KMSTransitPath = "cke/transit"
+
+// KMSTransitKey is the name of the transit key to encrypt data encryption keys of kube-apiserver.
+const KMSTransitKey = "kubernetes"
+
+// KMSParams is a set of parameters for the KMS v2 plugin running next to kube-apiserver.
+type KMSParams struct {
+	ServiceParams `json:",inline"`
+
+	// TimeoutSeconds is the timeout for kube-apiserver to call the plugin.
+	TimeoutSeconds *int `json:"timeout_seconds,omitempty"`
+}
+
+// GetTimeoutSeconds returns the timeout for kube-apiserver to call the plugin.
+func (p *KMSParams) GetTimeoutSeconds() int {
+	if p == nil || p.TimeoutSeconds == nil {
+		return DefaultKMSTimeoutSeconds
+	}
+	return *p.TimeoutSeconds
+}
+
+// UsesKMS returns true if the keys include a KMS key.
+func (s *EncryptionStatus) UsesKMS() bool {
+	if s == nil {
+		return false
+	}
+	for _, k := range s.Keys {
+		if k.Provider == EncryptionProviderKMS {
+			return true
+		}
+	}
+	return false
+}
+
+// KMSPluginEnabled returns true if the KMS plugin should run next to kube-apiserver.
+// The plugin keeps running while the keys include a KMS key even if kms is
+// removed from the params, because kube-apiserver needs it to decrypt data.
+func KMSPluginEnabled(params APIServerParams, st *EncryptionStatus) bool {
+	return params.KMS != nil || st.UsesKMS()
+}
+
+func validateKMS(p APIServerParams) error {
+	if p.KMS == nil {
+		return nil
+	}
+	if p.KMS.TimeoutSeconds != nil && *p.KMS.TimeoutSeconds <= 0 {
+		return errors.New("kms.timeout_seconds must be positive")
+	}
+	for _, arg := range p.KMS.ExtraArguments {
+		switch {
+		case strings.HasPrefix(arg, "--listen"), strings.HasPrefix(arg, "--vault-config"):
+			return fmt.Errorf("%s cannot be given to the KMS plugin", arg)
+		}
+	}
+	return nil
+}
+
+// KMSCredentials is the AppRole credentials of the KMS plugin.
+type KMSCredentials struct {
+	RoleID   string `json:"role-id"`
+	SecretID string `json:"secret-id"`
+}
+
+// ReadKMSCredentials reads the AppRole credentials of the KMS plugin from Vault.
+func ReadKMSCredentials(vc *vault.Client) (*KMSCredentials, error) {
+	secret, err := vc.Logical().Read(KMSSecret)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("no credentials for the KMS plugin; run \"ckecli vault init\" to create them")
+	}
+
+	roleID, _ := secret.Data["role-id"].(string)
+	secretID, _ := secret.Data["secret-id"].(string)
+	if roleID == "" || secretID == "" {
+		return nil, errors.New("invalid credentials for the KMS plugin")
+	}
+	return &KMSCredentials{RoleID: roleID, SecretID: secretID}, nil
+}
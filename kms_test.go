@@ -0,0 +1,61 @@
+package cke
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateKMS(t *testing.T) {
+	tests := []struct {
+		name    string
+		kms     *KMSParams
+		wantErr bool
+	}{
+		{"no kms", nil, false},
+		{"default", &KMSParams{}, false},
+		{"timeout", &KMSParams{TimeoutSeconds: new(10)}, false},
+		{"zero timeout", &KMSParams{TimeoutSeconds: new(0)}, true},
+		{"extra args", &KMSParams{ServiceParams: ServiceParams{ExtraArguments: []string{"--timeout=5s"}}}, false},
+		{"conflicting flag", &KMSParams{ServiceParams: ServiceParams{ExtraArguments: []string{"--listen=/tmp/kms.sock"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKMS(APIServerParams{KMS: tt.kms})
+			if tt.wantErr && err == nil {
+				t.Error("error is expected")
+			}
+			if !tt.wantErr && err != nil {
+				t.Error("unexpected error", err)
+			}
+		})
+	}
+}
+
+func TestKMSPluginEnabled(t *testing.T) {
+	secrets := make(EncryptionKeySecrets)
+	k, err := secrets.Generate(EncryptionProviderKMS, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Name != "vault-20260102T030405Z" || strings.Contains(k.Name, ":") {
+		t.Error("unexpected KMS key name", k.Name)
+	}
+	if len(secrets) != 0 {
+		t.Error("KMS keys should not have secrets", secrets)
+	}
+
+	aescbc := &EncryptionStatus{Keys: []EncryptionKeyInfo{{Name: "old", Provider: EncryptionProviderAESCBC}}}
+	kms := &EncryptionStatus{Keys: []EncryptionKeyInfo{k}}
+
+	if KMSPluginEnabled(APIServerParams{}, nil) || KMSPluginEnabled(APIServerParams{}, aescbc) {
+		t.Error("KMS plugin should be disabled")
+	}
+	if !KMSPluginEnabled(APIServerParams{KMS: &KMSParams{}}, aescbc) {
+		t.Error("KMS plugin should be enabled by the params")
+	}
+	if !KMSPluginEnabled(APIServerParams{}, kms) {
+		t.Error("KMS plugin should be enabled while KMS keys are used")
+	}
+}
@@ -47,3 +47,43 @@ func UserKubeconfig(cluster, userName, ca, clientCrt, clientKey, server string)
 
 	return cfg
 }
+
+// OIDCKubeconfig makes kubeconfig for users authenticated by an OpenID Connect provider.
+// ID tokens are obtained by kubelogin (kubectl oidc-login) as an exec credential plugin.
+func OIDCKubeconfig(cluster, ca, server, issuerURL, clientID, clientSecret string, extraScopes []string) *api.Config {
+	cfg := api.NewConfig()
+	c := api.NewCluster()
+	c.Server = server
+	c.CertificateAuthorityData = []byte(ca)
+	cfg.Clusters[cluster] = c
+
+	args := []string{
+		"oidc-login",
+		"get-token",
+		"--oidc-issuer-url=" + issuerURL,
+		"--oidc-client-id=" + clientID,
+	}
+	if clientSecret != "" {
+		args = append(args, "--oidc-client-secret="+clientSecret)
+	}
+	for _, scope := range extraScopes {
+		args = append(args, "--oidc-extra-scope="+scope)
+	}
+
+	auth := api.NewAuthInfo()
+	auth.Exec = &api.ExecConfig{
+		APIVersion:      "client.authentication.k8s.io/v1",
+		Command:         "kubectl",
+		Args:            args,
+		InteractiveMode: api.IfAvailableExecInteractiveMode,
+	}
+	cfg.AuthInfos["oidc"] = auth
+
+	ctx := api.NewContext()
+	ctx.AuthInfo = "oidc"
+	ctx.Cluster = cluster
+	cfg.Contexts["default"] = ctx
+	cfg.CurrentContext = "default"
+
+	return cfg
+}
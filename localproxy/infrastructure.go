@@ -25,13 +25,14 @@ var kubeHTTP cke.KubeHTTP
 
 type localInfra struct {
 	storage cke.Storage
+	images  *cke.ImageOverrides
 	vc      *vault.Client
 }
 
 var _ cke.Infrastructure = &localInfra{}
 
-func newInfrastructure(storage cke.Storage) cke.Infrastructure {
-	return &localInfra{storage: storage}
+func newInfrastructure(storage cke.Storage, images *cke.ImageOverrides) cke.Infrastructure {
+	return &localInfra{storage: storage, images: images}
 }
 
 func (i *localInfra) Close() {}
@@ -41,7 +42,7 @@ func (i *localInfra) Agent(addr string) cke.Agent {
 }
 
 func (i *localInfra) Engine(addr string) cke.ContainerEngine {
-	return localDocker{}
+	return localDocker{images: i.images}
 }
 
 func (i *localInfra) Vault() (*vault.Client, error) {
@@ -109,23 +110,26 @@ func (i *localInfra) ReleaseAgent(addr string) {
 	panic("not implemented") // TODO: Implement
 }
 
-type localDocker struct{}
+type localDocker struct {
+	images *cke.ImageOverrides
+}
 
 var _ cke.ContainerEngine = localDocker{}
 
 // PullImage pulls an image.
 func (l localDocker) PullImage(img cke.Image) error {
-	cmd := exec.Command("docker", "image", "list", "--format={{.Repository}}:{{.Tag}}")
+	name := l.images.Resolve(img).Name()
+	cmd := exec.Command("docker", "image", "list", "--format={{.Repository}}:{{.Tag}} {{.Repository}}@{{.Digest}}")
 	stdout, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to execute docker image list: %w", err)
 	}
 
-	if slices.Contains(strings.Fields(string(stdout)), img.Name()) {
+	if slices.Contains(strings.Fields(string(stdout)), name) {
 		return nil
 	}
 
-	return exec.Command("docker", "image", "pull", img.Name()).Run()
+	return exec.Command("docker", "image", "pull", name).Run()
 }
 
 // Run runs a container as a foreground process.
@@ -145,7 +149,7 @@ func (l localDocker) Run(img cke.Image, binds []cke.Mount, command string, args
 		}
 		runArgs = append(runArgs, fmt.Sprintf("--volume=%s:%s:%s", m.Source, m.Destination, o))
 	}
-	runArgs = append(runArgs, img.Name(), command)
+	runArgs = append(runArgs, l.images.Resolve(img).Name(), command)
 	runArgs = append(runArgs, args...)
 
 	out, err := exec.Command("docker", runArgs...).CombinedOutput()
@@ -173,7 +177,7 @@ func (l localDocker) RunWithInput(img cke.Image, binds []cke.Mount, command, inp
 		}
 		runArgs = append(runArgs, fmt.Sprintf("--volume=%s:%s:%s", m.Source, m.Destination, o))
 	}
-	runArgs = append(runArgs, img.Name(), command)
+	runArgs = append(runArgs, l.images.Resolve(img).Name(), command)
 	runArgs = append(runArgs, args...)
 
 	cmd := exec.Command("docker", runArgs...)
@@ -203,7 +207,7 @@ func (l localDocker) RunWithOutput(img cke.Image, binds []cke.Mount, command str
 		}
 		runArgs = append(runArgs, fmt.Sprintf("--volume=%s:%s:%s", m.Source, m.Destination, o))
 	}
-	runArgs = append(runArgs, img.Name(), command)
+	runArgs = append(runArgs, l.images.Resolve(img).Name(), command)
 	runArgs = append(runArgs, args...)
 
 	stdout := new(bytes.Buffer)
@@ -215,6 +219,35 @@ func (l localDocker) RunWithOutput(img cke.Image, binds []cke.Mount, command str
 	return stdout.Bytes(), stderr.Bytes(), err
 }
 
+// RunPrivileged runs a privileged container as a foreground process.
+func (l localDocker) RunPrivileged(img cke.Image, binds []cke.Mount, command string, args ...string) error {
+	runArgs := []string{
+		"run",
+		"--log-driver=journald",
+		"--rm",
+		"--network=host",
+		"--uts=host",
+		"--read-only",
+		"--privileged",
+		"--tmpfs=/run",
+	}
+	for _, m := range binds {
+		o := "rw"
+		if m.ReadOnly {
+			o = "ro"
+		}
+		runArgs = append(runArgs, fmt.Sprintf("--volume=%s:%s:%s", m.Source, m.Destination, o))
+	}
+	runArgs = append(runArgs, l.images.Resolve(img).Name(), command)
+	runArgs = append(runArgs, args...)
+
+	out, err := exec.Command("docker", runArgs...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %s: %w", img.Name(), out, err)
+	}
+	return nil
+}
+
 // RunSystem runs the named container as a system service.
 func (l localDocker) RunSystem(name string, img cke.Image, opts []string, params cke.ServiceParams, extra cke.ServiceParams) error {
 	args := []string{
@@ -277,7 +310,7 @@ func (l localDocker) RunSystem(name string, img cke.Image, opts []string, params
 	}
 	args = append(args, "--label-file="+labelFile.Name())
 
-	args = append(args, img.Name())
+	args = append(args, l.images.Resolve(img).Name())
 
 	args = append(args, params.ExtraArguments...)
 	args = append(args, extra.ExtraArguments...)
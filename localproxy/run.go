@@ -37,9 +37,6 @@ func (c *LocalProxy) Run(ctx context.Context) error {
 }
 
 func (c *LocalProxy) runOnce(ctx context.Context) error {
-	inf := newInfrastructure(c.Storage)
-	defer inf.Close()
-
 	cluster, err := c.Storage.GetCluster(ctx)
 	if errors.Is(err, cke.ErrNotFound) {
 		return nil
@@ -48,6 +45,9 @@ func (c *LocalProxy) runOnce(ctx context.Context) error {
 		return fmt.Errorf("failed to get cluster: %w", err)
 	}
 
+	inf := newInfrastructure(c.Storage, cluster.Images)
+	defer inf.Close()
+
 	st, err := getStatus(ctx, inf)
 	if err != nil {
 		log.Error("failed to get status", map[string]any{
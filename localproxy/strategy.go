@@ -22,10 +22,10 @@ func decideOps(c *cke.Cluster, currentAP string, st *status) (newAP string, ops
 	apURL := fmt.Sprintf("https://%s:6443", newAP)
 
 	if !st.proxyRunning {
-		ops = append(ops, k8s.KubeProxyBootOp(ckeNodes, c.Name, apURL, c.Options.Proxy))
+		ops = append(ops, k8s.KubeProxyBootOp(ckeNodes, c.Name, apURL, c.BaseProxyParams()))
 	} else {
-		if newAP != currentAP || st.proxyImage != cke.KubernetesImage.Name() {
-			ops = append(ops, k8s.KubeProxyRestartOp(ckeNodes, c.Name, apURL, c.Options.Proxy))
+		if newAP != currentAP || st.proxyImage != c.Images.Resolve(cke.KubernetesImage).Name() {
+			ops = append(ops, k8s.KubeProxyRestartOp(ckeNodes, c.Name, apURL, c.BaseProxyParams()))
 		}
 	}
 
@@ -34,7 +34,7 @@ func decideOps(c *cke.Cluster, currentAP string, st *status) (newAP string, ops
 		return
 	}
 
-	if !bytes.Equal(st.unboundConf, st.desiredUnboundConf) || st.unboundImage != cke.UnboundImage.Name() {
+	if !bytes.Equal(st.unboundConf, st.desiredUnboundConf) || st.unboundImage != c.Images.Resolve(cke.UnboundImage).Name() {
 		ops = append(ops, &unboundRestartOp{conf: st.desiredUnboundConf})
 	}
 
@@ -62,6 +62,10 @@ func NewCollector(storage storage) prometheus.Collector {
 				collectors:  []prometheus.Collector{operationPhase, operationPhaseTimestampSeconds},
 				isAvailable: isOperationPhaseAvailable,
 			},
+			"freeze": {
+				collectors:  []prometheus.Collector{frozen, freezeExpiresTimestampSeconds},
+				isAvailable: isFreezeAvailable,
+			},
 			"node": {
 				collectors:  []prometheus.Collector{nodeMetricsCollector{storage}},
 				isAvailable: isNodeAvailable,
@@ -33,6 +33,22 @@ var operationPhaseTimestampSeconds = prometheus.NewGauge(
 	},
 )
 
+var frozen = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "frozen",
+		Help:      "1 if operations are suspended by a maintenance freeze.",
+	},
+)
+
+var freezeExpiresTimestampSeconds = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "freeze_expires_timestamp_seconds",
+		Help:      "The Unix timestamp when the active freeze expires.  0 if it never expires or no freeze is active.",
+	},
+)
+
 var rebootQueueEnabled = prometheus.NewDesc(
 	prometheus.BuildFQName(namespace, "", "reboot_queue_enabled"),
 	"1 if reboot queue is enabled.",
@@ -39,6 +39,26 @@ func isOperationPhaseAvailable(_ context.Context, _ storage) (bool, error) {
 	return isLeader, nil
 }
 
+// UpdateFreeze updates "frozen" and its expiration time.
+func UpdateFreeze(active bool, f *cke.Freeze) {
+	if !active {
+		frozen.Set(0)
+		freezeExpiresTimestampSeconds.Set(0)
+		return
+	}
+
+	frozen.Set(1)
+	if f.ExpiresAt.IsZero() {
+		freezeExpiresTimestampSeconds.Set(0)
+	} else {
+		freezeExpiresTimestampSeconds.Set(float64(f.ExpiresAt.Unix()))
+	}
+}
+
+func isFreezeAvailable(_ context.Context, _ storage) (bool, error) {
+	return isLeader, nil
+}
+
 func isNodeAvailable(_ context.Context, _ storage) (bool, error) {
 	return isLeader, nil
 }
@@ -104,6 +104,7 @@ type updateSabakanIntegrationTestCase struct {
 func TestMetricsUpdater(t *testing.T) {
 	t.Run("UpdateLeader", testUpdateLeader)
 	t.Run("UpdateOperationPhase", testUpdateOperationPhase)
+	t.Run("UpdateFreeze", testUpdateFreeze)
 	t.Run("UpdateRebootQueueEntries", testUpdateRebootQueueEntries)
 	t.Run("UpdateRebootQueueItems", testUpdateRebootQueueItems)
 	t.Run("UpdateNodeRebootStatus", testUpdateNodeRebootStatus)
@@ -276,6 +277,89 @@ func testUpdateOperationPhase(t *testing.T) {
 	}
 }
 
+func testUpdateFreeze(t *testing.T) {
+	expires := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name            string
+		isLeader        bool
+		active          bool
+		freeze          *cke.Freeze
+		returned        bool
+		expectedFrozen  float64
+		expectedExpires float64
+	}{
+		{
+			name:     "not leader",
+			isLeader: false,
+			returned: false,
+		},
+		{
+			name:     "not frozen",
+			isLeader: true,
+			returned: true,
+		},
+		{
+			name:            "frozen",
+			isLeader:        true,
+			active:          true,
+			freeze:          &cke.Freeze{Reason: "test", Owner: "test", ExpiresAt: expires},
+			returned:        true,
+			expectedFrozen:  1,
+			expectedExpires: float64(expires.Unix()),
+		},
+		{
+			name:           "frozen without expiration",
+			isLeader:       true,
+			active:         true,
+			freeze:         &cke.Freeze{Reason: "test", Owner: "test"},
+			returned:       true,
+			expectedFrozen: 1,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			collector, _ := newTestCollector()
+			handler := GetHandler(collector)
+
+			UpdateLeader(tt.isLeader)
+			UpdateFreeze(tt.active, tt.freeze)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			handler.ServeHTTP(w, req)
+
+			metricsFamily, err := parseMetrics(w.Result())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := map[string]float64{
+				"cke_frozen":                           tt.expectedFrozen,
+				"cke_freeze_expires_timestamp_seconds": tt.expectedExpires,
+			}
+			found := map[string]bool{}
+			for _, mf := range metricsFamily {
+				exp, ok := expected[*mf.Name]
+				if !ok {
+					continue
+				}
+				found[*mf.Name] = true
+				if v := *mf.Metric[0].Gauge.Value; v != exp {
+					t.Errorf("value for %s is wrong.  expected: %f, actual: %f", *mf.Name, exp, v)
+				}
+			}
+			for name := range expected {
+				if tt.returned && !found[name] {
+					t.Errorf("metrics %s was not found", name)
+				}
+				if !tt.returned && found[name] {
+					t.Errorf("metrics %s should not be returned", name)
+				}
+			}
+		})
+	}
+}
+
 func testUpdateRebootQueueEntries(t *testing.T) {
 	testCases := []updateRebootQueueEntriesTestCase{
 		{
@@ -0,0 +1,238 @@
+package cke
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	proxyv1alpha1 "k8s.io/kube-proxy/config/v1alpha1"
+	kubeletv1beta1 "k8s.io/kubelet/config/v1beta1"
+)
+
+// KubeletOverride is a set of kubelet parameters overridden for a node group.
+type KubeletOverride struct {
+	// ServiceParams are appended to the cluster-wide ones.
+	// Environment variables of the same name are replaced.
+	ServiceParams `json:",inline"`
+	// BootTaints replace the cluster-wide ones if not nil.
+	BootTaints []corev1.Taint `json:"boot_taints,omitempty"`
+	// Config is merged into the cluster-wide KubeletConfiguration.
+	Config *unstructured.Unstructured `json:"config,omitempty"`
+}
+
+// ProxyOverride is a set of kube-proxy parameters overridden for a node group.
+type ProxyOverride struct {
+	// ServiceParams are appended to the cluster-wide ones.
+	// Environment variables of the same name are replaced.
+	ServiceParams `json:",inline"`
+	// Config is merged into the cluster-wide KubeProxyConfiguration.
+	Config *unstructured.Unstructured `json:"config,omitempty"`
+}
+
+// NodeGroup is a group of nodes that need different component options from the others.
+// A node belongs to the group if its address is listed in Nodes or its labels match NodeSelector.
+type NodeGroup struct {
+	Name         string                `json:"name"`
+	Nodes        []string              `json:"nodes,omitempty"`
+	NodeSelector *metav1.LabelSelector `json:"node_selector,omitempty"`
+	Kubelet      *KubeletOverride      `json:"kubelet,omitempty"`
+	Proxy        *ProxyOverride        `json:"kube-proxy,omitempty"`
+}
+
+// Contains returns true if n belongs to the group.
+func (g *NodeGroup) Contains(n *Node) bool {
+	for _, a := range g.Nodes {
+		if a == n.Address {
+			return true
+		}
+	}
+	if g.NodeSelector == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(g.NodeSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(n.Labels))
+}
+
+// NodeGroupsFor returns the names of node groups that n belongs to in order.
+func (c *Cluster) NodeGroupsFor(n *Node) []string {
+	var names []string
+	for i := range c.NodeGroups {
+		if c.NodeGroups[i].Contains(n) {
+			names = append(names, c.NodeGroups[i].Name)
+		}
+	}
+	return names
+}
+
+// KubeletParamsFor returns the effective kubelet parameters for n.
+// Overrides of node groups are applied in order, then the cluster-level feature gates.
+func (c *Cluster) KubeletParamsFor(n *Node) KubeletParams {
+	p := c.Options.Kubelet
+	for i := range c.NodeGroups {
+		g := &c.NodeGroups[i]
+		if g.Kubelet == nil || !g.Contains(n) {
+			continue
+		}
+		p.ServiceParams = mergeServiceParams(p.ServiceParams, g.Kubelet.ServiceParams)
+		if g.Kubelet.BootTaints != nil {
+			p.BootTaints = g.Kubelet.BootTaints
+		}
+		p.Config = mergeUnstructured(p.Config, g.Kubelet.Config)
+	}
+	p.Config = c.applyFeatureGates(p.Config, kubeletv1beta1.SchemeGroupVersion.String(), "KubeletConfiguration")
+	return p
+}
+
+// ProxyParamsFor returns the effective kube-proxy parameters for n.
+// Overrides of node groups are applied in order, then the cluster-level feature gates.
+func (c *Cluster) ProxyParamsFor(n *Node) ProxyParams {
+	p := c.Options.Proxy
+	for i := range c.NodeGroups {
+		g := &c.NodeGroups[i]
+		if g.Proxy == nil || !g.Contains(n) {
+			continue
+		}
+		p.ServiceParams = mergeServiceParams(p.ServiceParams, g.Proxy.ServiceParams)
+		p.Config = mergeUnstructured(p.Config, g.Proxy.Config)
+	}
+	p.Config = c.applyFeatureGates(p.Config, proxyv1alpha1.SchemeGroupVersion.String(), "KubeProxyConfiguration")
+	return p
+}
+
+func mergeServiceParams(base, override ServiceParams) ServiceParams {
+	p := ServiceParams{
+		ExtraArguments: append(append([]string{}, base.ExtraArguments...), override.ExtraArguments...),
+		ExtraBinds:     append(append([]Mount{}, base.ExtraBinds...), override.ExtraBinds...),
+	}
+	if len(p.ExtraArguments) == 0 {
+		p.ExtraArguments = nil
+	}
+	if len(p.ExtraBinds) == 0 {
+		p.ExtraBinds = nil
+	}
+	if base.ExtraEnvvar != nil || override.ExtraEnvvar != nil {
+		p.ExtraEnvvar = make(map[string]string)
+		maps.Copy(p.ExtraEnvvar, base.ExtraEnvvar)
+		maps.Copy(p.ExtraEnvvar, override.ExtraEnvvar)
+	}
+	return p
+}
+
+// mergeUnstructured returns a deep copy of base with override merged recursively.
+func mergeUnstructured(base, override *unstructured.Unstructured) *unstructured.Unstructured {
+	if override == nil {
+		return base
+	}
+	if base == nil {
+		return override.DeepCopy()
+	}
+	merged := base.DeepCopy()
+	mergeObject(merged.Object, override.DeepCopy().Object)
+	return merged
+}
+
+func mergeObject(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, ok1 := v.(map[string]any)
+		dstMap, ok2 := dst[k].(map[string]any)
+		if ok1 && ok2 {
+			mergeObject(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+func validateNodeGroups(c *Cluster) error {
+	names := make(map[string]bool)
+	for i := range c.NodeGroups {
+		g := &c.NodeGroups[i]
+		fldPath := field.NewPath("node_groups").Index(i)
+
+		if len(g.Name) == 0 {
+			return field.Required(fldPath.Child("name"), "name is empty")
+		}
+		if names[g.Name] {
+			return field.Duplicate(fldPath.Child("name"), g.Name)
+		}
+		names[g.Name] = true
+
+		if len(g.Nodes) == 0 && g.NodeSelector == nil {
+			return fmt.Errorf("node group %s: either nodes or node_selector must be specified", g.Name)
+		}
+		for j, a := range g.Nodes {
+			if net.ParseIP(a) == nil {
+				return field.Invalid(fldPath.Child("nodes").Index(j), a, "invalid IP address")
+			}
+		}
+		if g.NodeSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(g.NodeSelector); err != nil {
+				return fmt.Errorf("node group %s: %w", g.Name, err)
+			}
+		}
+
+		if g.Kubelet != nil {
+			if err := validateKubeletOverride(c, g.Kubelet, fldPath.Child("kubelet")); err != nil {
+				return fmt.Errorf("node group %s: %w", g.Name, err)
+			}
+		}
+		if g.Proxy != nil {
+			if err := validateProxyOverride(c, g.Proxy); err != nil {
+				return fmt.Errorf("node group %s: %w", g.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateKubeletOverride(c *Cluster, o *KubeletOverride, fldPath *field.Path) error {
+	if err := validateMounts(o.ExtraBinds); err != nil {
+		return err
+	}
+	for i, taint := range o.BootTaints {
+		if err := validateTaint(taint, fldPath.Child("boot_taints").Index(i)); err != nil {
+			return err
+		}
+	}
+
+	base, err := c.Options.Kubelet.MergeConfig(&kubeletv1beta1.KubeletConfiguration{})
+	if err != nil {
+		return err
+	}
+	p := c.Options.Kubelet
+	p.Config = mergeUnstructured(p.Config, o.Config)
+	merged, err := p.MergeConfig(&kubeletv1beta1.KubeletConfiguration{})
+	if err != nil {
+		return err
+	}
+	if merged.ClusterDomain != base.ClusterDomain {
+		return errors.New("clusterDomain cannot be overridden")
+	}
+	return nil
+}
+
+func validateProxyOverride(c *Cluster, o *ProxyOverride) error {
+	if err := validateMounts(o.ExtraBinds); err != nil {
+		return err
+	}
+
+	p := c.Options.Proxy
+	p.Config = mergeUnstructured(p.Config, o.Config)
+	merged, err := p.MergeConfig(&proxyv1alpha1.KubeProxyConfiguration{})
+	if err != nil {
+		return err
+	}
+	if len(merged.Mode) != 0 {
+		return ValidateProxyMode(merged.Mode)
+	}
+	return nil
+}
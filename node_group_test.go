@@ -0,0 +1,227 @@
+package cke
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	proxyv1alpha1 "k8s.io/kube-proxy/config/v1alpha1"
+	kubeletv1beta1 "k8s.io/kubelet/config/v1beta1"
+)
+
+func kubeletConfig(fields map[string]any) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: fields}
+	u.SetGroupVersionKind(kubeletv1beta1.SchemeGroupVersion.WithKind("KubeletConfiguration"))
+	return u
+}
+
+func proxyConfig(fields map[string]any) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: fields}
+	u.SetGroupVersionKind(proxyv1alpha1.SchemeGroupVersion.WithKind("KubeProxyConfiguration"))
+	return u
+}
+
+func newNodeGroupCluster() *Cluster {
+	return &Cluster{
+		Name:          "test",
+		ServiceSubnet: "10.0.0.0/14",
+		Nodes: []*Node{
+			{Address: "10.0.0.1", User: "cybozu"},
+			{Address: "10.0.0.2", User: "cybozu", Labels: map[string]string{"role": "storage"}},
+			{Address: "10.0.0.3", User: "cybozu", Labels: map[string]string{"role": "storage"}},
+		},
+		Options: Options{
+			Kubelet: KubeletParams{
+				ServiceParams: ServiceParams{
+					ExtraArguments: []string{"--v=2"},
+					ExtraEnvvar:    map[string]string{"A": "a", "B": "b"},
+				},
+				BootTaints:  []corev1.Taint{{Key: "boot", Effect: corev1.TaintEffectNoSchedule}},
+				CRIEndpoint: "/var/run/k8s-containerd.sock",
+				Config: kubeletConfig(map[string]any{
+					"clusterDomain": "cluster.local",
+					"evictionHard":  map[string]any{"memory.available": "100Mi", "nodefs.available": "10%"},
+				}),
+			},
+			Proxy: ProxyParams{
+				Config: proxyConfig(map[string]any{"mode": "ipvs"}),
+			},
+		},
+		NodeGroups: []NodeGroup{
+			{
+				Name:         "storage",
+				NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "storage"}},
+				Kubelet: &KubeletOverride{
+					ServiceParams: ServiceParams{
+						ExtraArguments: []string{"--v=4"},
+						ExtraEnvvar:    map[string]string{"B": "storage"},
+					},
+					Config: kubeletConfig(map[string]any{
+						"evictionHard": map[string]any{"memory.available": "1Gi"},
+					}),
+				},
+			},
+			{
+				Name:  "edge",
+				Nodes: []string{"10.0.0.3"},
+				Kubelet: &KubeletOverride{
+					BootTaints: []corev1.Taint{},
+				},
+				Proxy: &ProxyOverride{
+					Config: proxyConfig(map[string]any{"mode": "iptables"}),
+				},
+			},
+		},
+	}
+}
+
+func TestNodeGroupParams(t *testing.T) {
+	c := newNodeGroupCluster()
+
+	if groups := c.NodeGroupsFor(c.Nodes[0]); len(groups) != 0 {
+		t.Error("node 0 should not belong to any groups", groups)
+	}
+	if groups := c.NodeGroupsFor(c.Nodes[2]); !cmp.Equal(groups, []string{"storage", "edge"}) {
+		t.Error("node 2 should belong to storage and edge", groups)
+	}
+
+	p := c.KubeletParamsFor(c.Nodes[0])
+	if !cmp.Equal(p, c.Options.Kubelet) {
+		t.Error("params for nodes not in groups should be the same as options", cmp.Diff(p, c.Options.Kubelet))
+	}
+
+	p = c.KubeletParamsFor(c.Nodes[1])
+	if !cmp.Equal(p.ExtraArguments, []string{"--v=2", "--v=4"}) {
+		t.Error("extra_args should be appended", p.ExtraArguments)
+	}
+	if !cmp.Equal(p.ExtraEnvvar, map[string]string{"A": "a", "B": "storage"}) {
+		t.Error("extra_env should be merged", p.ExtraEnvvar)
+	}
+	if len(p.BootTaints) != 1 {
+		t.Error("boot_taints should be inherited", p.BootTaints)
+	}
+	cfg, err := p.MergeConfig(&kubeletv1beta1.KubeletConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedEviction := map[string]string{"memory.available": "1Gi", "nodefs.available": "10%"}
+	if cfg.ClusterDomain != "cluster.local" || !cmp.Equal(cfg.EvictionHard, expectedEviction) {
+		t.Error("config should be merged recursively", cfg.ClusterDomain, cfg.EvictionHard)
+	}
+
+	p = c.KubeletParamsFor(c.Nodes[2])
+	if p.BootTaints == nil || len(p.BootTaints) != 0 {
+		t.Error("boot_taints should be replaced", p.BootTaints)
+	}
+
+	// the cluster-wide options must not be modified.
+	if len(c.Options.Kubelet.ExtraArguments) != 1 || c.Options.Kubelet.ExtraEnvvar["B"] != "b" {
+		t.Error("options are modified", c.Options.Kubelet.ServiceParams)
+	}
+	eviction := c.Options.Kubelet.Config.Object["evictionHard"].(map[string]any)
+	if eviction["memory.available"] != "100Mi" {
+		t.Error("options config is modified", eviction)
+	}
+
+	for i, expected := range []proxyv1alpha1.ProxyMode{"ipvs", "ipvs", "iptables"} {
+		pc, err := c.ProxyParamsFor(c.Nodes[i]).MergeConfig(&proxyv1alpha1.KubeProxyConfiguration{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pc.Mode != expected {
+			t.Errorf("unexpected proxy mode for node %d: %s", i, pc.Mode)
+		}
+	}
+}
+
+func TestValidateNodeGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Cluster)
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			modify: func(c *Cluster) {},
+		},
+		{
+			name:    "empty name",
+			modify:  func(c *Cluster) { c.NodeGroups[0].Name = "" },
+			wantErr: true,
+		},
+		{
+			name:    "duplicate name",
+			modify:  func(c *Cluster) { c.NodeGroups[1].Name = "storage" },
+			wantErr: true,
+		},
+		{
+			name: "no selection",
+			modify: func(c *Cluster) {
+				c.NodeGroups[1].Nodes = nil
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid address",
+			modify: func(c *Cluster) {
+				c.NodeGroups[1].Nodes = []string{"node3"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid selector",
+			modify: func(c *Cluster) {
+				c.NodeGroups[0].NodeSelector.MatchLabels = map[string]string{"in valid": "x"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "relative bind",
+			modify: func(c *Cluster) {
+				c.NodeGroups[0].Kubelet.ExtraBinds = []Mount{{Source: "data", Destination: "/data"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid boot taint",
+			modify: func(c *Cluster) {
+				c.NodeGroups[1].Kubelet.BootTaints = []corev1.Taint{{Key: "a_b/c", Effect: corev1.TaintEffectNoSchedule}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "override cluster domain",
+			modify: func(c *Cluster) {
+				c.NodeGroups[0].Kubelet.Config.Object["clusterDomain"] = "other.local"
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong kind",
+			modify: func(c *Cluster) {
+				c.NodeGroups[0].Kubelet.Config.SetKind("KubeProxyConfiguration")
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid proxy mode",
+			modify: func(c *Cluster) {
+				c.NodeGroups[1].Proxy.Config.Object["mode"] = "bogus"
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newNodeGroupCluster()
+			tt.modify(c)
+			err := c.Validate(false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package cke
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// EventType is the type of events sent by the notifier.
+type EventType string
+
+// Event types.
+const (
+	// EventRecord is sent when an operation is completed, cancelled or rejected.
+	EventRecord = EventType("record")
+	// EventRebootQueue is sent when a reboot queue entry changes its status or backs off draining.
+	EventRebootQueue = EventType("reboot-queue")
+	// EventRepairQueue is sent when a repair queue entry changes its status or step.
+	EventRepairQueue = EventType("repair-queue")
+	// EventPhase is sent when the operation phase changes.
+	EventPhase = EventType("phase")
+	// EventLeader is sent when a CKE server becomes the leader.
+	EventLeader = EventType("leader")
+)
+
+// AllEventTypes contains all kinds of EventTypes.
+var AllEventTypes = []EventType{
+	EventRecord,
+	EventRebootQueue,
+	EventRepairQueue,
+	EventPhase,
+	EventLeader,
+}
+
+// Webhook formats.
+const (
+	WebhookFormatJSON  = "json"
+	WebhookFormatSlack = "slack"
+)
+
+// Default values for Webhook.
+const (
+	DefaultWebhookTimeoutSeconds = 10
+	DefaultWebhookMaxRetries     = 3
+	DefaultWebhookBackoffSeconds = 1
+)
+
+// Webhook is the configuration of an HTTP endpoint to send events.
+type Webhook struct {
+	Name   string      `json:"name"`
+	URL    string      `json:"url"`
+	Format string      `json:"format,omitempty"`
+	Events []EventType `json:"events,omitempty"`
+	// TimeoutSeconds is the timeout of each request.
+	TimeoutSeconds *int `json:"timeout_seconds,omitempty"`
+	// MaxRetries is the number of retries after a failed request.
+	MaxRetries *int `json:"max_retries,omitempty"`
+	// BackoffSeconds is the initial interval of retries.  The interval doubles on each retry.
+	BackoffSeconds *int `json:"backoff_seconds,omitempty"`
+}
+
+// Accepts returns true if the webhook should receive events of t.
+// If no event types are specified, the webhook receives all events.
+func (w *Webhook) Accepts(t EventType) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, t)
+}
+
+// NotifierConfig is the configuration of the notifier.
+type NotifierConfig struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// Validate validates the notifier configuration.
+func (c *NotifierConfig) Validate() error {
+	names := make(map[string]bool)
+	for _, w := range c.Webhooks {
+		if w.Name == "" {
+			return errors.New("webhook name is empty")
+		}
+		if names[w.Name] {
+			return fmt.Errorf("duplicate webhook name: %s", w.Name)
+		}
+		names[w.Name] = true
+
+		u, err := url.Parse(w.URL)
+		if err != nil {
+			return fmt.Errorf("webhook %s: %w", w.Name, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("webhook %s: invalid URL: %s", w.Name, w.URL)
+		}
+
+		switch w.Format {
+		case "", WebhookFormatJSON, WebhookFormatSlack:
+		default:
+			return fmt.Errorf("webhook %s: unknown format: %s", w.Name, w.Format)
+		}
+		for _, t := range w.Events {
+			if !slices.Contains(AllEventTypes, t) {
+				return fmt.Errorf("webhook %s: unknown event type: %s", w.Name, t)
+			}
+		}
+		if w.TimeoutSeconds != nil && *w.TimeoutSeconds <= 0 {
+			return fmt.Errorf("webhook %s: timeout_seconds must be positive", w.Name)
+		}
+		if w.MaxRetries != nil && *w.MaxRetries < 0 {
+			return fmt.Errorf("webhook %s: max_retries must not be negative", w.Name)
+		}
+		if w.BackoffSeconds != nil && *w.BackoffSeconds < 0 {
+			return fmt.Errorf("webhook %s: backoff_seconds must not be negative", w.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,2 @@
+// Package notifier sends events of CKE to HTTP webhooks.
+package notifier
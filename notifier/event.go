@@ -0,0 +1,187 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cybozu-go/cke"
+)
+
+// Event is a notification sent to webhooks.
+type Event struct {
+	Type      cke.EventType `json:"type"`
+	Timestamp time.Time     `json:"timestamp"`
+	Summary   string        `json:"summary"`
+	Data      any           `json:"data"`
+}
+
+func leaderEvent(hostname string, now time.Time) *Event {
+	return &Event{
+		Type:      cke.EventLeader,
+		Timestamp: now,
+		Summary:   fmt.Sprintf("%s became the leader", hostname),
+		Data: map[string]string{
+			"hostname": hostname,
+		},
+	}
+}
+
+// eventFromChange returns an event for a change of the etcd key.
+// prev is nil if the key was created.  value is nil if the key was deleted.
+// This returns nil if the change is not worth notifying.
+func eventFromChange(key string, prev, value []byte, now time.Time) (*Event, error) {
+	switch {
+	case strings.HasPrefix(key, cke.KeyRecords):
+		if value == nil {
+			return nil, nil
+		}
+		return recordEvent(prev, value, now)
+	case strings.HasPrefix(key, cke.KeyRebootsPrefix):
+		return rebootEvent(prev, value, now)
+	case strings.HasPrefix(key, cke.KeyRepairsPrefix):
+		return repairEvent(prev, value, now)
+	case key == cke.KeyStatus:
+		if value == nil {
+			return nil, nil
+		}
+		return phaseEvent(prev, value, now)
+	}
+	return nil, nil
+}
+
+func recordEvent(prev, value []byte, now time.Time) (*Event, error) {
+	r := new(cke.Record)
+	if err := json.Unmarshal(value, r); err != nil {
+		return nil, err
+	}
+	switch r.Status {
+	case cke.StatusCompleted, cke.StatusCancelled, cke.StatusRejected:
+	default:
+		return nil, nil
+	}
+	if prev != nil {
+		p := new(cke.Record)
+		if err := json.Unmarshal(prev, p); err != nil {
+			return nil, err
+		}
+		if p.Status == r.Status {
+			return nil, nil
+		}
+	}
+
+	summary := fmt.Sprintf("operation %s (id=%d) %s", r.Operation, r.ID, r.Status)
+	if r.Error != "" {
+		summary += ": " + r.Error
+	}
+	return &Event{
+		Type:      cke.EventRecord,
+		Timestamp: now,
+		Summary:   summary,
+		Data:      r,
+	}, nil
+}
+
+func rebootEvent(prev, value []byte, now time.Time) (*Event, error) {
+	var p, e *cke.RebootQueueEntry
+	if prev != nil {
+		p = new(cke.RebootQueueEntry)
+		if err := json.Unmarshal(prev, p); err != nil {
+			return nil, err
+		}
+	}
+	if value != nil {
+		e = new(cke.RebootQueueEntry)
+		if err := json.Unmarshal(value, e); err != nil {
+			return nil, err
+		}
+	}
+
+	var summary string
+	switch {
+	case e == nil && p == nil:
+		return nil, nil
+	case e == nil:
+		summary = fmt.Sprintf("reboot queue entry %d for %s was removed", p.Index, p.Node)
+		e = p
+	case p == nil:
+		summary = fmt.Sprintf("reboot queue entry %d for %s was added", e.Index, e.Node)
+	case p.Status != e.Status:
+		summary = fmt.Sprintf("reboot queue entry %d for %s: %s -> %s", e.Index, e.Node, p.Status, e.Status)
+	case e.DrainBackOffCount > p.DrainBackOffCount:
+		summary = fmt.Sprintf("reboot queue entry %d for %s: drain backed off %d time(s) until %s", e.Index, e.Node, e.DrainBackOffCount, e.DrainBackOffExpire.Format(time.RFC3339))
+	default:
+		return nil, nil
+	}
+	return &Event{
+		Type:      cke.EventRebootQueue,
+		Timestamp: now,
+		Summary:   summary,
+		Data:      e,
+	}, nil
+}
+
+func repairEvent(prev, value []byte, now time.Time) (*Event, error) {
+	var p, e *cke.RepairQueueEntry
+	if prev != nil {
+		p = new(cke.RepairQueueEntry)
+		if err := json.Unmarshal(prev, p); err != nil {
+			return nil, err
+		}
+	}
+	if value != nil {
+		e = new(cke.RepairQueueEntry)
+		if err := json.Unmarshal(value, e); err != nil {
+			return nil, err
+		}
+	}
+
+	var summary string
+	switch {
+	case e == nil && p == nil:
+		return nil, nil
+	case e == nil:
+		summary = fmt.Sprintf("repair queue entry %d for %s was removed", p.Index, p.Address)
+		e = p
+	case p == nil:
+		summary = fmt.Sprintf("repair queue entry %d for %s was added: %s", e.Index, e.Address, e.Operation)
+	case p.Status != e.Status:
+		summary = fmt.Sprintf("repair queue entry %d for %s: %s -> %s", e.Index, e.Address, p.Status, e.Status)
+	case p.Step != e.Step || p.StepStatus != e.StepStatus:
+		summary = fmt.Sprintf("repair queue entry %d for %s: step %d %s", e.Index, e.Address, e.Step, e.StepStatus)
+	case e.DrainBackOffCount > p.DrainBackOffCount:
+		summary = fmt.Sprintf("repair queue entry %d for %s: drain backed off %d time(s) until %s", e.Index, e.Address, e.DrainBackOffCount, e.DrainBackOffExpire.Format(time.RFC3339))
+	default:
+		return nil, nil
+	}
+	return &Event{
+		Type:      cke.EventRepairQueue,
+		Timestamp: now,
+		Summary:   summary,
+		Data:      e,
+	}, nil
+}
+
+func phaseEvent(prev, value []byte, now time.Time) (*Event, error) {
+	st := new(cke.ServerStatus)
+	if err := json.Unmarshal(value, st); err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		p := new(cke.ServerStatus)
+		if err := json.Unmarshal(prev, p); err != nil {
+			return nil, err
+		}
+		if p.Phase == st.Phase {
+			return nil, nil
+		}
+	}
+
+	return &Event{
+		Type:      cke.EventPhase,
+		Timestamp: now,
+		Summary:   fmt.Sprintf("operation phase changed to %s", st.Phase),
+		Data:      st,
+	}, nil
+}
@@ -0,0 +1,182 @@
+package notifier
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/cke"
+)
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestEventFromChange(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	running := &cke.Record{ID: 1, Operation: "etcd-add-member", Status: cke.StatusRunning}
+	cancelled := &cke.Record{ID: 1, Operation: "etcd-add-member", Status: cke.StatusCancelled, Error: "timeout"}
+	queued := &cke.RebootQueueEntry{Index: 1, Node: "10.0.0.11", Status: cke.RebootStatusQueued}
+	draining := &cke.RebootQueueEntry{Index: 1, Node: "10.0.0.11", Status: cke.RebootStatusDraining}
+	backedOff := &cke.RebootQueueEntry{Index: 1, Node: "10.0.0.11", Status: cke.RebootStatusQueued, DrainBackOffCount: 1, DrainBackOffExpire: now}
+	repairQueued := &cke.RepairQueueEntry{Index: 2, Address: "10.0.0.12", Operation: "unreachable", Status: cke.RepairStatusQueued}
+	repairFailed := &cke.RepairQueueEntry{Index: 2, Address: "10.0.0.12", Operation: "unreachable", Status: cke.RepairStatusFailed}
+
+	testCases := []struct {
+		name    string
+		key     string
+		prev    []byte
+		value   []byte
+		typ     cke.EventType
+		summary string
+	}{
+		{
+			name:  "record running",
+			key:   "records/0000000000000001",
+			value: mustJSON(t, running),
+		},
+		{
+			name:    "record cancelled",
+			key:     "records/0000000000000001",
+			prev:    mustJSON(t, running),
+			value:   mustJSON(t, cancelled),
+			typ:     cke.EventRecord,
+			summary: "operation etcd-add-member (id=1) cancelled: timeout",
+		},
+		{
+			name:  "record unchanged",
+			key:   "records/0000000000000001",
+			prev:  mustJSON(t, cancelled),
+			value: mustJSON(t, cancelled),
+		},
+		{
+			name:  "record deleted",
+			key:   "records/0000000000000001",
+			prev:  mustJSON(t, cancelled),
+			value: nil,
+		},
+		{
+			name:    "reboot added",
+			key:     "reboots/data/0000000000000001",
+			value:   mustJSON(t, queued),
+			typ:     cke.EventRebootQueue,
+			summary: "reboot queue entry 1 for 10.0.0.11 was added",
+		},
+		{
+			name:    "reboot transition",
+			key:     "reboots/data/0000000000000001",
+			prev:    mustJSON(t, queued),
+			value:   mustJSON(t, draining),
+			typ:     cke.EventRebootQueue,
+			summary: "reboot queue entry 1 for 10.0.0.11: queued -> draining",
+		},
+		{
+			name:    "reboot drain back-off",
+			key:     "reboots/data/0000000000000001",
+			prev:    mustJSON(t, queued),
+			value:   mustJSON(t, backedOff),
+			typ:     cke.EventRebootQueue,
+			summary: "reboot queue entry 1 for 10.0.0.11: drain backed off 1 time(s) until 2024-01-01T00:00:00Z",
+		},
+		{
+			name:    "reboot removed",
+			key:     "reboots/data/0000000000000001",
+			prev:    mustJSON(t, draining),
+			typ:     cke.EventRebootQueue,
+			summary: "reboot queue entry 1 for 10.0.0.11 was removed",
+		},
+		{
+			name:    "repair failed",
+			key:     "repairs/data/0000000000000002",
+			prev:    mustJSON(t, repairQueued),
+			value:   mustJSON(t, repairFailed),
+			typ:     cke.EventRepairQueue,
+			summary: "repair queue entry 2 for 10.0.0.12: queued -> failed",
+		},
+		{
+			name:    "phase changed",
+			key:     cke.KeyStatus,
+			prev:    mustJSON(t, &cke.ServerStatus{Phase: cke.PhaseCompleted}),
+			value:   mustJSON(t, &cke.ServerStatus{Phase: cke.PhaseRebootNodes}),
+			typ:     cke.EventPhase,
+			summary: "operation phase changed to reboot-nodes",
+		},
+		{
+			name:  "phase unchanged",
+			key:   cke.KeyStatus,
+			prev:  mustJSON(t, &cke.ServerStatus{Phase: cke.PhaseCompleted}),
+			value: mustJSON(t, &cke.ServerStatus{Phase: cke.PhaseCompleted, Timestamp: now}),
+		},
+		{
+			name:  "unrelated key",
+			key:   cke.KeyCluster,
+			value: []byte("{}"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := eventFromChange(tt.key, tt.prev, tt.value, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.typ == "" {
+				if e != nil {
+					t.Error("unexpected event:", e)
+				}
+				return
+			}
+			if e == nil {
+				t.Fatal("event is not returned")
+			}
+			if e.Type != tt.typ {
+				t.Error("unexpected type:", e.Type)
+			}
+			if e.Summary != tt.summary {
+				t.Error("unexpected summary:", e.Summary)
+			}
+			if !e.Timestamp.Equal(now) {
+				t.Error("unexpected timestamp:", e.Timestamp)
+			}
+		})
+	}
+}
+
+func TestWatchTargets(t *testing.T) {
+	watched := func(key string) bool {
+		for _, target := range watchTargets {
+			if key == target.key || (target.prefix && strings.HasPrefix(key, target.key)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, key := range []string{
+		cke.KeyNotifierConfig,
+		cke.KeyStatus,
+		cke.KeyRecords + "0000000000000001",
+		cke.KeyRebootsPrefix + "0000000000000001",
+		cke.KeyRepairsPrefix + "0000000000000001",
+	} {
+		if !watched(key) {
+			t.Error("key is not watched:", key)
+		}
+	}
+	for _, key := range []string{
+		cke.KeyLeader + "694d8a4b0e2f1234",
+		cke.KeyCluster,
+		cke.KeyRecordID,
+	} {
+		if watched(key) {
+			t.Error("key should not be watched:", key)
+		}
+	}
+}
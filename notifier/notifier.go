@@ -0,0 +1,220 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cybozu-go/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/cybozu-go/cke"
+)
+
+// Notifier watches changes in etcd and sends events to the configured webhooks.
+// It should run only on the leader to avoid duplicated notifications.
+type Notifier struct {
+	etcd       *clientv3.Client
+	httpClient *http.Client
+
+	wg      sync.WaitGroup
+	cancel  context.CancelFunc
+	senders []*webhookSender
+}
+
+// New creates a Notifier.
+func New(etcd *clientv3.Client) *Notifier {
+	return &Notifier{
+		etcd:       etcd,
+		httpClient: &http.Client{},
+	}
+}
+
+// watchRetryInterval is the interval to restart watching after an error.
+const watchRetryInterval = 5 * time.Second
+
+// Run watches etcd until ctx is canceled.
+// Errors of etcd are logged and watching is restarted from the current revision,
+// so that failures of notification do not affect the leader.
+func (n *Notifier) Run(ctx context.Context) error {
+	defer n.wg.Wait()
+	defer n.stopSenders()
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+
+	notified := false
+	for {
+		rev, err := n.loadConfig(ctx)
+		if err == nil {
+			if !notified {
+				n.notify(leaderEvent(hostname, time.Now().UTC()))
+				notified = true
+			}
+			err = n.watchChanges(ctx, rev)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Error("notifier: failed to watch etcd; some events may be lost", map[string]any{
+			log.FnError: err,
+		})
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+// loadConfig loads and applies the configuration, and returns the revision of etcd.
+func (n *Notifier) loadConfig(ctx context.Context) (int64, error) {
+	resp, err := n.etcd.Get(ctx, cke.KeyNotifierConfig)
+	if err != nil {
+		return 0, err
+	}
+	var value []byte
+	if len(resp.Kvs) == 1 {
+		value = resp.Kvs[0].Value
+	}
+	n.configure(ctx, value)
+	return resp.Header.Revision, nil
+}
+
+// watchChanges sends events for the changes after rev until an error occurs.
+func (n *Notifier) watchChanges(ctx context.Context, rev int64) error {
+	wch, stop := n.watch(ctx, rev+1)
+	defer stop()
+	for resp := range wch {
+		if err := resp.Err(); err != nil {
+			return err
+		}
+
+		for _, ev := range resp.Events {
+			key := string(ev.Kv.Key)
+			var prev, value []byte
+			if ev.PrevKv != nil {
+				prev = ev.PrevKv.Value
+			}
+			if ev.Type == clientv3.EventTypePut {
+				value = ev.Kv.Value
+			}
+
+			if key == cke.KeyNotifierConfig {
+				n.configure(ctx, value)
+				continue
+			}
+
+			e, err := eventFromChange(key, prev, value, time.Now().UTC())
+			if err != nil {
+				log.Warn("notifier: failed to parse a change", map[string]any{
+					log.FnError: err,
+					"key":       key,
+				})
+				continue
+			}
+			if e != nil {
+				n.notify(e)
+			}
+		}
+	}
+	return errors.New("watch channel is closed")
+}
+
+// watchTargets are the keys watched by Notifier.
+var watchTargets = []struct {
+	key    string
+	prefix bool
+}{
+	{cke.KeyNotifierConfig, false},
+	{cke.KeyStatus, false},
+	{cke.KeyRecords, true},
+	{cke.KeyRebootsPrefix, true},
+	{cke.KeyRepairsPrefix, true},
+}
+
+// watch watches watchTargets from rev and merges the responses into a channel.
+// The channel is closed when ctx is canceled or stop is called.
+func (n *Notifier) watch(ctx context.Context, rev int64) (ch <-chan clientv3.WatchResponse, stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	merged := make(chan clientv3.WatchResponse)
+
+	var wg sync.WaitGroup
+	for _, t := range watchTargets {
+		opts := []clientv3.OpOption{clientv3.WithRev(rev), clientv3.WithPrevKV()}
+		if t.prefix {
+			opts = append(opts, clientv3.WithPrefix())
+		}
+		wch := n.etcd.Watch(ctx, t.key, opts...)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for resp := range wch {
+				select {
+				case merged <- resp:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged, cancel
+}
+
+// configure replaces the webhook senders.  Pending events for the old ones are dropped.
+func (n *Notifier) configure(ctx context.Context, value []byte) {
+	n.stopSenders()
+
+	cfg := new(cke.NotifierConfig)
+	if value != nil {
+		err := json.Unmarshal(value, cfg)
+		if err == nil {
+			err = cfg.Validate()
+		}
+		if err != nil {
+			log.Error("notifier: invalid configuration", map[string]any{
+				log.FnError: err,
+			})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	n.cancel = cancel
+	n.senders = make([]*webhookSender, len(cfg.Webhooks))
+	for i, hook := range cfg.Webhooks {
+		s := newWebhookSender(hook, n.httpClient)
+		n.senders[i] = s
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			s.run(ctx)
+		}()
+	}
+}
+
+func (n *Notifier) stopSenders() {
+	if n.cancel != nil {
+		n.cancel()
+		n.cancel = nil
+	}
+	n.senders = nil
+}
+
+func (n *Notifier) notify(e *Event) {
+	for _, s := range n.senders {
+		s.enqueue(e)
+	}
+}
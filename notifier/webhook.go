@@ -0,0 +1,152 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cybozu-go/log"
+
+	"github.com/cybozu-go/cke"
+)
+
+const (
+	queueSize  = 100
+	maxBackoff = time.Minute
+)
+
+type webhookSender struct {
+	hook   cke.Webhook
+	client *http.Client
+	queue  chan *Event
+}
+
+func newWebhookSender(hook cke.Webhook, client *http.Client) *webhookSender {
+	return &webhookSender{
+		hook:   hook,
+		client: client,
+		queue:  make(chan *Event, queueSize),
+	}
+}
+
+// enqueue queues ev without blocking.  If the queue is full, ev is dropped.
+func (s *webhookSender) enqueue(ev *Event) {
+	if !s.hook.Accepts(ev.Type) {
+		return
+	}
+	select {
+	case s.queue <- ev:
+	default:
+		log.Warn("notifier: queue is full, event dropped", map[string]any{
+			"webhook": s.hook.Name,
+			"type":    ev.Type,
+			"summary": ev.Summary,
+		})
+	}
+}
+
+func (s *webhookSender) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-s.queue:
+			err := s.send(ctx, ev)
+			if err != nil {
+				log.Error("notifier: failed to send event", map[string]any{
+					log.FnError: err,
+					"webhook":   s.hook.Name,
+					"type":      ev.Type,
+					"summary":   ev.Summary,
+				})
+			}
+		}
+	}
+}
+
+// send posts ev to the webhook.  Failed requests are retried with exponential back-off.
+func (s *webhookSender) send(ctx context.Context, ev *Event) error {
+	body, err := payload(s.hook.Format, ev)
+	if err != nil {
+		return err
+	}
+
+	retries := cke.DefaultWebhookMaxRetries
+	if s.hook.MaxRetries != nil {
+		retries = *s.hook.MaxRetries
+	}
+	backoff := time.Duration(cke.DefaultWebhookBackoffSeconds) * time.Second
+	if s.hook.BackoffSeconds != nil {
+		backoff = time.Duration(*s.hook.BackoffSeconds) * time.Second
+	}
+
+	for i := 0; ; i++ {
+		retryable, err := s.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || i >= retries {
+			return err
+		}
+
+		log.Warn("notifier: retrying", map[string]any{
+			log.FnError: err,
+			"webhook":   s.hook.Name,
+			"attempts":  i + 1,
+		})
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (s *webhookSender) post(ctx context.Context, body []byte) (retryable bool, err error) {
+	timeout := time.Duration(cke.DefaultWebhookTimeoutSeconds) * time.Second
+	if s.hook.TimeoutSeconds != nil {
+		timeout = time.Duration(*s.hook.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned %s", resp.Status)
+	default:
+		return false, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+func payload(format string, ev *Event) ([]byte, error) {
+	if format == cke.WebhookFormatSlack {
+		return json.Marshal(slackMessage{
+			Text: fmt.Sprintf("[CKE] %s: %s", ev.Type, ev.Summary),
+		})
+	}
+	return json.Marshal(ev)
+}
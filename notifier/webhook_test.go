@@ -0,0 +1,176 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/cke"
+)
+
+type webhookStub struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	bodies   [][]byte
+}
+
+func (s *webhookStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, body)
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(s.status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *webhookStub) requests() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bodies
+}
+
+func testEvent() *Event {
+	return &Event{
+		Type:      cke.EventRecord,
+		Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Summary:   "operation foo (id=1) cancelled",
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestWebhookSend(t *testing.T) {
+	testCases := []struct {
+		name      string
+		failures  int
+		status    int
+		retries   int
+		requests  int
+		succeeded bool
+	}{
+		{name: "success", requests: 1, succeeded: true},
+		{name: "retry", failures: 2, status: http.StatusServiceUnavailable, retries: 3, requests: 3, succeeded: true},
+		{name: "too many requests", failures: 1, status: http.StatusTooManyRequests, retries: 1, requests: 2, succeeded: true},
+		{name: "give up", failures: 5, status: http.StatusInternalServerError, retries: 2, requests: 3},
+		{name: "no retry for client error", failures: 1, status: http.StatusBadRequest, retries: 3, requests: 1},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &webhookStub{failures: tt.failures, status: tt.status}
+			ts := httptest.NewServer(stub)
+			defer ts.Close()
+
+			s := newWebhookSender(cke.Webhook{
+				Name:           "test",
+				URL:            ts.URL,
+				MaxRetries:     intPtr(tt.retries),
+				BackoffSeconds: intPtr(0),
+			}, ts.Client())
+
+			err := s.send(context.Background(), testEvent())
+			if tt.succeeded && err != nil {
+				t.Error(err)
+			}
+			if !tt.succeeded && err == nil {
+				t.Error("send should fail")
+			}
+			if n := len(stub.requests()); n != tt.requests {
+				t.Error("unexpected number of requests:", n)
+			}
+		})
+	}
+}
+
+func TestWebhookFormat(t *testing.T) {
+	stub := &webhookStub{}
+	ts := httptest.NewServer(stub)
+	defer ts.Close()
+
+	ev := testEvent()
+	for _, format := range []string{"", cke.WebhookFormatSlack} {
+		s := newWebhookSender(cke.Webhook{Name: "test", URL: ts.URL, Format: format}, ts.Client())
+		if err := s.send(context.Background(), ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reqs := stub.requests()
+	if len(reqs) != 2 {
+		t.Fatal("unexpected number of requests:", len(reqs))
+	}
+
+	got := new(Event)
+	if err := json.Unmarshal(reqs[0], got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != ev.Type || got.Summary != ev.Summary || !got.Timestamp.Equal(ev.Timestamp) {
+		t.Error("unexpected event:", got)
+	}
+
+	msg := new(slackMessage)
+	if err := json.Unmarshal(reqs[1], msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Text != "[CKE] record: operation foo (id=1) cancelled" {
+		t.Error("unexpected slack message:", msg.Text)
+	}
+}
+
+func TestWebhookFilter(t *testing.T) {
+	stub := &webhookStub{}
+	ts := httptest.NewServer(stub)
+	defer ts.Close()
+
+	s := newWebhookSender(cke.Webhook{
+		Name:   "test",
+		URL:    ts.URL,
+		Events: []cke.EventType{cke.EventPhase},
+	}, ts.Client())
+
+	s.enqueue(testEvent())
+	s.enqueue(&Event{Type: cke.EventPhase, Summary: "operation phase changed to completed"})
+	if len(s.queue) != 1 {
+		t.Fatal("unexpected number of queued events:", len(s.queue))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(stub.requests()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("event was not sent")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	got := new(Event)
+	if err := json.Unmarshal(stub.requests()[0], got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != cke.EventPhase {
+		t.Error("unexpected event type:", got.Type)
+	}
+}
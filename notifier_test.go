@@ -0,0 +1,59 @@
+package cke
+
+import (
+	"testing"
+)
+
+func TestNotifierConfig(t *testing.T) {
+	negative := -1
+
+	testCases := []struct {
+		name  string
+		hooks []Webhook
+		valid bool
+	}{
+		{name: "empty", valid: true},
+		{
+			name: "valid",
+			hooks: []Webhook{
+				{Name: "ops", URL: "https://example.com/hook", Events: []EventType{EventRecord, EventPhase}},
+				{Name: "slack", URL: "http://localhost:8080/", Format: WebhookFormatSlack},
+			},
+			valid: true,
+		},
+		{name: "no name", hooks: []Webhook{{URL: "https://example.com/"}}},
+		{
+			name: "duplicate name",
+			hooks: []Webhook{
+				{Name: "a", URL: "https://example.com/"},
+				{Name: "a", URL: "https://example.org/"},
+			},
+		},
+		{name: "invalid URL", hooks: []Webhook{{Name: "a", URL: "example.com"}}},
+		{name: "unknown format", hooks: []Webhook{{Name: "a", URL: "https://example.com/", Format: "xml"}}},
+		{name: "unknown event", hooks: []Webhook{{Name: "a", URL: "https://example.com/", Events: []EventType{"foo"}}}},
+		{name: "negative retries", hooks: []Webhook{{Name: "a", URL: "https://example.com/", MaxRetries: &negative}}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &NotifierConfig{Webhooks: tt.hooks}
+			err := c.Validate()
+			if tt.valid && err != nil {
+				t.Error(err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("should be invalid")
+			}
+		})
+	}
+
+	w := &Webhook{}
+	if !w.Accepts(EventLeader) {
+		t.Error("webhook without events should accept all events")
+	}
+	w.Events = []EventType{EventRecord}
+	if w.Accepts(EventLeader) || !w.Accepts(EventRecord) {
+		t.Error("webhook should accept only the specified events")
+	}
+}
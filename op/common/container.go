@@ -21,6 +21,7 @@ type runContainerCommand struct {
 	extra     cke.ServiceParams
 
 	restart bool
+	*CommandOutput
 }
 
 // RunOption is a functional option for RunContainerCommand
@@ -28,7 +29,7 @@ type RunOption func(c *runContainerCommand)
 
 // RunContainerCommand returns a Commander to run or restart a system container.
 func RunContainerCommand(nodes []*cke.Node, name string, img cke.Image, opts ...RunOption) cke.Commander {
-	c := &runContainerCommand{nodes: nodes, name: name, img: img}
+	c := &runContainerCommand{nodes: nodes, name: name, img: img, CommandOutput: new(CommandOutput)}
 	for _, f := range opts {
 		f(c)
 	}
@@ -70,7 +71,7 @@ func (c runContainerCommand) Run(ctx context.Context, inf cke.Infrastructure, _
 	for _, n := range c.nodes {
 		n := n
 		ce := inf.Engine(n.Address)
-		env.Go(func(ctx context.Context) error {
+		env.Go(c.track(n.Address, func(ctx context.Context) error {
 			params, ok := c.paramsMap[n.Address]
 			if !ok {
 				params = c.params
@@ -96,7 +97,7 @@ func (c runContainerCommand) Run(ctx context.Context, inf cke.Infrastructure, _
 				}
 			}
 			return ce.RunSystem(c.name, c.img, opts, params, c.extra)
-		})
+		}))
 	}
 	env.Stop()
 	return env.Wait()
@@ -112,16 +113,17 @@ func (c runContainerCommand) Command() cke.Command {
 type stopContainerCommand struct {
 	nodes []*cke.Node
 	name  string
+	*CommandOutput
 }
 
 // StopContainersCommand returns a Commander to stop each container on nodes.
 func StopContainersCommand(nodes []*cke.Node, name string) cke.Commander {
-	return stopContainerCommand{nodes, name}
+	return stopContainerCommand{nodes, name, new(CommandOutput)}
 }
 
 // StopContainerCommand returns a Commander to stop a container on a node.
 func StopContainerCommand(node *cke.Node, name string) cke.Commander {
-	return stopContainerCommand{[]*cke.Node{node}, name}
+	return stopContainerCommand{[]*cke.Node{node}, name, new(CommandOutput)}
 }
 
 func (c stopContainerCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
@@ -129,7 +131,7 @@ func (c stopContainerCommand) Run(ctx context.Context, inf cke.Infrastructure, _
 	for _, n := range c.nodes {
 		n := n
 		ce := inf.Engine(n.Address)
-		env.Go(func(ctx context.Context) error {
+		env.Go(c.track(n.Address, func(ctx context.Context) error {
 			begin := time.Now()
 			exists, err := ce.Exists(c.name)
 			if err != nil {
@@ -155,7 +157,7 @@ func (c stopContainerCommand) Run(ctx context.Context, inf cke.Infrastructure, _
 				"elapsed":   time.Since(begin).Seconds(),
 			})
 			return err
-		})
+		}))
 	}
 	env.Stop()
 	return env.Wait()
@@ -171,11 +173,12 @@ func (c stopContainerCommand) Command() cke.Command {
 type killContainersCommand struct {
 	nodes []*cke.Node
 	name  string
+	*CommandOutput
 }
 
 // KillContainersCommand returns a Commander to kill a container on nodes.
 func KillContainersCommand(nodes []*cke.Node, name string) cke.Commander {
-	return killContainersCommand{nodes, name}
+	return killContainersCommand{nodes, name, new(CommandOutput)}
 }
 
 func (c killContainersCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
@@ -183,7 +186,7 @@ func (c killContainersCommand) Run(ctx context.Context, inf cke.Infrastructure,
 	env := well.NewEnvironment(ctx)
 	for _, n := range c.nodes {
 		ce := inf.Engine(n.Address)
-		env.Go(func(ctx context.Context) error {
+		env.Go(c.track(n.Address, func(ctx context.Context) error {
 			exists, err := ce.Exists(c.name)
 			if err != nil {
 				return err
@@ -202,7 +205,7 @@ func (c killContainersCommand) Run(ctx context.Context, inf cke.Infrastructure,
 				}
 			}
 			return ce.Remove(c.name)
-		})
+		}))
 	}
 	env.Stop()
 	err := env.Wait()
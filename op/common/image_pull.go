@@ -13,6 +13,7 @@ import (
 type imagePullCommand struct {
 	nodes []*cke.Node
 	img   cke.Image
+	*CommandOutput
 }
 
 const (
@@ -22,7 +23,7 @@ const (
 
 // ImagePullCommand returns a Commander to pull an image on nodes.
 func ImagePullCommand(nodes []*cke.Node, img cke.Image) cke.Commander {
-	return imagePullCommand{nodes, img}
+	return imagePullCommand{nodes, img, new(CommandOutput)}
 }
 
 func (c imagePullCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
@@ -41,6 +42,7 @@ func (c imagePullCommand) Run(ctx context.Context, inf cke.Infrastructure, _ str
 					"image":     c.img.Name(),
 					log.FnError: err,
 				})
+				c.addError(n.Address, err)
 				select {
 				case <-ctx.Done():
 					return ctx.Err()
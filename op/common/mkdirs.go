@@ -14,16 +14,17 @@ type makeDirsCommand struct {
 	nodes []*cke.Node
 	dirs  []string
 	mode  string
+	*CommandOutput
 }
 
 // MakeDirsCommand returns a Commander to make directories on nodes.
 func MakeDirsCommand(nodes []*cke.Node, dirs []string) cke.Commander {
-	return makeDirsCommand{nodes, dirs, "755"}
+	return makeDirsCommand{nodes, dirs, "755", new(CommandOutput)}
 }
 
 // MakeDirsCommandWithMode returns a Commander to make directories on nodes with given permission mode.
 func MakeDirsCommandWithMode(nodes []*cke.Node, dirs []string, mode string) cke.Commander {
-	return makeDirsCommand{nodes, dirs, mode}
+	return makeDirsCommand{nodes, dirs, mode, new(CommandOutput)}
 }
 
 func (c makeDirsCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
@@ -52,9 +53,9 @@ func (c makeDirsCommand) Run(ctx context.Context, inf cke.Infrastructure, _ stri
 	env := well.NewEnvironment(ctx)
 	for _, n := range c.nodes {
 		ce := inf.Engine(n.Address)
-		env.Go(func(ctx context.Context) error {
+		env.Go(c.track(n.Address, func(ctx context.Context) error {
 			return ce.Run(cke.ToolsImage, binds, "make_directories", args...)
-		})
+		}))
 	}
 	env.Stop()
 	return env.Wait()
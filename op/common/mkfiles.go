@@ -23,11 +23,12 @@ type fileData struct {
 type FilesBuilder struct {
 	nodes []*cke.Node
 	files []fileData
+	*CommandOutput
 }
 
 // NewFilesBuilder creates a new FilesBuilder.
 func NewFilesBuilder(nodes []*cke.Node) *FilesBuilder {
-	return &FilesBuilder{nodes: nodes}
+	return &FilesBuilder{nodes: nodes, CommandOutput: new(CommandOutput)}
 }
 
 // AddFile adds a file to the builder.
@@ -117,7 +118,7 @@ func (c *FilesBuilder) Run(ctx context.Context, inf cke.Infrastructure, _ string
 	env := well.NewEnvironment(ctx)
 	for _, n := range c.nodes {
 		n := n
-		env.Go(func(ctx context.Context) error {
+		env.Go(c.track(n.Address, func(ctx context.Context) error {
 			buf := new(bytes.Buffer)
 			tw := tar.NewWriter(buf)
 			for _, f := range c.files {
@@ -141,7 +142,7 @@ func (c *FilesBuilder) Run(ctx context.Context, inf cke.Infrastructure, _ string
 
 			ce := inf.Engine(n.Address)
 			return ce.RunWithInput(cke.ToolsImage, binds, "write_files", data, "/mnt")
-		})
+		}))
 	}
 	env.Stop()
 	return env.Wait()
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/cybozu-go/cke"
+)
+
+// CommandOutput collects stdout and stderr of commands run by a Commander.
+// Commanders embedding *CommandOutput implement cke.OutputCommander.
+// Each line is prefixed with the target of the command, typically the node address.
+type CommandOutput struct {
+	mu     sync.Mutex
+	stdout strings.Builder
+	stderr strings.Builder
+}
+
+// Add appends the output of a command run for target.
+func (o *CommandOutput) Add(target, stdout, stderr string) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	writeOutput(&o.stdout, target, stdout)
+	writeOutput(&o.stderr, target, stderr)
+}
+
+// addError appends the output of a failed command run for target.
+// Container engines return the output of failed commands as cke.OutputError.
+func (o *CommandOutput) addError(target string, err error) {
+	var oe cke.OutputError
+	if errors.As(err, &oe) {
+		o.Add(target, string(oe.Stdout), string(oe.Stderr))
+	}
+}
+
+// track returns a function that runs f and appends the output of its failed command.
+func (o *CommandOutput) track(target string, f func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		err := f(ctx)
+		o.addError(target, err)
+		return err
+	}
+}
+
+func writeOutput(b *strings.Builder, target, out string) {
+	if out == "" {
+		return
+	}
+	for _, line := range strings.SplitAfter(out, "\n") {
+		if line == "" {
+			continue
+		}
+		b.WriteString(target)
+		b.WriteString(": ")
+		b.WriteString(line)
+	}
+	if !strings.HasSuffix(out, "\n") {
+		b.WriteString("\n")
+	}
+}
+
+// Output implements cke.OutputCommander.
+func (o *CommandOutput) Output() (stdout, stderr string) {
+	if o == nil {
+		return "", ""
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stdout.String(), o.stderr.String()
+}
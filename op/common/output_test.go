@@ -0,0 +1,70 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cybozu-go/cke"
+)
+
+func TestCommandOutput(t *testing.T) {
+	var nilOutput *CommandOutput
+	nilOutput.Add("10.0.0.11", "foo", "bar")
+	if stdout, stderr := nilOutput.Output(); stdout != "" || stderr != "" {
+		t.Error("nil output should be empty")
+	}
+
+	o := new(CommandOutput)
+	o.Add("10.0.0.11", "foo\nbar\n", "")
+	o.Add("10.0.0.12", "baz", "error")
+
+	stdout, stderr := o.Output()
+	if stdout != "10.0.0.11: foo\n10.0.0.11: bar\n10.0.0.12: baz\n" {
+		t.Errorf("unexpected stdout: %q", stdout)
+	}
+	if stderr != "10.0.0.12: error\n" {
+		t.Errorf("unexpected stderr: %q", stderr)
+	}
+
+	o = new(CommandOutput)
+	ctx := context.Background()
+	if err := o.track("10.0.0.11", func(context.Context) error { return nil })(ctx); err != nil {
+		t.Error(err)
+	}
+	err := o.track("10.0.0.12", func(context.Context) error {
+		return cke.OutputError{Err: errors.New("exit status 1"), Stdout: []byte("foo\n"), Stderr: []byte("no such image\n")}
+	})(ctx)
+	if err == nil || err.Error() != "exit status 1" {
+		t.Error("error should be returned as is", err)
+	}
+	err = o.track("10.0.0.13", func(context.Context) error { return errors.New("connection refused") })(ctx)
+	if err == nil {
+		t.Error("error should be returned as is")
+	}
+	stdout, stderr = o.Output()
+	if stdout != "10.0.0.12: foo\n" {
+		t.Errorf("unexpected stdout: %q", stdout)
+	}
+	if stderr != "10.0.0.12: no such image\n" {
+		t.Errorf("unexpected stderr: %q", stderr)
+	}
+}
+
+func TestCommandersOutput(t *testing.T) {
+	commanders := []cke.Commander{
+		RunContainerCommand(nil, "etcd", cke.EtcdImage),
+		StopContainersCommand(nil, "etcd"),
+		KillContainersCommand(nil, "etcd"),
+		ImagePullCommand(nil, cke.EtcdImage),
+		MakeDirsCommand(nil, []string{"/var/lib/etcd"}),
+		NewFilesBuilder(nil),
+		VolumeCreateCommand(nil, "etcd"),
+		VolumeRemoveCommand(nil, "etcd"),
+	}
+	for _, c := range commanders {
+		if _, ok := c.(cke.OutputCommander); !ok {
+			t.Errorf("%s does not provide the output", c.Command().Name)
+		}
+	}
+}
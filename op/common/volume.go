@@ -11,20 +11,21 @@ import (
 type volumeCreateCommand struct {
 	nodes   []*cke.Node
 	volname string
+	*CommandOutput
 }
 
 // VolumeCreateCommand returns a Commander to create a volume on nodes.
 func VolumeCreateCommand(nodes []*cke.Node, name string) cke.Commander {
-	return volumeCreateCommand{nodes, name}
+	return volumeCreateCommand{nodes, name, new(CommandOutput)}
 }
 
 func (c volumeCreateCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
 	env := well.NewEnvironment(ctx)
 	for _, n := range c.nodes {
 		ce := inf.Engine(n.Address)
-		env.Go(func(ctx context.Context) error {
+		env.Go(c.track(n.Address, func(ctx context.Context) error {
 			return ce.VolumeCreate(c.volname)
-		})
+		}))
 	}
 	env.Stop()
 	return env.Wait()
@@ -40,18 +41,19 @@ func (c volumeCreateCommand) Command() cke.Command {
 type volumeRemoveCommand struct {
 	nodes   []*cke.Node
 	volname string
+	*CommandOutput
 }
 
 // VolumeRemoveCommand returns a Commander to remove a volume on nodes.
 func VolumeRemoveCommand(nodes []*cke.Node, name string) cke.Commander {
-	return volumeRemoveCommand{nodes, name}
+	return volumeRemoveCommand{nodes, name, new(CommandOutput)}
 }
 
 func (c volumeRemoveCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
 	env := well.NewEnvironment(ctx)
 	for _, n := range c.nodes {
 		ce := inf.Engine(n.Address)
-		env.Go(func(ctx context.Context) error {
+		env.Go(c.track(n.Address, func(ctx context.Context) error {
 			exists, err := ce.VolumeExists(c.volname)
 			if err != nil {
 				return err
@@ -60,7 +62,7 @@ func (c volumeRemoveCommand) Run(ctx context.Context, inf cke.Infrastructure, _
 				return ce.VolumeRemove(c.volname)
 			}
 			return nil
-		})
+		}))
 	}
 	env.Stop()
 	return env.Wait()
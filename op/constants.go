@@ -34,6 +34,8 @@ const (
 	RiversContainerName = "rivers"
 	// EtcdRiversContainerName is container name of etcd-rivers
 	EtcdRiversContainerName = "etcd-rivers"
+	// KMSPluginContainerName is container name of the KMS plugin
+	KMSPluginContainerName = "kms-plugin"
 
 	// RiversUpstreamPort is upstream port of rivers container
 	RiversUpstreamPort = 6443
@@ -67,6 +69,8 @@ const (
 
 	// CKEAnnotationReboot is the annotation to mark reboot targets
 	CKEAnnotationReboot = "cke.cybozu.com/reboot"
+	// CKEAnnotationRecordID is the annotation of Events to refer the operation record
+	CKEAnnotationRecordID = "cke.cybozu.com/record-id"
 
 	// SchedulerConfigPath is a path for scheduler extender config
 	SchedulerConfigPath = "/etc/kubernetes/scheduler/config.yml"
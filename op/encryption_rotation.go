@@ -0,0 +1,241 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cybozu-go/log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/cybozu-go/cke"
+)
+
+// reencryptPageSize is the number of objects listed at once for re-encryption.
+const reencryptPageSize = 500
+
+type encryptionPromoteKeyOp struct {
+	status   *cke.EncryptionStatus
+	finished bool
+}
+
+// EncryptionPromoteKeyOp returns an Operator to make the new key the encryption key.
+// This must be run after all API servers have been restarted with the new key.
+func EncryptionPromoteKeyOp(status *cke.EncryptionStatus) cke.Operator {
+	return &encryptionPromoteKeyOp{status: status}
+}
+
+func (o *encryptionPromoteKeyOp) Name() string {
+	return "encryption-promote-key"
+}
+
+func (o *encryptionPromoteKeyOp) NextCommand() cke.Commander {
+	if o.finished {
+		return nil
+	}
+	o.finished = true
+	return o
+}
+
+func (o *encryptionPromoteKeyOp) Targets() []string {
+	return nil
+}
+
+func (o *encryptionPromoteKeyOp) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
+	o.status.PromoteKey(time.Now().UTC())
+	return inf.Storage().PutEncryptionStatus(ctx, o.status)
+}
+
+func (o *encryptionPromoteKeyOp) Command() cke.Command {
+	return cke.Command{
+		Name:   "promote-encryption-key",
+		Target: o.status.Rotation.NewKey.Name,
+	}
+}
+
+type encryptionReencryptOp struct {
+	apiserver *cke.Node
+	status    *cke.EncryptionStatus
+	resources []string
+	finished  bool
+}
+
+// EncryptionReencryptOp returns an Operator to re-encrypt resources with the new key.
+// This must be run after all API servers have been restarted to encrypt data with the new key.
+func EncryptionReencryptOp(apiserver *cke.Node, status *cke.EncryptionStatus, resources []string) cke.Operator {
+	return &encryptionReencryptOp{
+		apiserver: apiserver,
+		status:    status,
+		resources: resources,
+	}
+}
+
+func (o *encryptionReencryptOp) Name() string {
+	return "encryption-reencrypt"
+}
+
+func (o *encryptionReencryptOp) NextCommand() cke.Commander {
+	if o.finished {
+		return nil
+	}
+	o.finished = true
+	return o
+}
+
+func (o *encryptionReencryptOp) Targets() []string {
+	return []string{
+		o.apiserver.Address,
+	}
+}
+
+func (o *encryptionReencryptOp) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
+	if o.status.Rotation.Phase != cke.EncryptionRotationReencrypt {
+		o.status.StartReencrypt(time.Now().UTC())
+		if err := inf.Storage().PutEncryptionStatus(ctx, o.status); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := inf.K8sConfig(ctx, o.apiserver)
+	if err != nil {
+		return err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, res := range o.resources {
+		if slices.Contains(o.status.Rotation.Reencrypted, res) {
+			continue
+		}
+
+		// resources are given as "<resource>.<group>" like EncryptionConfiguration.
+		resource, group, _ := strings.Cut(res, ".")
+		gvr, err := mapper.ResourceFor(schema.GroupVersionResource{Group: group, Resource: resource})
+		if err != nil {
+			return fmt.Errorf("failed to find resource %s: %w", res, err)
+		}
+
+		n, err := reencryptResource(ctx, dyn.Resource(gvr))
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt %s: %w", res, err)
+		}
+		log.Info("re-encrypted resources", map[string]any{
+			"resource": res,
+			"count":    n,
+		})
+
+		o.status.FinishReencrypt(res, o.resources, time.Now().UTC())
+		if err := inf.Storage().PutEncryptionStatus(ctx, o.status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reencryptResource rewrites all objects of a resource unchanged so that
+// API servers store them encrypted with the current encryption key.
+func reencryptResource(ctx context.Context, ri dynamic.NamespaceableResourceInterface) (int, error) {
+	var count int
+	var cont string
+	for {
+		list, err := ri.List(ctx, metav1.ListOptions{Limit: reencryptPageSize, Continue: cont})
+		if err != nil {
+			return count, err
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			_, err := ri.Namespace(obj.GetNamespace()).Update(ctx, obj, metav1.UpdateOptions{})
+			switch {
+			case err == nil:
+			case apierrors.IsConflict(err), apierrors.IsNotFound(err):
+				// the object has been updated or deleted, so it is stored with the current key.
+			default:
+				return count, err
+			}
+			count++
+		}
+
+		cont = list.GetContinue()
+		if cont == "" {
+			return count, nil
+		}
+	}
+}
+
+func (o *encryptionReencryptOp) Command() cke.Command {
+	return cke.Command{
+		Name:   "reencrypt-resources",
+		Target: strings.Join(o.resources, ","),
+	}
+}
+
+type encryptionDropKeysOp struct {
+	status   *cke.EncryptionStatus
+	finished bool
+}
+
+// EncryptionDropKeysOp returns an Operator to remove the old encryption keys.
+func EncryptionDropKeysOp(status *cke.EncryptionStatus) cke.Operator {
+	return &encryptionDropKeysOp{status: status}
+}
+
+func (o *encryptionDropKeysOp) Name() string {
+	return "encryption-drop-keys"
+}
+
+func (o *encryptionDropKeysOp) NextCommand() cke.Commander {
+	if o.finished {
+		return nil
+	}
+	o.finished = true
+	return o
+}
+
+func (o *encryptionDropKeysOp) Targets() []string {
+	return nil
+}
+
+func (o *encryptionDropKeysOp) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
+	dropped := o.status.DropKeys(time.Now().UTC())
+
+	// Update the status first so that API servers will not refer to the dropped keys.
+	if err := inf.Storage().PutEncryptionStatus(ctx, o.status); err != nil {
+		return err
+	}
+
+	vc, err := inf.Vault()
+	if err != nil {
+		return err
+	}
+	secrets, err := cke.ReadEncryptionKeySecrets(vc)
+	if err != nil {
+		return err
+	}
+	for _, k := range dropped {
+		secrets.Remove(k)
+	}
+	return cke.WriteEncryptionKeySecrets(vc, secrets)
+}
+
+func (o *encryptionDropKeysOp) Command() cke.Command {
+	return cke.Command{
+		Name:   "drop-encryption-keys",
+		Target: o.status.Rotation.NewKey.Name,
+	}
+}
@@ -40,6 +40,15 @@ func addressInURLs(address string, urls []string) (bool, error) {
 	return false, nil
 }
 
+// clientURLs returns the client URLs of etcd on the node.
+func clientURLs(node *cke.Node) []string {
+	urls := make([]string, 0, 2)
+	for _, a := range node.Addresses() {
+		urls = append(urls, "https://"+net.JoinHostPort(a, "2379"))
+	}
+	return urls
+}
+
 // BuiltInParams returns etcd parameters.
 func BuiltInParams(node *cke.Node, initialCluster []string, state string) cke.ServiceParams {
 	// NOTE: "--initial-*" flags and its value must be joined with '=' to
@@ -48,7 +57,7 @@ func BuiltInParams(node *cke.Node, initialCluster []string, state string) cke.Se
 		"--name=" + node.Address,
 		"--listen-peer-urls=https://0.0.0.0:2380",
 		"--listen-client-urls=https://0.0.0.0:2379",
-		"--advertise-client-urls=https://" + node.Address + ":2379",
+		"--advertise-client-urls=" + strings.Join(clientURLs(node), ","),
 		"--cert-file=" + op.EtcdPKIPath("server.crt"),
 		"--key-file=" + op.EtcdPKIPath("server.key"),
 		"--client-cert-auth=true",
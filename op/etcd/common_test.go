@@ -28,3 +28,15 @@ func TestBuiltInParamsCompaction(t *testing.T) {
 		}
 	}
 }
+
+func TestBuiltInParamsDualStack(t *testing.T) {
+	args := BuiltInParams(&cke.Node{Address: "10.0.0.11"}, nil, "").ExtraArguments
+	if !slices.Contains(args, "--advertise-client-urls=https://10.0.0.11:2379") {
+		t.Error("unexpected client URLs", args)
+	}
+
+	args = BuiltInParams(&cke.Node{Address: "10.0.0.11", SecondaryAddress: "fd00::11"}, nil, "").ExtraArguments
+	if !slices.Contains(args, "--advertise-client-urls=https://10.0.0.11:2379,https://[fd00::11]:2379") {
+		t.Error("secondary address should be advertised", args)
+	}
+}
@@ -0,0 +1,95 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cybozu-go/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/cybozu-go/cke"
+)
+
+const (
+	// EventComponent is the source component of Events emitted by CKE
+	EventComponent = "cke"
+	// EventReportingController is the reporting controller of Events emitted by CKE
+	EventReportingController = "cke.cybozu.com/cke"
+)
+
+// Reasons of Events emitted by CKE for Node resources.
+const (
+	EventReasonCordoned     = "CKECordoned"
+	EventReasonDraining     = "CKEDraining"
+	EventReasonDrainFailed  = "CKEDrainFailed"
+	EventReasonRebooting    = "CKERebooting"
+	EventReasonRebootFailed = "CKERebootFailed"
+	EventReasonRepairing    = "CKERepairing"
+	EventReasonRepairFailed = "CKERepairFailed"
+	EventReasonNodeUpdated  = "CKENodeUpdated"
+	EventReasonUncordoned   = "CKEUncordoned"
+)
+
+// recordNodeEvent creates an Event for the Node resource.
+// The ID of the operation record is taken from ctx if available.
+//
+// Events are informational; failures are logged and otherwise ignored.
+// Nothing is recorded if cs is nil.
+func recordNodeEvent(ctx context.Context, cs kubernetes.Interface, node, eventType, reason, message string) {
+	if cs == nil || node == "" {
+		return
+	}
+
+	ev := newNodeEvent(ctx, node, eventType, reason, message, time.Now())
+	_, err := cs.CoreV1().Events(ev.Namespace).Create(ctx, ev, metav1.CreateOptions{})
+	if err != nil {
+		log.Warn("failed to create event", map[string]any{
+			log.FnError: err,
+			"node":      node,
+			"reason":    reason,
+		})
+	}
+}
+
+func newNodeEvent(ctx context.Context, node, eventType, reason, message string, now time.Time) *corev1.Event {
+	hostname, _ := os.Hostname()
+
+	ev := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			// Events for cluster-scoped resources are created in the default namespace.
+			// The name follows the convention of client-go's event recorder.
+			Name:      fmt.Sprintf("%s.%x", node, now.UnixNano()),
+			Namespace: metav1.NamespaceDefault,
+		},
+		// kubectl describe node looks up events by the node name as UID, as kubelet does.
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       "Node",
+			APIVersion: "v1",
+			Name:       node,
+			UID:        types.UID(node),
+		},
+		Reason:              reason,
+		Message:             message,
+		Type:                eventType,
+		Source:              corev1.EventSource{Component: EventComponent, Host: hostname},
+		FirstTimestamp:      metav1.NewTime(now),
+		LastTimestamp:       metav1.NewTime(now),
+		Count:               1,
+		ReportingController: EventReportingController,
+		ReportingInstance:   hostname,
+	}
+
+	if id, ok := cke.RecordIDFromContext(ctx); ok {
+		ev.Annotations = map[string]string{
+			CKEAnnotationRecordID: strconv.FormatInt(id, 10),
+		}
+		ev.Message = fmt.Sprintf("%s (record %d)", message, id)
+	}
+	return ev
+}
@@ -0,0 +1,81 @@
+package op
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/cybozu-go/cke"
+)
+
+func TestNodeUpdateEvents(t *testing.T) {
+	cs := fake.NewClientset(cleanNode("10.0.0.1"), cleanNode("10.0.0.2"))
+	inf := &fakeInfrastructure{cs: cs}
+
+	nodes := []*corev1.Node{cleanNode("10.0.0.1"), cleanNode("10.0.0.2")}
+	nodes[0].Labels = map[string]string{"foo": "bar"}
+
+	ctx := cke.WithRecordID(context.Background(), 123)
+	err := nodeUpdateCommand{nodes: nodes}.Run(ctx, inf, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := cs.CoreV1().Events(metav1.NamespaceDefault).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events.Items))
+	}
+
+	for i, ev := range events.Items {
+		name := nodes[i].Name
+		expectedRef := corev1.ObjectReference{
+			Kind:       "Node",
+			APIVersion: "v1",
+			Name:       name,
+			UID:        types.UID(name),
+		}
+		if ev.InvolvedObject != expectedRef {
+			t.Errorf("unexpected involved object: %#v", ev.InvolvedObject)
+		}
+		if ev.Reason != EventReasonNodeUpdated || ev.Type != corev1.EventTypeNormal {
+			t.Errorf("unexpected reason or type: %s, %s", ev.Reason, ev.Type)
+		}
+		if ev.Source.Component != EventComponent || ev.ReportingController != EventReportingController {
+			t.Errorf("unexpected reporter: %#v, %s", ev.Source, ev.ReportingController)
+		}
+		if ev.Annotations[CKEAnnotationRecordID] != "123" {
+			t.Errorf("unexpected record ID annotation: %v", ev.Annotations)
+		}
+	}
+}
+
+func TestRecordNodeEventWithoutRecord(t *testing.T) {
+	cs := fake.NewClientset()
+	ctx := context.Background()
+
+	recordNodeEvent(ctx, cs, "10.0.0.1", corev1.EventTypeWarning, EventReasonDrainFailed, "failed")
+	recordNodeEvent(ctx, nil, "10.0.0.1", corev1.EventTypeWarning, EventReasonDrainFailed, "ignored")
+	recordNodeEvent(ctx, cs, "", corev1.EventTypeWarning, EventReasonDrainFailed, "ignored")
+
+	events, err := cs.CoreV1().Events(metav1.NamespaceDefault).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events.Items))
+	}
+	ev := events.Items[0]
+	if ev.Message != "failed" {
+		t.Errorf("unexpected message: %s", ev.Message)
+	}
+	if _, ok := ev.Annotations[CKEAnnotationRecordID]; ok {
+		t.Error("record ID annotation should not be set")
+	}
+}
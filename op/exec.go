@@ -22,8 +22,8 @@ var (
 
 // runCommand runs a command (command[0] is the executable, the rest are its arguments). It blocks until the command exits.
 // If timeoutSeconds is nonzero, the command is killed after that many seconds; a zero value means no timeout is applied.
-// This function logs the result (elapsed time, stdout, and stderr of the command) and returns the captured stdout.
-func runCommand(ctx context.Context, timeoutSeconds int, command []string) (stdout string, err error) {
+// This function logs the result (elapsed time, stdout, and stderr of the command) and returns the captured stdout and stderr.
+func runCommand(ctx context.Context, timeoutSeconds int, command []string) (stdout, stderr string, err error) {
 	execCtx := ctx
 	if timeoutSeconds != 0 {
 		var cancel context.CancelFunc
@@ -40,7 +40,7 @@ func runCommand(ctx context.Context, timeoutSeconds int, command []string) (stdo
 	err = cmd.Run()
 	et := time.Now()
 	stdout = stdoutBuf.String()
-	stderr := stderrBuf.String()
+	stderr = stderrBuf.String()
 
 	fields := map[string]any{
 		log.FnType:         "exec",
@@ -62,16 +62,16 @@ func runCommand(ctx context.Context, timeoutSeconds int, command []string) (stdo
 		switch {
 		case ctx.Err() != nil:
 			log.Error("exec: context done", fields)
-			return stdout, errContextDone
+			return stdout, stderr, errContextDone
 		case timeoutSeconds != 0 && execCtx.Err() == context.DeadlineExceeded:
 			log.Error("exec: timed out", fields)
-			return stdout, errCommandTimedOut
+			return stdout, stderr, errCommandTimedOut
 		default:
 			log.Error("exec: failed", fields)
-			return stdout, errCommandFailed
+			return stdout, stderr, errCommandFailed
 		}
 	}
 
 	log.Info("exec: succeeded", fields)
-	return stdout, nil
+	return stdout, stderr, nil
 }
@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cybozu-go/cke"
+)
+
+const admissionConfigBasePath = "/etc/kubernetes/apiserver/admission-config-%x.yaml"
+
+// admissionConfigFilePath returns the path of AdmissionConfiguration.
+// The path changes with the plugin configurations so that API servers are restarted when they are updated.
+func admissionConfigFilePath(plugins []cke.AdmissionPluginConfig) string {
+	// plugins are decoded from JSON or YAML, so encoding them never fails.
+	data, _ := json.Marshal(plugins)
+	return fmt.Sprintf(admissionConfigBasePath, md5.Sum(data))
+}
+
+// admissionConfigData renders AdmissionConfiguration with the plugin configurations embedded.
+func admissionConfigData(plugins []cke.AdmissionPluginConfig) ([]byte, error) {
+	pluginList := make([]any, len(plugins))
+	for i, p := range plugins {
+		pluginList[i] = map[string]any{
+			"name":          p.Name,
+			"configuration": p.Configuration.Object,
+		}
+	}
+	return yaml.Marshal(map[string]any{
+		"apiVersion": apiserverv1.SchemeGroupVersion.String(),
+		"kind":       "AdmissionConfiguration",
+		"plugins":    pluginList,
+	})
+}
+
+// enabledAdmissionPlugins returns the admission plugins to be enabled in addition to the default ones.
+// Plugins configured in the params are enabled because some of them, such as EventRateLimit, are disabled by default.
+func enabledAdmissionPlugins(plugins []cke.AdmissionPluginConfig) []string {
+	enabled := slices.Clone(admissionPlugins)
+	for _, p := range plugins {
+		if !slices.Contains(enabled, p.Name) {
+			enabled = append(enabled, p.Name)
+		}
+	}
+	return enabled
+}
@@ -3,15 +3,24 @@ package k8s
 import (
 	"context"
 	"crypto/md5"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
 	"github.com/cybozu-go/cke"
 	"github.com/cybozu-go/cke/op"
 	"github.com/cybozu-go/cke/op/common"
 )
 
-const auditPolicyBasePath = "/etc/kubernetes/apiserver/audit-policy-%x.yaml"
+const (
+	auditPolicyBasePath          = "/etc/kubernetes/apiserver/audit-policy-%x.yaml"
+	authenticationConfigBasePath = "/etc/kubernetes/apiserver/authentication-config-%x.yaml"
+)
 
 // admissionPlugins is our recommended list of admission plugins in addition to the default ones.
 // https://kubernetes.io/docs/reference/access-authn-authz/admission-controllers/#is-there-a-recommended-set-of-admission-controllers-to-use
@@ -26,22 +35,26 @@ var admissionPlugins = []string{
 type apiServerRestartOp struct {
 	nodes []*cke.Node
 
-	serviceSubnet string
-	params        cke.APIServerParams
-	clusterDomain string
+	serviceSubnets []string
+	featureGates   map[string]bool
+	params         cke.APIServerParams
+	encryption     *cke.EncryptionStatus
+	clusterDomain  string
 
 	step  int
 	files *common.FilesBuilder
 }
 
 // APIServerRestartOp returns an Operator to restart kube-apiserver
-func APIServerRestartOp(nodes []*cke.Node, serviceSubnet string, params cke.APIServerParams, clusterDomain string) cke.Operator {
+func APIServerRestartOp(nodes []*cke.Node, serviceSubnets []string, featureGates map[string]bool, params cke.APIServerParams, encryption *cke.EncryptionStatus, clusterDomain string) cke.Operator {
 	return &apiServerRestartOp{
-		nodes:         nodes,
-		serviceSubnet: serviceSubnet,
-		clusterDomain: clusterDomain,
-		params:        params,
-		files:         common.NewFilesBuilder(nodes),
+		nodes:          nodes,
+		serviceSubnets: serviceSubnets,
+		featureGates:   featureGates,
+		clusterDomain:  clusterDomain,
+		params:         params,
+		encryption:     encryption,
+		files:          common.NewFilesBuilder(nodes),
 	}
 }
 
@@ -59,7 +72,7 @@ func (o *apiServerRestartOp) NextCommand() cke.Commander {
 		return common.MakeDirsCommandWithMode(o.nodes, []string{encryptionConfigDir}, "700")
 	case 2:
 		o.step++
-		return prepareAPIServerFilesCommand{o.files, o.serviceSubnet, o.clusterDomain, o.params}
+		return prepareAPIServerFilesCommand{o.files, o.serviceSubnets, o.clusterDomain, o.params, o.encryption}
 	case 3:
 		o.step++
 		return o.files
@@ -73,7 +86,7 @@ func (o *apiServerRestartOp) NextCommand() cke.Commander {
 		}
 		paramsMap := make(map[string]cke.ServiceParams)
 		for _, n := range o.nodes {
-			paramsMap[n.Address] = APIServerParams(n.Address, o.serviceSubnet, o.params.AuditLogEnabled, o.params.AuditLogPolicy, o.params.AuditLogPath, o.clusterDomain)
+			paramsMap[n.Address] = APIServerParams(n.Address, o.serviceSubnets, o.featureGates, o.params, o.encryption, o.clusterDomain)
 		}
 		return common.RunContainerCommand(o.nodes,
 			op.KubeAPIServerContainerName, cke.KubernetesImage,
@@ -94,10 +107,11 @@ func (o *apiServerRestartOp) Targets() []string {
 }
 
 type prepareAPIServerFilesCommand struct {
-	files         *common.FilesBuilder
-	serviceSubnet string
-	clusterDomain string
-	params        cke.APIServerParams
+	files          *common.FilesBuilder
+	serviceSubnets []string
+	clusterDomain  string
+	params         cke.APIServerParams
+	encryption     *cke.EncryptionStatus
 }
 
 func (c prepareAPIServerFilesCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
@@ -105,7 +119,7 @@ func (c prepareAPIServerFilesCommand) Run(ctx context.Context, inf cke.Infrastru
 
 	// server (and client) certs of API server.
 	f := func(ctx context.Context, n *cke.Node) (cert, key []byte, err error) {
-		c, k, e := cke.KubernetesCA{}.IssueForAPIServer(ctx, inf, n, c.serviceSubnet, c.clusterDomain)
+		c, k, e := cke.KubernetesCA{}.IssueForAPIServer(ctx, inf, n, c.serviceSubnets, c.clusterDomain)
 		if e != nil {
 			return nil, nil, e
 		}
@@ -202,7 +216,7 @@ func (c prepareAPIServerFilesCommand) Run(ctx context.Context, inf cke.Infrastru
 	}
 
 	// EncryptionConfiguration
-	enccfg, err := getEncryptionConfiguration(ctx, inf)
+	enccfg, err := getEncryptionConfiguration(ctx, inf, c.encryption, c.params)
 	if err != nil {
 		return err
 	}
@@ -210,15 +224,87 @@ func (c prepareAPIServerFilesCommand) Run(ctx context.Context, inf cke.Infrastru
 	if err != nil {
 		return err
 	}
-	err = c.files.AddFile(ctx, encryptionConfigFile, func(ctx context.Context, node *cke.Node) ([]byte, error) {
+	err = c.files.AddFile(ctx, encryptionConfigFilePath(c.encryption, c.params), func(ctx context.Context, node *cke.Node) ([]byte, error) {
 		return enccfgData, nil
 	})
 	if err != nil {
 		return err
 	}
 
+	// AuthenticationConfiguration
+	if c.params.AuthenticationConfig != nil {
+		authncfgData, err := yaml.Marshal(c.params.AuthenticationConfig.Object)
+		if err != nil {
+			return err
+		}
+		err = c.files.AddFile(ctx, authenticationConfigFilePath(c.params.AuthenticationConfig), func(context.Context, *cke.Node) ([]byte, error) {
+			return authncfgData, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// AuthorizationConfiguration and kubeconfig files for webhook authorizers
+	if authz := c.params.Authorization; authz != nil {
+		authzcfgData, err := encodeToYAML(generateAuthorizationConfiguration(authz))
+		if err != nil {
+			return err
+		}
+		err = c.files.AddFile(ctx, authorizationConfigFilePath(authz), func(context.Context, *cke.Node) ([]byte, error) {
+			return authzcfgData, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, a := range authz.Authorizers {
+			if a.Webhook == nil {
+				continue
+			}
+			kubeconfigData, err := clientcmd.Write(*webhookKubeconfig(a.Name, a.Webhook.Server, a.Webhook.CACert))
+			if err != nil {
+				return err
+			}
+			err = c.files.AddFile(ctx, authorizationWebhookKubeconfigPath(authz, a.Name), func(context.Context, *cke.Node) ([]byte, error) {
+				return kubeconfigData, nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// AdmissionConfiguration
+	if len(c.params.AdmissionPlugins) > 0 {
+		admissioncfgData, err := admissionConfigData(c.params.AdmissionPlugins)
+		if err != nil {
+			return err
+		}
+		err = c.files.AddFile(ctx, admissionConfigFilePath(c.params.AdmissionPlugins), func(context.Context, *cke.Node) ([]byte, error) {
+			return admissioncfgData, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// kubeconfig for the audit webhook
+	if w := c.params.AuditWebhook; w != nil {
+		kubeconfigData, err := clientcmd.Write(*webhookKubeconfig(auditWebhookName, w.Server, w.CACert))
+		if err != nil {
+			return err
+		}
+		err = c.files.AddFile(ctx, auditWebhookKubeconfigPath(w), func(context.Context, *cke.Node) ([]byte, error) {
+			return kubeconfigData, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	// audit log policy
-	if c.params.AuditLogEnabled {
+	if c.params.AuditEnabled() {
 		return c.files.AddFile(ctx, auditPolicyFilePath(c.params.AuditLogPolicy), func(context.Context, *cke.Node) ([]byte, error) {
 			return []byte(c.params.AuditLogPolicy), nil
 		})
@@ -237,8 +323,32 @@ func auditPolicyFilePath(policy string) string {
 	return fmt.Sprintf(auditPolicyBasePath, md5.Sum([]byte(policy)))
 }
 
+// authenticationConfigFilePath returns the path of AuthenticationConfiguration.
+// The path changes with the content so that API servers are restarted when it is updated.
+func authenticationConfigFilePath(cfg *unstructured.Unstructured) string {
+	// cfg is decoded from JSON or YAML, so encoding it never fails.
+	data, _ := json.Marshal(cfg.Object)
+	return fmt.Sprintf(authenticationConfigBasePath, md5.Sum(data))
+}
+
+// apiServerFeatureGates returns the feature gates for API server.
+// Coordinated leader election is always enabled for stable rolling restart of API server processes.
+func apiServerFeatureGates(featureGates map[string]bool) string {
+	gates := maps.Clone(featureGates)
+	if gates == nil {
+		gates = make(map[string]bool)
+	}
+	gates["CoordinatedLeaderElection"] = true
+	return cke.FeatureGatesArg(gates)
+}
+
 // APIServerParams returns parameters for API server.
-func APIServerParams(advertiseAddress, serviceSubnet string, auditLogEnabled bool, auditLogPolicy, auditLogPath string, clusterDomain string) cke.ServiceParams {
+func APIServerParams(advertiseAddress string, serviceSubnets []string, featureGates map[string]bool, params cke.APIServerParams, encryption *cke.EncryptionStatus, clusterDomain string) cke.ServiceParams {
+	authorizationArg := "--authorization-mode=Node,RBAC"
+	if params.Authorization != nil {
+		authorizationArg = "--authorization-config=" + authorizationConfigFilePath(params.Authorization)
+	}
+
 	args := []string{
 		"kube-apiserver",
 		"--allow-privileged",
@@ -256,7 +366,7 @@ func APIServerParams(advertiseAddress, serviceSubnet string, auditLogEnabled boo
 		"--kubelet-client-certificate=" + op.K8sPKIPath("apiserver.crt"),
 		"--kubelet-client-key=" + op.K8sPKIPath("apiserver.key"),
 
-		"--enable-admission-plugins=" + strings.Join(admissionPlugins, ","),
+		"--enable-admission-plugins=" + strings.Join(enabledAdmissionPlugins(params.AdmissionPlugins), ","),
 
 		// for service accounts
 		"--service-account-issuer=https://kubernetes.default.svc." + clusterDomain,
@@ -273,46 +383,55 @@ func APIServerParams(advertiseAddress, serviceSubnet string, auditLogEnabled boo
 		"--proxy-client-cert-file=" + op.K8sPKIPath("aggregation.crt"),
 		"--proxy-client-key-file=" + op.K8sPKIPath("aggregation.key"),
 
-		"--authorization-mode=Node,RBAC",
+		authorizationArg,
 
 		"--advertise-address=" + advertiseAddress,
 
 		// See https://github.com/cybozu-go/neco/issues/397
 		"--endpoint-reconciler-type=none",
 
-		"--service-cluster-ip-range=" + serviceSubnet,
-		"--encryption-provider-config=" + encryptionConfigFile,
+		"--service-cluster-ip-range=" + strings.Join(serviceSubnets, ","),
+		"--encryption-provider-config=" + encryptionConfigFilePath(encryption, params),
 
-		// enable coordinated leader election for stable rolling restart of API server processes
-		"--feature-gates=CoordinatedLeaderElection=true",
+		"--feature-gates=" + apiServerFeatureGates(featureGates),
 		"--runtime-config=coordination.k8s.io/v1beta1=true",
 	}
-	if auditLogEnabled {
-		logPath := "-"
-		if auditLogPath != "" {
-			logPath = auditLogPath
-		}
-		args = append(args, "--audit-log-path="+logPath)
-		args = append(args, "--audit-policy-file="+auditPolicyFilePath(auditLogPolicy))
+	if len(params.AdmissionPlugins) > 0 {
+		args = append(args, "--admission-control-config-file="+admissionConfigFilePath(params.AdmissionPlugins))
+	}
+	if params.AuthenticationConfig != nil {
+		args = append(args, "--authentication-config="+authenticationConfigFilePath(params.AuthenticationConfig))
+	}
+	args = append(args, auditArgs(params)...)
+
+	binds := []cke.Mount{
+		{
+			Source:      "/etc/machine-id",
+			Destination: "/etc/machine-id",
+			ReadOnly:    true,
+			Propagation: "",
+			Label:       "",
+		},
+		{
+			Source:      "/etc/kubernetes",
+			Destination: "/etc/kubernetes",
+			ReadOnly:    true,
+			Propagation: "",
+			Label:       cke.LabelShared,
+		},
+	}
+	if cke.KMSPluginEnabled(params, encryption) {
+		binds = append(binds, cke.Mount{
+			Source:      kmsSocketDir,
+			Destination: kmsSocketDir,
+			ReadOnly:    false,
+			Propagation: "",
+			Label:       cke.LabelShared,
+		})
 	}
 
 	return cke.ServiceParams{
 		ExtraArguments: args,
-		ExtraBinds: []cke.Mount{
-			{
-				Source:      "/etc/machine-id",
-				Destination: "/etc/machine-id",
-				ReadOnly:    true,
-				Propagation: "",
-				Label:       "",
-			},
-			{
-				Source:      "/etc/kubernetes",
-				Destination: "/etc/kubernetes",
-				ReadOnly:    true,
-				Propagation: "",
-				Label:       cke.LabelShared,
-			},
-		},
+		ExtraBinds:     binds,
 	}
 }
@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/cybozu-go/cke"
+)
+
+const (
+	auditWebhookName               = "audit-webhook"
+	auditWebhookKubeconfigBasePath = "/etc/kubernetes/apiserver/audit-webhook-%x.kubeconfig"
+)
+
+// auditWebhookKubeconfigPath returns the path of kubeconfig for the audit webhook.
+// The path changes with the server and the CA certificate so that API servers are restarted when they are updated.
+func auditWebhookKubeconfigPath(w *cke.AuditWebhookParams) string {
+	// the server and the CA certificate are strings, so encoding them never fails.
+	data, _ := json.Marshal([]string{w.Server, w.CACert})
+	return fmt.Sprintf(auditWebhookKubeconfigBasePath, md5.Sum(data))
+}
+
+// auditArgs returns the command-line arguments of kube-apiserver for audit backends.
+func auditArgs(params cke.APIServerParams) []string {
+	if !params.AuditEnabled() {
+		return nil
+	}
+
+	var args []string
+	if params.AuditLogEnabled {
+		logPath := "-"
+		if params.AuditLogPath != "" {
+			logPath = params.AuditLogPath
+		}
+		args = append(args, "--audit-log-path="+logPath)
+	}
+	args = append(args, "--audit-policy-file="+auditPolicyFilePath(params.AuditLogPolicy))
+
+	if params.AuditLogMaxSize != nil {
+		args = append(args, "--audit-log-maxsize="+strconv.Itoa(*params.AuditLogMaxSize))
+	}
+	if params.AuditLogMaxBackups != nil {
+		args = append(args, "--audit-log-maxbackup="+strconv.Itoa(*params.AuditLogMaxBackups))
+	}
+	if params.AuditLogMaxAge != nil {
+		args = append(args, "--audit-log-maxage="+strconv.Itoa(*params.AuditLogMaxAge))
+	}
+
+	if w := params.AuditWebhook; w != nil {
+		args = append(args,
+			"--audit-webhook-config-file="+auditWebhookKubeconfigPath(w),
+			"--audit-webhook-mode="+w.GetMode(),
+			fmt.Sprintf("--audit-webhook-initial-backoff=%ds", w.GetInitialBackoffSeconds()),
+		)
+		if w.GetMode() == cke.AuditWebhookModeBatch {
+			args = append(args,
+				"--audit-webhook-batch-buffer-size="+strconv.Itoa(w.GetBatchBufferSize()),
+				"--audit-webhook-batch-max-size="+strconv.Itoa(w.GetBatchMaxSize()),
+				fmt.Sprintf("--audit-webhook-batch-max-wait=%ds", w.GetBatchMaxWaitSeconds()),
+			)
+		}
+	}
+	return args
+}
@@ -0,0 +1,97 @@
+package k8s
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cybozu-go/cke"
+	"github.com/cybozu-go/cke/op"
+)
+
+const (
+	authorizationConfigBasePath            = "/etc/kubernetes/apiserver/authorization-config-%x.yaml"
+	authorizationWebhookKubeconfigBasePath = "/etc/kubernetes/apiserver/authorization-webhook-%x-%s.kubeconfig"
+)
+
+// authorizationHash returns the hash of the authorization parameters.
+// The hash is embedded in the paths of the rendered files so that
+// API servers are restarted when the parameters are updated.
+func authorizationHash(p *cke.AuthorizationParams) [md5.Size]byte {
+	// p consists of strings and integers, so encoding it never fails.
+	data, _ := json.Marshal(p)
+	return md5.Sum(data)
+}
+
+func authorizationConfigFilePath(p *cke.AuthorizationParams) string {
+	return fmt.Sprintf(authorizationConfigBasePath, authorizationHash(p))
+}
+
+func authorizationWebhookKubeconfigPath(p *cke.AuthorizationParams, name string) string {
+	return fmt.Sprintf(authorizationWebhookKubeconfigBasePath, authorizationHash(p), name)
+}
+
+// generateAuthorizationConfiguration generates AuthorizationConfiguration from the parameters.
+func generateAuthorizationConfiguration(p *cke.AuthorizationParams) *apiserverv1.AuthorizationConfiguration {
+	cfg := &apiserverv1.AuthorizationConfiguration{}
+	for _, a := range p.Authorizers {
+		authz := apiserverv1.AuthorizerConfiguration{
+			Type: a.Type,
+			Name: a.Name,
+		}
+		if w := a.Webhook; w != nil {
+			kubeconfig := authorizationWebhookKubeconfigPath(p, a.Name)
+			authz.Webhook = &apiserverv1.WebhookConfiguration{
+				AuthorizedTTL:                            seconds(w.GetAuthorizedTTLSeconds()),
+				UnauthorizedTTL:                          seconds(w.GetUnauthorizedTTLSeconds()),
+				Timeout:                                  seconds(w.GetTimeoutSeconds()),
+				SubjectAccessReviewVersion:               "v1",
+				MatchConditionSubjectAccessReviewVersion: "v1",
+				FailurePolicy:                            w.GetFailurePolicy(),
+				ConnectionInfo: apiserverv1.WebhookConnectionInfo{
+					Type:           apiserverv1.AuthorizationWebhookConnectionInfoTypeKubeConfigFile,
+					KubeConfigFile: &kubeconfig,
+				},
+				MatchConditions: []apiserverv1.WebhookMatchCondition{},
+			}
+			for _, cond := range w.MatchConditions {
+				authz.Webhook.MatchConditions = append(authz.Webhook.MatchConditions, apiserverv1.WebhookMatchCondition{Expression: cond})
+			}
+		}
+		cfg.Authorizers = append(cfg.Authorizers, authz)
+	}
+	return cfg
+}
+
+// webhookKubeconfig returns kubeconfig for kube-apiserver to call a webhook such as
+// a webhook authorizer or an audit webhook.
+// kube-apiserver authenticates itself with its client certificate for kubelets.
+func webhookKubeconfig(name, server, caCert string) *api.Config {
+	cfg := api.NewConfig()
+	c := api.NewCluster()
+	c.Server = server
+	c.CertificateAuthorityData = []byte(caCert)
+	cfg.Clusters[name] = c
+
+	auth := api.NewAuthInfo()
+	auth.ClientCertificate = op.K8sPKIPath("apiserver.crt")
+	auth.ClientKey = op.K8sPKIPath("apiserver.key")
+	cfg.AuthInfos["kube-apiserver"] = auth
+
+	ctx := api.NewContext()
+	ctx.AuthInfo = "kube-apiserver"
+	ctx.Cluster = name
+	cfg.Contexts["default"] = ctx
+	cfg.CurrentContext = "default"
+
+	return cfg
+}
+
+func seconds(n int) metav1.Duration {
+	return metav1.Duration{Duration: time.Duration(n) * time.Second}
+}
@@ -91,6 +91,10 @@ func GenerateProxyConfiguration(params cke.ProxyParams, n *cke.Node) *proxyv1alp
 			TCPCloseWaitTimeout:   &metav1.Duration{Duration: 1 * time.Hour},
 		},
 	}
+	if n.SecondaryAddress != "" {
+		// kube-proxy takes the primary IP family from bindAddress for dual-stack.
+		base.BindAddress = n.Address
+	}
 
 	c, err := params.MergeConfig(&base)
 	if err != nil {
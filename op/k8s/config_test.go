@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
 	schedulerv1 "k8s.io/kube-scheduler/config/v1"
 	kubeletv1beta1 "k8s.io/kubelet/config/v1beta1"
 
@@ -131,3 +133,276 @@ func TestGenerateKubeletConfiguration(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateAuthorizationConfiguration(t *testing.T) {
+	t.Parallel()
+
+	input := &cke.AuthorizationParams{
+		Authorizers: []cke.Authorizer{
+			{Type: cke.AuthorizerNode, Name: "node"},
+			{
+				Type: cke.AuthorizerWebhook,
+				Name: "external",
+				Webhook: &cke.AuthorizationWebhook{
+					Server:          "https://authz.example.com/authorize",
+					CACert:          "dummy",
+					TimeoutSeconds:  new(5),
+					FailurePolicy:   "Deny",
+					MatchConditions: []string{"request.user != 'admin'"},
+				},
+			},
+			{Type: cke.AuthorizerRBAC, Name: "rbac"},
+		},
+	}
+	kubeconfig := authorizationWebhookKubeconfigPath(input, "external")
+	expected := &apiserverv1.AuthorizationConfiguration{
+		Authorizers: []apiserverv1.AuthorizerConfiguration{
+			{Type: "Node", Name: "node"},
+			{
+				Type: "Webhook",
+				Name: "external",
+				Webhook: &apiserverv1.WebhookConfiguration{
+					AuthorizedTTL:                            metav1.Duration{Duration: 5 * time.Minute},
+					UnauthorizedTTL:                          metav1.Duration{Duration: 30 * time.Second},
+					Timeout:                                  metav1.Duration{Duration: 5 * time.Second},
+					SubjectAccessReviewVersion:               "v1",
+					MatchConditionSubjectAccessReviewVersion: "v1",
+					FailurePolicy:                            "Deny",
+					ConnectionInfo: apiserverv1.WebhookConnectionInfo{
+						Type:           "KubeConfigFile",
+						KubeConfigFile: &kubeconfig,
+					},
+					MatchConditions: []apiserverv1.WebhookMatchCondition{{Expression: "request.user != 'admin'"}},
+				},
+			},
+			{Type: "RBAC", Name: "rbac"},
+		},
+	}
+
+	cfg := generateAuthorizationConfiguration(input)
+	if !cmp.Equal(cfg, expected) {
+		t.Error("generateAuthorizationConfiguration() generated unexpected result", cmp.Diff(cfg, expected))
+	}
+
+	data, err := encodeToYAML(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "apiVersion: apiserver.config.k8s.io/v1\n") ||
+		!strings.Contains(string(data), "kind: AuthorizationConfiguration\n") {
+		t.Error("unexpected YAML", string(data))
+	}
+
+	// the paths change with the parameters.
+	input.Authorizers[1].Webhook.Server = "https://authz2.example.com/authorize"
+	if authorizationWebhookKubeconfigPath(input, "external") == kubeconfig {
+		t.Error("kubeconfig path should be changed")
+	}
+}
+
+func TestAuditArgs(t *testing.T) {
+	t.Parallel()
+
+	params := cke.APIServerParams{
+		AuditLogEnabled:    true,
+		AuditLogPolicy:     "policy",
+		AuditLogPath:       "/var/log/audit/audit.log",
+		AuditLogMaxSize:    new(100),
+		AuditLogMaxBackups: new(10),
+		AuditWebhook: &cke.AuditWebhookParams{
+			Server:       "https://audit.example.com/events",
+			CACert:       "dummy",
+			BatchMaxSize: new(100),
+		},
+	}
+	kubeconfig := auditWebhookKubeconfigPath(params.AuditWebhook)
+	expected := []string{
+		"--audit-log-path=/var/log/audit/audit.log",
+		"--audit-policy-file=" + auditPolicyFilePath("policy"),
+		"--audit-log-maxsize=100",
+		"--audit-log-maxbackup=10",
+		"--audit-webhook-config-file=" + kubeconfig,
+		"--audit-webhook-mode=batch",
+		"--audit-webhook-initial-backoff=10s",
+		"--audit-webhook-batch-buffer-size=10000",
+		"--audit-webhook-batch-max-size=100",
+		"--audit-webhook-batch-max-wait=30s",
+	}
+	if args := auditArgs(params); !cmp.Equal(args, expected) {
+		t.Error("unexpected audit args", cmp.Diff(expected, args))
+	}
+
+	// batch options are not used in blocking modes.
+	params.AuditLogEnabled = false
+	params.AuditWebhook.Mode = cke.AuditWebhookModeBlocking
+	expected = []string{
+		"--audit-policy-file=" + auditPolicyFilePath("policy"),
+		"--audit-log-maxsize=100",
+		"--audit-log-maxbackup=10",
+		"--audit-webhook-config-file=" + kubeconfig,
+		"--audit-webhook-mode=blocking",
+		"--audit-webhook-initial-backoff=10s",
+	}
+	if args := auditArgs(params); !cmp.Equal(args, expected) {
+		t.Error("unexpected audit args", cmp.Diff(expected, args))
+	}
+
+	params.AuditWebhook.CACert = "dummy2"
+	if auditWebhookKubeconfigPath(params.AuditWebhook) == kubeconfig {
+		t.Error("kubeconfig path should be changed")
+	}
+
+	if args := auditArgs(cke.APIServerParams{AuditLogPolicy: "policy"}); len(args) != 0 {
+		t.Error("audit args should be empty", args)
+	}
+}
+
+func TestAPIServerFeatureGates(t *testing.T) {
+	t.Parallel()
+
+	if gates := apiServerFeatureGates(nil); gates != "CoordinatedLeaderElection=true" {
+		t.Error("unexpected default feature gates", gates)
+	}
+
+	featureGates := map[string]bool{"WatchList": false, "APIServerTracing": true}
+	expected := "APIServerTracing=true,CoordinatedLeaderElection=true,WatchList=false"
+	if gates := apiServerFeatureGates(featureGates); gates != expected {
+		t.Error("unexpected feature gates", gates)
+	}
+	if len(featureGates) != 2 {
+		t.Error("featureGates is modified", featureGates)
+	}
+}
+
+func TestAdmissionConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := &unstructured.Unstructured{}
+	cfg.SetAPIVersion("eventratelimit.admission.k8s.io/v1alpha1")
+	cfg.SetKind("Configuration")
+	cfg.Object["limits"] = []any{map[string]any{"type": "Server", "qps": 50, "burst": 100}}
+	plugins := []cke.AdmissionPluginConfig{{Name: "EventRateLimit", Configuration: cfg}}
+
+	data, err := admissionConfigData(plugins)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: apiserver.config.k8s.io/v1
+kind: AdmissionConfiguration
+plugins:
+- configuration:
+    apiVersion: eventratelimit.admission.k8s.io/v1alpha1
+    kind: Configuration
+    limits:
+    - burst: 100
+      qps: 50
+      type: Server
+  name: EventRateLimit
+`
+	if string(data) != expected {
+		t.Error("unexpected AdmissionConfiguration", cmp.Diff(expected, string(data)))
+	}
+
+	enabled := enabledAdmissionPlugins(plugins)
+	if !cmp.Equal(enabled, []string{"NodeRestriction", "DenyServiceExternalIPs", "EventRateLimit"}) {
+		t.Error("EventRateLimit should be enabled", enabled)
+	}
+	if len(admissionPlugins) != 2 {
+		t.Error("admissionPlugins must not be modified", admissionPlugins)
+	}
+
+	path := admissionConfigFilePath(plugins)
+	cfg.Object["limits"] = []any{map[string]any{"type": "Server", "qps": 50, "burst": 200}}
+	if admissionConfigFilePath(plugins) == path {
+		t.Error("the path should be changed with the configuration")
+	}
+}
+
+func TestGenerateEncryptionConfiguration(t *testing.T) {
+	t.Parallel()
+
+	secrets := cke.EncryptionKeySecrets{
+		cke.EncryptionProviderAESCBC: {
+			{Name: "cbc1", Secret: "secret1"},
+			{Name: "cbc2", Secret: "secret2"},
+		},
+		cke.EncryptionProviderAESGCM: {
+			{Name: "gcm1", Secret: "secret3"},
+		},
+	}
+	keys := []cke.EncryptionKeyInfo{
+		{Name: "vault-20260102T030405Z", Provider: cke.EncryptionProviderKMS},
+		{Name: "gcm1", Provider: cke.EncryptionProviderAESGCM},
+		{Name: "cbc2", Provider: cke.EncryptionProviderAESCBC},
+		{Name: "cbc1", Provider: cke.EncryptionProviderAESCBC},
+	}
+	resources := []string{"secrets", "configmaps"}
+	params := cke.APIServerParams{
+		EncryptionResources: resources,
+		KMS:                 &cke.KMSParams{TimeoutSeconds: new(5)},
+	}
+	expected := &apiserverv1.EncryptionConfiguration{
+		Resources: []apiserverv1.ResourceConfiguration{
+			{
+				Resources: resources,
+				Providers: []apiserverv1.ProviderConfiguration{
+					{KMS: &apiserverv1.KMSConfiguration{
+						APIVersion: "v2",
+						Name:       "vault-20260102T030405Z",
+						Endpoint:   "unix:///run/cke-kms/kms.sock",
+						Timeout:    &metav1.Duration{Duration: 5 * time.Second},
+					}},
+					{AESGCM: &apiserverv1.AESConfiguration{Keys: []apiserverv1.Key{{Name: "gcm1", Secret: "secret3"}}}},
+					{AESCBC: &apiserverv1.AESConfiguration{Keys: []apiserverv1.Key{
+						{Name: "cbc2", Secret: "secret2"},
+						{Name: "cbc1", Secret: "secret1"},
+					}}},
+					{Identity: &apiserverv1.IdentityConfiguration{}},
+				},
+			},
+		},
+	}
+
+	cfg, err := generateEncryptionConfiguration(keys, secrets, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(cfg, expected) {
+		t.Error("generateEncryptionConfiguration() generated unexpected result", cmp.Diff(cfg, expected))
+	}
+
+	if _, err := generateEncryptionConfiguration(nil, secrets, params); err == nil {
+		t.Error("no keys should be rejected")
+	}
+	missing := []cke.EncryptionKeyInfo{{Name: "gcm2", Provider: cke.EncryptionProviderAESGCM}}
+	if _, err := generateEncryptionConfiguration(missing, secrets, params); err == nil {
+		t.Error("keys without secrets should be rejected")
+	}
+
+	// the path is kept until the keys are managed by the rotation workflow.
+	if p := encryptionConfigFilePath(nil, cke.APIServerParams{}); p != encryptionConfigFile {
+		t.Error("unexpected path", p)
+	}
+	st := &cke.EncryptionStatus{Keys: keys[1:]}
+	path := encryptionConfigFilePath(st, cke.APIServerParams{})
+	if path == encryptionConfigFile {
+		t.Error("the path should be changed with the keys")
+	}
+	if encryptionConfigFilePath(st, cke.APIServerParams{EncryptionResources: resources}) == path {
+		t.Error("the path should be changed with the resources")
+	}
+	if encryptionConfigFilePath(st, params) != encryptionConfigFilePath(st, cke.APIServerParams{EncryptionResources: resources}) {
+		t.Error("the path should not be changed with the KMS timeout unless KMS is used")
+	}
+	st.Keys = keys[1:3]
+	if encryptionConfigFilePath(st, cke.APIServerParams{}) == path {
+		t.Error("the path should be changed when a key is dropped")
+	}
+
+	st.Keys = keys
+	path = encryptionConfigFilePath(st, params)
+	params.KMS = nil
+	if encryptionConfigFilePath(st, params) == path {
+		t.Error("the path should be changed with the KMS timeout")
+	}
+}
@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"context"
+	"strings"
 
 	"k8s.io/client-go/tools/clientcmd"
 
@@ -13,22 +14,24 @@ import (
 type controllerManagerBootOp struct {
 	nodes []*cke.Node
 
-	cluster       string
-	serviceSubnet string
-	params        cke.ServiceParams
+	cluster        string
+	serviceSubnets []string
+	featureGates   map[string]bool
+	params         cke.ControllerManagerParams
 
 	step  int
 	files *common.FilesBuilder
 }
 
 // ControllerManagerBootOp returns an Operator to bootstrap kube-controller-manager
-func ControllerManagerBootOp(nodes []*cke.Node, cluster string, serviceSubnet string, params cke.ServiceParams) cke.Operator {
+func ControllerManagerBootOp(nodes []*cke.Node, cluster string, serviceSubnets []string, featureGates map[string]bool, params cke.ControllerManagerParams) cke.Operator {
 	return &controllerManagerBootOp{
-		nodes:         nodes,
-		cluster:       cluster,
-		serviceSubnet: serviceSubnet,
-		params:        params,
-		files:         common.NewFilesBuilder(nodes),
+		nodes:          nodes,
+		cluster:        cluster,
+		serviceSubnets: serviceSubnets,
+		featureGates:   featureGates,
+		params:         params,
+		files:          common.NewFilesBuilder(nodes),
 	}
 }
 
@@ -51,8 +54,8 @@ func (o *controllerManagerBootOp) NextCommand() cke.Commander {
 		o.step++
 		return common.RunContainerCommand(o.nodes,
 			op.KubeControllerManagerContainerName, cke.KubernetesImage,
-			common.WithParams(ControllerManagerParams(o.cluster, o.serviceSubnet)),
-			common.WithExtra(o.params))
+			common.WithParams(ControllerManagerParams(o.cluster, o.serviceSubnets, o.featureGates, o.params)),
+			common.WithExtra(o.params.ServiceParams))
 	default:
 		return nil
 	}
@@ -109,11 +112,12 @@ func (c prepareControllerManagerFilesCommand) Command() cke.Command {
 }
 
 // ControllerManagerParams returns parameters for kube-controller-manager.
-func ControllerManagerParams(clusterName, serviceSubnet string) cke.ServiceParams {
+// The arguments converted from the configuration in params are included.
+func ControllerManagerParams(clusterName string, serviceSubnets []string, featureGates map[string]bool, params cke.ControllerManagerParams) cke.ServiceParams {
 	args := []string{
 		"kube-controller-manager",
 		"--cluster-name=" + clusterName,
-		"--service-cluster-ip-range=" + serviceSubnet,
+		"--service-cluster-ip-range=" + strings.Join(serviceSubnets, ","),
 		"--kubeconfig=" + op.ControllerManagerKubeConfigPath,
 		"--authentication-kubeconfig=" + op.ControllerManagerKubeConfigPath,
 		"--authorization-kubeconfig=" + op.ControllerManagerKubeConfigPath,
@@ -135,6 +139,10 @@ func ControllerManagerParams(clusterName, serviceSubnet string) cke.ServiceParam
 		"--service-account-private-key-file=" + op.K8sPKIPath("service-account.key"),
 		"--use-service-account-credentials=true",
 	}
+	if len(featureGates) > 0 {
+		args = append(args, "--feature-gates="+cke.FeatureGatesArg(featureGates))
+	}
+	args = append(args, params.ConfigArguments()...)
 	return cke.ServiceParams{
 		ExtraArguments: args,
 		ExtraBinds: []cke.Mount{
@@ -9,21 +9,23 @@ import (
 type controllerManagerRestartOp struct {
 	nodes []*cke.Node
 
-	cluster       string
-	serviceSubnet string
-	params        cke.ServiceParams
+	cluster        string
+	serviceSubnets []string
+	featureGates   map[string]bool
+	params         cke.ControllerManagerParams
 
 	pulled   bool
 	finished bool
 }
 
 // ControllerManagerRestartOp returns an Operator to restart kube-controller-manager
-func ControllerManagerRestartOp(nodes []*cke.Node, cluster, serviceSubnet string, params cke.ServiceParams) cke.Operator {
+func ControllerManagerRestartOp(nodes []*cke.Node, cluster string, serviceSubnets []string, featureGates map[string]bool, params cke.ControllerManagerParams) cke.Operator {
 	return &controllerManagerRestartOp{
-		nodes:         nodes,
-		cluster:       cluster,
-		serviceSubnet: serviceSubnet,
-		params:        params,
+		nodes:          nodes,
+		cluster:        cluster,
+		serviceSubnets: serviceSubnets,
+		featureGates:   featureGates,
+		params:         params,
 	}
 }
 
@@ -40,8 +42,8 @@ func (o *controllerManagerRestartOp) NextCommand() cke.Commander {
 	if !o.finished {
 		o.finished = true
 		return common.RunContainerCommand(o.nodes, op.KubeControllerManagerContainerName, cke.KubernetesImage,
-			common.WithParams(ControllerManagerParams(o.cluster, o.serviceSubnet)),
-			common.WithExtra(o.params),
+			common.WithParams(ControllerManagerParams(o.cluster, o.serviceSubnets, o.featureGates, o.params)),
+			common.WithExtra(o.params.ServiceParams),
 			common.WithRestart())
 	}
 	return nil
@@ -2,60 +2,133 @@ package k8s
 
 import (
 	"context"
+	"crypto/md5"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
 
 	"github.com/cybozu-go/cke"
 )
 
 const (
-	encryptionConfigDir  = "/etc/kubernetes/apiserver"
-	encryptionConfigFile = encryptionConfigDir + "/encryption.yml"
+	encryptionConfigDir      = "/etc/kubernetes/apiserver"
+	encryptionConfigFile     = encryptionConfigDir + "/encryption.yml"
+	encryptionConfigBasePath = encryptionConfigDir + "/encryption-%x.yml"
 )
 
-func getEncryptionSecret(ctx context.Context, inf cke.Infrastructure, key string) (string, error) {
-	vc, err := inf.Vault()
-	if err != nil {
-		return "", err
+// encryptionConfigFilePath returns the path of EncryptionConfiguration.
+//
+// Before the first key rotation, the keys are not recorded in etcd and the path is fixed.
+// Otherwise, the path changes with the key names and the resources so that API servers
+// are restarted when the keys are rotated.  The key materials are not used for the path.
+func encryptionConfigFilePath(st *cke.EncryptionStatus, params cke.APIServerParams) string {
+	resources := params.GetEncryptionResources()
+	if st == nil && slices.Equal(resources, cke.DefaultEncryptionResources) {
+		return encryptionConfigFile
 	}
 
-	secret, err := vc.Logical().Read(cke.K8sSecret)
-	if err != nil {
-		return "", err
+	var keys []cke.EncryptionKeyInfo
+	var kmsTimeout int
+	if st != nil {
+		keys = st.Keys
 	}
-	if secret == nil {
-		return "", errors.New("no encryption secrets for API server")
-	}
-
-	data, ok := secret.Data[key]
-	if !ok {
-		return "", errors.New("no secret data for " + key)
+	if st.UsesKMS() {
+		kmsTimeout = params.KMS.GetTimeoutSeconds()
 	}
-	return data.(string), nil
+	// keys and resources consist of strings, so encoding them never fails.
+	data, _ := json.Marshal(struct {
+		Keys       []cke.EncryptionKeyInfo `json:"keys"`
+		Resources  []string                `json:"resources"`
+		KMSTimeout int                     `json:"kms_timeout,omitempty"`
+	}{keys, resources, kmsTimeout})
+	return fmt.Sprintf(encryptionConfigBasePath, md5.Sum(data))
 }
 
-func getEncryptionConfiguration(ctx context.Context, inf cke.Infrastructure) (*apiserverv1.EncryptionConfiguration, error) {
-	data, err := getEncryptionSecret(ctx, inf, "aescbc")
+func getEncryptionConfiguration(ctx context.Context, inf cke.Infrastructure, st *cke.EncryptionStatus, params cke.APIServerParams) (*apiserverv1.EncryptionConfiguration, error) {
+	vc, err := inf.Vault()
 	if err != nil {
 		return nil, err
 	}
-
-	aescfg := new(apiserverv1.AESConfiguration)
-	err = json.Unmarshal([]byte(data), aescfg)
+	secrets, err := cke.ReadEncryptionKeySecrets(vc)
 	if err != nil {
 		return nil, err
 	}
 
+	keys := secrets.LegacyKeys()
+	if st != nil {
+		keys = st.Keys
+	}
+	return generateEncryptionConfiguration(keys, secrets, params)
+}
+
+// generateEncryptionConfiguration generates EncryptionConfiguration.
+// Consecutive keys of the same provider are grouped into one provider
+// to keep the order of the keys.  Each KMS key is a provider by itself.
+// The identity provider comes last to read data that has not been encrypted yet.
+func generateEncryptionConfiguration(keys []cke.EncryptionKeyInfo, secrets cke.EncryptionKeySecrets, params cke.APIServerParams) (*apiserverv1.EncryptionConfiguration, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no encryption keys")
+	}
+
+	var providers []apiserverv1.ProviderConfiguration
+	var last string
+	for _, k := range keys {
+		if k.Provider == cke.EncryptionProviderKMS {
+			providers = append(providers, apiserverv1.ProviderConfiguration{
+				KMS: &apiserverv1.KMSConfiguration{
+					APIVersion: "v2",
+					Name:       k.Name,
+					Endpoint:   "unix://" + kmsSocketPath,
+					Timeout:    &metav1.Duration{Duration: time.Duration(params.KMS.GetTimeoutSeconds()) * time.Second},
+				},
+			})
+			last = k.Provider
+			continue
+		}
+
+		key, err := secrets.Find(k)
+		if err != nil {
+			return nil, err
+		}
+
+		if k.Provider != last {
+			var p apiserverv1.ProviderConfiguration
+			switch k.Provider {
+			case cke.EncryptionProviderAESCBC:
+				p.AESCBC = &apiserverv1.AESConfiguration{}
+			case cke.EncryptionProviderAESGCM:
+				p.AESGCM = &apiserverv1.AESConfiguration{}
+			case cke.EncryptionProviderSecretbox:
+				p.Secretbox = &apiserverv1.SecretboxConfiguration{}
+			default:
+				return nil, fmt.Errorf("unsupported encryption provider: %s", k.Provider)
+			}
+			providers = append(providers, p)
+			last = k.Provider
+		}
+
+		p := &providers[len(providers)-1]
+		switch {
+		case p.AESCBC != nil:
+			p.AESCBC.Keys = append(p.AESCBC.Keys, key)
+		case p.AESGCM != nil:
+			p.AESGCM.Keys = append(p.AESGCM.Keys, key)
+		case p.Secretbox != nil:
+			p.Secretbox.Keys = append(p.Secretbox.Keys, key)
+		}
+	}
+	providers = append(providers, apiserverv1.ProviderConfiguration{Identity: &apiserverv1.IdentityConfiguration{}})
+
 	return &apiserverv1.EncryptionConfiguration{
 		Resources: []apiserverv1.ResourceConfiguration{
 			{
-				Resources: []string{"secrets"},
-				Providers: []apiserverv1.ProviderConfiguration{
-					{AESCBC: aescfg},
-					{Identity: &apiserverv1.IdentityConfiguration{}},
-				},
+				Resources: params.GetEncryptionResources(),
+				Providers: providers,
 			},
 		},
 	}, nil
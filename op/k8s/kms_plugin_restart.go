@@ -0,0 +1,142 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cybozu-go/cke"
+	"github.com/cybozu-go/cke/op"
+	"github.com/cybozu-go/cke/op/common"
+)
+
+const (
+	kmsSocketDir       = "/run/cke-kms"
+	kmsSocketPath      = kmsSocketDir + "/kms.sock"
+	kmsConfigDir       = "/etc/kubernetes/kms"
+	kmsVaultConfigPath = kmsConfigDir + "/vault.json"
+)
+
+type kmsPluginRestartOp struct {
+	nodes  []*cke.Node
+	params *cke.KMSParams
+
+	step  int
+	files *common.FilesBuilder
+}
+
+// KMSPluginRestartOp returns an Operator to start or restart the KMS plugin.
+// params may be nil if the plugin is kept running only to decrypt data.
+func KMSPluginRestartOp(nodes []*cke.Node, params *cke.KMSParams) cke.Operator {
+	return &kmsPluginRestartOp{
+		nodes:  nodes,
+		params: params,
+		files:  common.NewFilesBuilder(nodes),
+	}
+}
+
+func (o *kmsPluginRestartOp) Name() string {
+	return "kms-plugin-restart"
+}
+
+func (o *kmsPluginRestartOp) NextCommand() cke.Commander {
+	switch o.step {
+	case 0:
+		o.step++
+		return common.ImagePullCommand(o.nodes, cke.ToolsImage)
+	case 1:
+		o.step++
+		return common.MakeDirsCommandWithMode(o.nodes, []string{kmsSocketDir, kmsConfigDir}, "700")
+	case 2:
+		o.step++
+		return prepareKMSPluginFilesCommand{o.files}
+	case 3:
+		o.step++
+		return o.files
+	case 4:
+		o.step++
+		var extra cke.ServiceParams
+		if o.params != nil {
+			extra = o.params.ServiceParams
+		}
+		return common.RunContainerCommand(o.nodes, op.KMSPluginContainerName, cke.ToolsImage,
+			common.WithParams(KMSPluginParams()),
+			common.WithExtra(extra),
+			common.WithRestart())
+	default:
+		return nil
+	}
+}
+
+func (o *kmsPluginRestartOp) Targets() []string {
+	ips := make([]string, len(o.nodes))
+	for i, n := range o.nodes {
+		ips[i] = n.Address
+	}
+	return ips
+}
+
+type prepareKMSPluginFilesCommand struct {
+	files *common.FilesBuilder
+}
+
+func (c prepareKMSPluginFilesCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
+	cfg, err := inf.Storage().GetVaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+	vc, err := inf.Vault()
+	if err != nil {
+		return err
+	}
+	cred, err := cke.ReadKMSCredentials(vc)
+	if err != nil {
+		return err
+	}
+
+	// the plugin logs in to Vault with its own AppRole, not with that of CKE.
+	data, err := json.Marshal(&cke.VaultConfig{
+		Endpoint: cfg.Endpoint,
+		CACert:   cfg.CACert,
+		RoleID:   cred.RoleID,
+		SecretID: cred.SecretID,
+	})
+	if err != nil {
+		return err
+	}
+	return c.files.AddFile(ctx, kmsVaultConfigPath, func(context.Context, *cke.Node) ([]byte, error) {
+		return data, nil
+	})
+}
+
+func (c prepareKMSPluginFilesCommand) Command() cke.Command {
+	return cke.Command{
+		Name: "prepare-kms-plugin-files",
+	}
+}
+
+// KMSPluginParams returns parameters for the KMS plugin.
+func KMSPluginParams() cke.ServiceParams {
+	return cke.ServiceParams{
+		ExtraArguments: []string{
+			"vault-kms",
+			"--listen=" + kmsSocketPath,
+			"--vault-config=" + kmsVaultConfigPath,
+			"--transit-path=" + cke.KMSTransitPath,
+			"--key=" + cke.KMSTransitKey,
+		},
+		ExtraBinds: []cke.Mount{
+			{
+				Source:      kmsSocketDir,
+				Destination: kmsSocketDir,
+				ReadOnly:    false,
+				Label:       cke.LabelShared,
+			},
+			{
+				Source:      kmsConfigDir,
+				Destination: kmsConfigDir,
+				ReadOnly:    true,
+				Label:       cke.LabelShared,
+			},
+		},
+	}
+}
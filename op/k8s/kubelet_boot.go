@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/cybozu-go/well"
@@ -365,6 +366,10 @@ func KubeletServiceParams(n *cke.Node, params cke.KubeletParams) cke.ServicePara
 		"--kubeconfig=/etc/kubernetes/kubelet/kubeconfig",
 		"--hostname-override=" + n.Nodename(),
 	}
+	if n.SecondaryAddress != "" {
+		// kubelet registers the addresses of both IP families for dual-stack.
+		args = append(args, "--node-ip="+strings.Join(n.Addresses(), ","))
+	}
 	if len(params.CRIEndpoint) != 0 {
 		args = append(args, "--container-runtime-endpoint="+params.CRIEndpoint)
 	}
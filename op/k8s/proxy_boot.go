@@ -16,6 +16,14 @@ const (
 	proxyConfigPath     = "/etc/kubernetes/proxy/config.yml"
 )
 
+// proxyRunOpts are the options to run kube-proxy.
+// All the proxy modes need privileges to manipulate the rules in the kernel.
+// /run is writable for the lock file of iptables.
+var proxyRunOpts = []string{
+	"--tmpfs=/run",
+	"--privileged",
+}
+
 type kubeProxyBootOp struct {
 	nodes []*cke.Node
 
@@ -55,17 +63,13 @@ func (o *kubeProxyBootOp) NextCommand() cke.Commander {
 		return o.files
 	case 3:
 		o.step++
-		opts := []string{
-			"--tmpfs=/run",
-			"--privileged",
-		}
 		paramsMap := make(map[string]cke.ServiceParams)
 		for _, n := range o.nodes {
 			params := ProxyParams()
 			paramsMap[n.Address] = params
 		}
 		return common.RunContainerCommand(o.nodes, op.KubeProxyContainerName, cke.KubernetesImage,
-			common.WithOpts(opts),
+			common.WithOpts(proxyRunOpts),
 			common.WithParamsMap(paramsMap),
 			common.WithExtra(o.params.ServiceParams))
 	default:
@@ -119,6 +123,15 @@ func (c prepareProxyFilesCommand) Command() cke.Command {
 	}
 }
 
+// proxyModulesMount is to load kernel modules for IPVS and nftables.
+var proxyModulesMount = cke.Mount{
+	Source:      "/lib/modules",
+	Destination: "/lib/modules",
+	ReadOnly:    true,
+	Propagation: "",
+	Label:       "",
+}
+
 // ProxyParams returns parameters for kube-proxy.
 func ProxyParams() cke.ServiceParams {
 	args := []string{
@@ -142,13 +155,7 @@ func ProxyParams() cke.ServiceParams {
 				Propagation: "",
 				Label:       cke.LabelShared,
 			},
-			{
-				Source:      "/lib/modules",
-				Destination: "/lib/modules",
-				ReadOnly:    true,
-				Propagation: "",
-				Label:       "",
-			},
+			proxyModulesMount,
 		},
 	}
 }
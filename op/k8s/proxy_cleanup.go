@@ -0,0 +1,105 @@
+package k8s
+
+import (
+	"context"
+
+	"github.com/cybozu-go/well"
+
+	"github.com/cybozu-go/cke"
+	"github.com/cybozu-go/cke/op"
+	"github.com/cybozu-go/cke/op/common"
+)
+
+type kubeProxySwitchModeOp struct {
+	nodes []*cke.Node
+
+	cluster string
+	ap      string
+	params  cke.ProxyParams
+
+	step  int
+	files *common.FilesBuilder
+}
+
+// KubeProxySwitchModeOp returns an Operator to restart kube-proxy in a different proxy mode.
+// The rules of the old mode are cleaned up while kube-proxy is stopped.
+func KubeProxySwitchModeOp(nodes []*cke.Node, cluster, ap string, params cke.ProxyParams) cke.Operator {
+	return &kubeProxySwitchModeOp{
+		nodes:   nodes,
+		cluster: cluster,
+		ap:      ap,
+		params:  params,
+		files:   common.NewFilesBuilder(nodes),
+	}
+}
+
+func (o *kubeProxySwitchModeOp) Name() string {
+	return "kube-proxy-switch-mode"
+}
+
+func (o *kubeProxySwitchModeOp) NextCommand() cke.Commander {
+	switch o.step {
+	case 0:
+		o.step++
+		return common.ImagePullCommand(o.nodes, cke.KubernetesImage)
+	case 1:
+		o.step++
+		return prepareProxyFilesCommand{cluster: o.cluster, ap: o.ap, files: o.files, params: o.params}
+	case 2:
+		o.step++
+		return o.files
+	case 3:
+		o.step++
+		return common.StopContainersCommand(o.nodes, op.KubeProxyContainerName)
+	case 4:
+		o.step++
+		return proxyCleanupCommand{o.nodes}
+	case 5:
+		o.step++
+		paramsMap := make(map[string]cke.ServiceParams)
+		for _, n := range o.nodes {
+			params := ProxyParams()
+			paramsMap[n.Address] = params
+		}
+		return common.RunContainerCommand(o.nodes, op.KubeProxyContainerName, cke.KubernetesImage,
+			common.WithOpts(proxyRunOpts),
+			common.WithParamsMap(paramsMap),
+			common.WithExtra(o.params.ServiceParams))
+	default:
+		return nil
+	}
+}
+
+func (o *kubeProxySwitchModeOp) Targets() []string {
+	ips := make([]string, len(o.nodes))
+	for i, n := range o.nodes {
+		ips[i] = n.Address
+	}
+	return ips
+}
+
+type proxyCleanupCommand struct {
+	nodes []*cke.Node
+}
+
+// Run removes the rules of all the proxy modes, i.e. iptables chains,
+// IPVS virtual servers, and nftables tables, created by kube-proxy.
+// kube-proxy must be stopped beforehand.
+func (c proxyCleanupCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
+	env := well.NewEnvironment(ctx)
+	for _, n := range c.nodes {
+		ce := inf.Engine(n.Address)
+		env.Go(func(ctx context.Context) error {
+			return ce.RunPrivileged(cke.KubernetesImage, []cke.Mount{proxyModulesMount}, "kube-proxy", "--cleanup")
+		})
+	}
+	env.Stop()
+	return env.Wait()
+}
+
+func (c proxyCleanupCommand) Command() cke.Command {
+	return cke.Command{
+		Name:   "cleanup-proxy-rules",
+		Target: op.KubeProxyContainerName,
+	}
+}
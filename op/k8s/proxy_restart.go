@@ -45,17 +45,13 @@ func (o *kubeProxyRestartOp) NextCommand() cke.Commander {
 		return o.files
 	case 3:
 		o.step++
-		opts := []string{
-			"--tmpfs=/run",
-			"--privileged",
-		}
 		paramsMap := make(map[string]cke.ServiceParams)
 		for _, n := range o.nodes {
 			params := ProxyParams()
 			paramsMap[n.Address] = params
 		}
 		return common.RunContainerCommand(o.nodes, op.KubeProxyContainerName, cke.KubernetesImage,
-			common.WithOpts(opts),
+			common.WithOpts(proxyRunOpts),
 			common.WithParamsMap(paramsMap),
 			common.WithExtra(o.params.ServiceParams),
 			common.WithRestart())
@@ -13,20 +13,22 @@ import (
 type schedulerBootOp struct {
 	nodes []*cke.Node
 
-	cluster string
-	params  cke.SchedulerParams
+	cluster      string
+	featureGates map[string]bool
+	params       cke.SchedulerParams
 
 	step  int
 	files *common.FilesBuilder
 }
 
 // SchedulerBootOp returns an Operator to bootstrap kube-scheduler
-func SchedulerBootOp(nodes []*cke.Node, cluster string, params cke.SchedulerParams) cke.Operator {
+func SchedulerBootOp(nodes []*cke.Node, cluster string, featureGates map[string]bool, params cke.SchedulerParams) cke.Operator {
 	return &schedulerBootOp{
-		nodes:   nodes,
-		cluster: cluster,
-		params:  params,
-		files:   common.NewFilesBuilder(nodes),
+		nodes:        nodes,
+		cluster:      cluster,
+		featureGates: featureGates,
+		params:       params,
+		files:        common.NewFilesBuilder(nodes),
 	}
 }
 
@@ -48,7 +50,7 @@ func (o *schedulerBootOp) NextCommand() cke.Commander {
 	case 3:
 		o.step++
 		return common.RunContainerCommand(o.nodes, op.KubeSchedulerContainerName, cke.KubernetesImage,
-			common.WithParams(SchedulerParams()),
+			common.WithParams(SchedulerParams(o.featureGates)),
 			common.WithExtra(o.params.ServiceParams))
 	default:
 		return nil
@@ -102,7 +104,7 @@ func (c prepareSchedulerFilesCommand) Command() cke.Command {
 }
 
 // SchedulerParams returns parameters for kube-scheduler.
-func SchedulerParams() cke.ServiceParams {
+func SchedulerParams(featureGates map[string]bool) cke.ServiceParams {
 	args := []string{
 		"kube-scheduler",
 		"--config=" + op.SchedulerConfigPath,
@@ -112,6 +114,9 @@ func SchedulerParams() cke.ServiceParams {
 		"--tls-cert-file=" + op.K8sPKIPath("apiserver.crt"),
 		"--tls-private-key-file=" + op.K8sPKIPath("apiserver.key"),
 	}
+	if len(featureGates) > 0 {
+		args = append(args, "--feature-gates="+cke.FeatureGatesArg(featureGates))
+	}
 	return cke.ServiceParams{
 		ExtraArguments: args,
 		ExtraBinds: []cke.Mount{
@@ -9,20 +9,22 @@ import (
 type schedulerRestartOp struct {
 	nodes []*cke.Node
 
-	cluster string
-	params  cke.SchedulerParams
+	cluster      string
+	featureGates map[string]bool
+	params       cke.SchedulerParams
 
 	step  int
 	files *common.FilesBuilder
 }
 
 // SchedulerRestartOp returns an Operator to restart kube-scheduler
-func SchedulerRestartOp(nodes []*cke.Node, cluster string, params cke.SchedulerParams) cke.Operator {
+func SchedulerRestartOp(nodes []*cke.Node, cluster string, featureGates map[string]bool, params cke.SchedulerParams) cke.Operator {
 	return &schedulerRestartOp{
-		nodes:   nodes,
-		cluster: cluster,
-		params:  params,
-		files:   common.NewFilesBuilder(nodes),
+		nodes:        nodes,
+		cluster:      cluster,
+		featureGates: featureGates,
+		params:       params,
+		files:        common.NewFilesBuilder(nodes),
 	}
 }
 
@@ -44,7 +46,7 @@ func (o *schedulerRestartOp) NextCommand() cke.Commander {
 	case 3:
 		o.step++
 		return common.RunContainerCommand(o.nodes, op.KubeSchedulerContainerName, cke.KubernetesImage,
-			common.WithParams(SchedulerParams()),
+			common.WithParams(SchedulerParams(o.featureGates)),
 			common.WithExtra(o.params.ServiceParams),
 			common.WithRestart())
 	default:
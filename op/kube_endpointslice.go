@@ -10,6 +10,15 @@ import (
 	"github.com/cybozu-go/cke"
 )
 
+// EndpointSliceName returns the name of EndpointSlice for addresses of the type.
+// EndpointSlices for IPv4 addresses have the same name as Endpoints for compatibility.
+func EndpointSliceName(name string, addressType discoveryv1.AddressType) string {
+	if addressType == discoveryv1.AddressTypeIPv4 {
+		return name
+	}
+	return name + "-" + strings.ToLower(string(addressType))
+}
+
 type kubeEndpointSliceCreateOp struct {
 	apiserver     *cke.Node
 	endpointslice *discoveryv1.EndpointSlice
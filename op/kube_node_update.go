@@ -58,6 +58,7 @@ func (c nodeUpdateCommand) Run(ctx context.Context, inf cke.Infrastructure, _ st
 		if err != nil {
 			return err
 		}
+		recordNodeEvent(ctx, cs, n.Name, corev1.EventTypeNormal, EventReasonNodeUpdated, "Labels, annotations and taints are updated by CKE")
 	}
 
 	return nil
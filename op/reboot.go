@@ -10,11 +10,13 @@ import (
 
 	"github.com/cybozu-go/log"
 	"github.com/cybozu-go/well"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/cybozu-go/cke"
+	"github.com/cybozu-go/cke/op/common"
 )
 
 const (
@@ -148,6 +150,7 @@ func (c rebootDrainStartCommand) Run(ctx context.Context, inf cke.Infrastructure
 			if err != nil {
 				return fmt.Errorf("failed to cordon node %s: %v", entry.Node, err)
 			}
+			recordNodeEvent(ctx, cs, entry.Node, corev1.EventTypeNormal, EventReasonCordoned, "Node is cordoned by CKE for reboot")
 			log.Info("start eviction dry-run", map[string]any{
 				"name": entry.Node,
 			})
@@ -166,6 +169,7 @@ func (c rebootDrainStartCommand) Run(ctx context.Context, inf cke.Infrastructure
 		}()
 		if err != nil {
 			c.notifyFailedNode(entry.Node)
+			recordNodeEvent(ctx, cs, entry.Node, corev1.EventTypeWarning, EventReasonDrainFailed, "Failed to drain node for reboot: "+err.Error())
 			err = drainBackOff(ctx, inf, entry, err)
 			if err != nil {
 				return err
@@ -180,6 +184,7 @@ func (c rebootDrainStartCommand) Run(ctx context.Context, inf cke.Infrastructure
 		log.Info("start eviction", map[string]any{
 			"name": entry.Node,
 		})
+		recordNodeEvent(ctx, cs, entry.Node, corev1.EventTypeNormal, EventReasonDraining, "Evicting pods for reboot")
 		err := evictOrDeleteNodePod(ctx, cs, entry.Node, protected, c.protectedJobPods, c.evictAttempts, c.evictInterval)
 		if err != nil {
 			log.Warn("eviction failed", map[string]any{
@@ -187,6 +192,7 @@ func (c rebootDrainStartCommand) Run(ctx context.Context, inf cke.Infrastructure
 				log.FnError: err,
 			})
 			c.notifyFailedNode(entry.Node)
+			recordNodeEvent(ctx, cs, entry.Node, corev1.EventTypeWarning, EventReasonDrainFailed, "Failed to evict pods for reboot: "+err.Error())
 			err = drainBackOff(ctx, inf, entry, err)
 			if err != nil {
 				return err
@@ -308,8 +314,9 @@ func (c rebootDeleteDaemonSetPodCommand) Run(ctx context.Context, inf cke.Infras
 type rebootRebootOp struct {
 	finished bool
 
-	entries []*cke.RebootQueueEntry
-	config  *cke.Reboot
+	entries   []*cke.RebootQueueEntry
+	config    *cke.Reboot
+	apiserver *cke.Node
 
 	mu          sync.Mutex
 	failedNodes []string
@@ -317,12 +324,14 @@ type rebootRebootOp struct {
 
 type rebootRebootCommand struct {
 	entries        []*cke.RebootQueueEntry
+	apiserver      *cke.Node
 	command        []string
 	timeoutSeconds *int
 	retries        *int
 	interval       *int
 
 	notifyFailedNode func(string)
+	*common.CommandOutput
 }
 
 func (o *rebootRebootOp) notifyFailedNode(node string) {
@@ -334,8 +343,9 @@ func (o *rebootRebootOp) notifyFailedNode(node string) {
 // RebootRebootOp returns an Operator to reboot nodes.
 func RebootRebootOp(apiserver *cke.Node, entries []*cke.RebootQueueEntry, config *cke.Reboot) cke.InfoOperator {
 	return &rebootRebootOp{
-		entries: entries,
-		config:  config,
+		entries:   entries,
+		config:    config,
+		apiserver: apiserver,
 	}
 }
 
@@ -351,11 +361,13 @@ func (o *rebootRebootOp) NextCommand() cke.Commander {
 
 	return rebootRebootCommand{
 		entries:          o.entries,
+		apiserver:        o.apiserver,
 		command:          o.config.RebootCommand,
 		timeoutSeconds:   o.config.CommandTimeoutSeconds,
 		retries:          o.config.CommandRetries,
 		interval:         o.config.CommandInterval,
 		notifyFailedNode: o.notifyFailedNode,
+		CommandOutput:    new(common.CommandOutput),
 	}
 }
 
@@ -377,6 +389,20 @@ func (o *rebootRebootOp) Info() string {
 func (c rebootRebootCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
 	var mu sync.Mutex
 
+	// Events are informational, so failing to get the client must not stop rebooting.
+	// cs is left nil on error as the returned client may be a typed nil.
+	var cs kubernetes.Interface
+	if c.apiserver != nil {
+		client, err := inf.K8sClient(ctx, c.apiserver)
+		if err != nil {
+			log.Warn("failed to get Kubernetes client to record events", map[string]any{
+				log.FnError: err,
+			})
+		} else {
+			cs = client
+		}
+	}
+
 	env := well.NewEnvironment(ctx)
 	for _, entry := range c.entries {
 		entry := entry // save loop variable for goroutine
@@ -393,6 +419,8 @@ func (c rebootRebootCommand) Run(ctx context.Context, inf cke.Infrastructure, _
 			inf.ReleaseAgent(entry.Node)
 			mu.Unlock()
 
+			recordNodeEvent(ctx, cs, entry.Node, corev1.EventTypeNormal, EventReasonRebooting, "Rebooting node by CKE")
+
 			attempts := 1
 			if c.retries != nil {
 				attempts = *c.retries + 1
@@ -403,7 +431,8 @@ func (c rebootRebootCommand) Run(ctx context.Context, inf cke.Infrastructure, _
 				if c.timeoutSeconds != nil {
 					timeout = *c.timeoutSeconds
 				}
-				_, err := runCommand(ctx, timeout, append(c.command, entry.Node))
+				stdout, stderr, err := runCommand(ctx, timeout, append(c.command, entry.Node))
+				c.Add(entry.Node, stdout, stderr)
 				if err == nil {
 					return nil
 				}
@@ -422,6 +451,7 @@ func (c rebootRebootCommand) Run(ctx context.Context, inf cke.Infrastructure, _
 				}
 			}
 			c.notifyFailedNode(entry.Node)
+			recordNodeEvent(ctx, cs, entry.Node, corev1.EventTypeWarning, EventReasonRebootFailed, "Gave up rebooting node")
 			log.Warn("given up rebooting node", map[string]any{
 				"node": entry.Node,
 			})
@@ -564,6 +594,7 @@ func (c rebootUncordonCommand) Run(ctx context.Context, inf cke.Infrastructure,
 		if err != nil {
 			return fmt.Errorf("failed to uncordon node %s: %v", name, err)
 		}
+		recordNodeEvent(ctx, cs, name, corev1.EventTypeNormal, EventReasonUncordoned, "Node is uncordoned by CKE after reboot")
 	}
 	return nil
 }
@@ -405,7 +405,7 @@ func rebootCompleted(ctx context.Context, c *cke.Cluster, entry *cke.RebootQueue
 		timeout = *c.Reboot.CommandTimeoutSeconds
 	}
 
-	stdout, err := runCommand(ctx, timeout, append(c.Reboot.BootCheckCommand, entry.Node, strconv.FormatInt(entry.LastTransitionTime.Unix(), 10)))
+	stdout, _, err := runCommand(ctx, timeout, append(c.Reboot.BootCheckCommand, entry.Node, strconv.FormatInt(entry.LastTransitionTime.Unix(), 10)))
 	if err != nil {
 		log.Warn("failed to check boot", map[string]any{
 			log.FnError: err,
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/cybozu-go/log"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -116,22 +117,26 @@ func (c repairDrainStartCommand) Run(ctx context.Context, inf cke.Infrastructure
 		if err != nil {
 			return fmt.Errorf("failed to cordon node %s: %v", c.entry.Address, err)
 		}
+		recordNodeEvent(ctx, cs, c.entry.Nodename, corev1.EventTypeNormal, EventReasonCordoned, "Node is cordoned by CKE for repair")
 
 		return nil
 	}()
 	if err != nil {
+		recordNodeEvent(ctx, cs, c.entry.Nodename, corev1.EventTypeWarning, EventReasonDrainFailed, "Failed to drain node for repair: "+err.Error())
 		return repairDrainBackOff(ctx, inf, c.entry, err)
 	}
 
 	log.Info("start eviction", map[string]any{
 		"address": c.entry.Address,
 	})
+	recordNodeEvent(ctx, cs, c.entry.Nodename, corev1.EventTypeNormal, EventReasonDraining, "Evicting pods for repair")
 	err = evictOrDeleteNodePod(ctx, cs, c.entry.Nodename, protected, c.protectedJobPods, c.evictAttempts, c.evictInterval)
 	if err != nil {
 		log.Warn("eviction failed", map[string]any{
 			"address":   c.entry.Address,
 			log.FnError: err,
 		})
+		recordNodeEvent(ctx, cs, c.entry.Nodename, corev1.EventTypeWarning, EventReasonDrainFailed, "Failed to evict pods for repair: "+err.Error())
 		return repairDrainBackOff(ctx, inf, c.entry, err)
 	}
 	log.Info("eviction succeeded", map[string]any{
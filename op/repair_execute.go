@@ -6,23 +6,28 @@ import (
 	"time"
 
 	"github.com/cybozu-go/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/cybozu-go/cke"
+	"github.com/cybozu-go/cke/op/common"
 )
 
 type repairExecuteOp struct {
 	finished bool
 
-	entry   *cke.RepairQueueEntry
-	step    *cke.RepairStep
-	cluster *cke.Cluster
+	apiserver *cke.Node
+	entry     *cke.RepairQueueEntry
+	step      *cke.RepairStep
+	cluster   *cke.Cluster
 }
 
-func RepairExecuteOp(entry *cke.RepairQueueEntry, step *cke.RepairStep, cluster *cke.Cluster) cke.Operator {
+func RepairExecuteOp(apiserver *cke.Node, entry *cke.RepairQueueEntry, step *cke.RepairStep, cluster *cke.Cluster) cke.Operator {
 	return &repairExecuteOp{
-		entry:   entry,
-		step:    step,
-		cluster: cluster,
+		apiserver: apiserver,
+		entry:     entry,
+		step:      step,
+		cluster:   cluster,
 	}
 }
 
@@ -37,12 +42,14 @@ func (o *repairExecuteOp) NextCommand() cke.Commander {
 	o.finished = true
 
 	return repairExecuteCommand{
+		apiserver:      o.apiserver,
 		entry:          o.entry,
 		command:        o.step.RepairCommand,
 		timeoutSeconds: o.step.CommandTimeoutSeconds,
 		retries:        o.step.CommandRetries,
 		interval:       o.step.CommandInterval,
 		cluster:        o.cluster,
+		CommandOutput:  new(common.CommandOutput),
 	}
 }
 
@@ -51,12 +58,14 @@ func (o *repairExecuteOp) Targets() []string {
 }
 
 type repairExecuteCommand struct {
+	apiserver      *cke.Node
 	entry          *cke.RepairQueueEntry
 	command        []string
 	timeoutSeconds *int
 	retries        *int
 	interval       *int
 	cluster        *cke.Cluster
+	*common.CommandOutput
 }
 
 func (c repairExecuteCommand) Run(ctx context.Context, inf cke.Infrastructure, _ string) error {
@@ -68,6 +77,20 @@ func (c repairExecuteCommand) Run(ctx context.Context, inf cke.Infrastructure, _
 		return err
 	}
 
+	// Only machines registered as Nodes can have Events.
+	var cs kubernetes.Interface
+	if c.entry.IsInCluster() && c.apiserver != nil {
+		client, err := inf.K8sClient(ctx, c.apiserver)
+		if err != nil {
+			log.Warn("failed to get Kubernetes client to record events", map[string]any{
+				log.FnError: err,
+			})
+		} else {
+			cs = client
+		}
+	}
+	recordNodeEvent(ctx, cs, c.entry.Nodename, corev1.EventTypeNormal, EventReasonRepairing, "Executing repair command: "+strings.Join(c.command, " "))
+
 	attempts := 1
 	if c.retries != nil {
 		attempts = *c.retries + 1
@@ -78,7 +101,8 @@ RETRY:
 		if c.timeoutSeconds != nil {
 			timeout = *c.timeoutSeconds
 		}
-		_, err := runCommand(ctx, timeout, append(c.command, c.entry.Address))
+		stdout, stderr, err := runCommand(ctx, timeout, append(c.command, c.entry.Address))
+		c.Add(c.entry.Address, stdout, stderr)
 		if err == nil {
 			return nil
 		}
@@ -100,6 +124,7 @@ RETRY:
 	}
 
 	// The failure of a repair command should not be considered as a serious error of CKE.
+	recordNodeEvent(ctx, cs, c.entry.Nodename, corev1.EventTypeWarning, EventReasonRepairFailed, "Gave up executing repair command: "+strings.Join(c.command, " "))
 	log.Warn("given up repairing machine", map[string]any{
 		"index":   c.entry.Index,
 		"address": c.entry.Address,
@@ -79,7 +79,7 @@ func repairFinish(ctx context.Context, inf cke.Infrastructure, entry *cke.Repair
 			if op.SuccessCommandTimeout != nil {
 				timeout = *op.SuccessCommandTimeout
 			}
-			_, err = runCommand(ctx, timeout, append(op.SuccessCommand, entry.Address))
+			_, _, err = runCommand(ctx, timeout, append(op.SuccessCommand, entry.Address))
 			return err
 		}()
 		if err != nil {
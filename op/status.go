@@ -14,6 +14,7 @@ import (
 	"github.com/cybozu-go/log"
 	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -47,6 +48,7 @@ func GetNodeStatus(ctx context.Context, inf cke.Infrastructure, node *cke.Node,
 		EtcdContainerName,
 		RiversContainerName,
 		EtcdRiversContainerName,
+		KMSPluginContainerName,
 		KubeAPIServerContainerName,
 		KubeControllerManagerContainerName,
 		KubeSchedulerContainerName,
@@ -74,6 +76,7 @@ func GetNodeStatus(ctx context.Context, inf cke.Infrastructure, node *cke.Node,
 	}
 	status.Rivers = ss[RiversContainerName]
 	status.EtcdRivers = ss[EtcdRiversContainerName]
+	status.KMSPlugin = ss[KMSPluginContainerName]
 
 	status.APIServer = cke.KubeComponentStatus{
 		ServiceStatus: ss[KubeAPIServerContainerName],
@@ -358,6 +361,15 @@ func GetKubernetesClusterStatus(ctx context.Context, inf cke.Infrastructure, n *
 		return cke.KubernetesClusterStatus{}, err
 	}
 
+	eps, err = epsAPI(metav1.NamespaceDefault).Get(ctx, EndpointSliceName("kubernetes", discoveryv1.AddressTypeIPv6), metav1.GetOptions{})
+	switch {
+	case err == nil:
+		s.MasterEndpointSliceIPv6 = eps
+	case k8serr.IsNotFound(err):
+	default:
+		return cke.KubernetesClusterStatus{}, err
+	}
+
 	svc, err := clientset.CoreV1().Services(metav1.NamespaceSystem).Get(ctx, EtcdServiceName, metav1.GetOptions{})
 	switch {
 	case err == nil:
@@ -385,6 +397,15 @@ func GetKubernetesClusterStatus(ctx context.Context, inf cke.Infrastructure, n *
 		return cke.KubernetesClusterStatus{}, err
 	}
 
+	eps, err = epsAPI(metav1.NamespaceSystem).Get(ctx, EndpointSliceName(EtcdEndpointSliceName, discoveryv1.AddressTypeIPv6), metav1.GetOptions{})
+	switch {
+	case err == nil:
+		s.EtcdEndpointSliceIPv6 = eps
+	case k8serr.IsNotFound(err):
+	default:
+		return cke.KubernetesClusterStatus{}, err
+	}
+
 	resources, err := inf.Storage().GetAllResources(ctx)
 	if err != nil {
 		return cke.KubernetesClusterStatus{}, err
@@ -650,7 +671,7 @@ func isRepairTargetHealthy(ctx context.Context, entry *cke.RepairQueueEntry, clu
 		timeout = *op.CommandTimeoutSeconds
 	}
 
-	stdout, err := runCommand(ctx, timeout, append(op.HealthCheckCommand, entry.Address))
+	stdout, _, err := runCommand(ctx, timeout, append(op.HealthCheckCommand, entry.Address))
 	if err != nil {
 		return false, err
 	}
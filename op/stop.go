@@ -71,6 +71,14 @@ func EtcdRiversStopOp(nodes []*cke.Node) cke.Operator {
 	}
 }
 
+// KMSPluginStopOp returns an Operator to stop the KMS plugin
+func KMSPluginStopOp(nodes []*cke.Node) cke.Operator {
+	return &containerStopOp{
+		nodes: nodes,
+		name:  KMSPluginContainerName,
+	}
+}
+
 // ProxyStopOp returns an Operator to stop kube-proxy
 func ProxyStopOp(nodes []*cke.Node) cke.Operator {
 	return &containerStopOp{
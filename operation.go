@@ -29,6 +29,13 @@ type Commander interface {
 	Command() Command
 }
 
+// OutputCommander is an extension of Commander that provides the output
+// of external commands executed by Run
+type OutputCommander interface {
+	Commander
+	Output() (stdout, stderr string)
+}
+
 // Command represents some command
 type Command struct {
 	Name   string `json:"name"`
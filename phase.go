@@ -1,6 +1,9 @@
 package cke
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 // OperationPhase represents the processing status of CKE server.
 type OperationPhase string
@@ -21,6 +24,7 @@ const (
 	PhaseRepairMachines  = OperationPhase("repair-machines")
 	PhaseUncordonNodes   = OperationPhase("uncordon-nodes")
 	PhaseRebootNodes     = OperationPhase("reboot-nodes")
+	PhaseSuspended       = OperationPhase("suspended")
 	PhaseCompleted       = OperationPhase("completed")
 )
 
@@ -40,11 +44,39 @@ var AllOperationPhases = []OperationPhase{
 	PhaseRepairMachines,
 	PhaseUncordonNodes,
 	PhaseRebootNodes,
+	PhaseSuspended,
 	PhaseCompleted,
 }
 
+// SuspendablePhases contains OperationPhases that can be suspended.
+var SuspendablePhases = []OperationPhase{
+	PhaseUpgrade,
+	PhaseRivers,
+	PhaseEtcdBoot,
+	PhaseEtcdStart,
+	PhaseEtcdWait,
+	PhaseK8sStart,
+	PhaseEtcdMaintain,
+	PhaseK8sMaintain,
+	PhaseStopCP,
+	PhaseUncordonNodes,
+	PhaseRepairMachines,
+	PhaseRebootNodes,
+}
+
+// IsSuspendable returns true if the phase can be suspended.
+func (p OperationPhase) IsSuspendable() bool {
+	return slices.Contains(SuspendablePhases, p)
+}
+
 // ServerStatus represents the current server status.
 type ServerStatus struct {
 	Phase     OperationPhase `json:"phase"`
 	Timestamp time.Time      `json:"timestamp"`
+	// Frozen is true if operations are suspended by a maintenance freeze.
+	Frozen bool `json:"frozen,omitempty"`
+	// SuspendedPhases is the list of phases suspended by the administrator.
+	SuspendedPhases []OperationPhase `json:"suspended_phases,omitempty"`
+	// EncryptionRotation is the last or ongoing encryption key rotation, if any.
+	EncryptionRotation *EncryptionKeyRotation `json:"encryption_rotation,omitempty"`
 }
@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"time"
@@ -28,6 +31,10 @@ var (
 	flgSessionTTL           = pflag.String("session-ttl", "60s", "leader session's TTL")
 	flgDebugSabakan         = pflag.Bool("debug-sabakan", false, "debug sabakan integration")
 	flgMaxConcurrentUpdates = pflag.Int("max-concurrent-updates", 10, "the maximum number of components that can be updated simultaneously")
+	flgHTTPS                = pflag.String("https", "", "<Listen IP>:<Port number> for HTTPS with client authentication (disabled if empty)")
+	flgTLSCert              = pflag.String("tls-cert", "/etc/cke/server.crt", "TLS server certificate file for --https")
+	flgTLSKey               = pflag.String("tls-key", "/etc/cke/server.key", "TLS server private key file for --https")
+	flgRecordArchive        = pflag.String("record-archive", "", "JSON Lines file to archive pruned operation records (disabled if empty)")
 )
 
 func loadConfig(p string) (*etcdutil.Config, error) {
@@ -44,6 +51,21 @@ func loadConfig(p string) (*etcdutil.Config, error) {
 	return cfg, nil
 }
 
+// clientCAPool returns the CA certificate pool to verify API clients.
+// Clients are authenticated with certificates issued by the CKE server CA.
+func clientCAPool(ctx context.Context, storage *cke.Storage) (*x509.CertPool, error) {
+	ca, err := storage.GetCACertificate(ctx, cke.CAServer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the server CA certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(ca)) {
+		return nil, errors.New("failed to parse the server CA certificate")
+	}
+	return pool, nil
+}
+
 func debugSabakan(addon server.Integrator) {
 	well.Go(func(ctx context.Context) error {
 		ctx = context.WithValue(ctx, sabakan.WaitSecs, float64(5))
@@ -121,6 +143,7 @@ func main() {
 		Interval:             interval,
 		CertsGCInterval:      gcInterval,
 		MaxConcurrentUpdates: maxConcurrentUpdates,
+		RecordArchivePath:    *flgRecordArchive,
 	})
 	well.Go(controller.Run)
 
@@ -148,6 +171,32 @@ func main() {
 	if err != nil {
 		log.ErrorExit(err)
 	}
+
+	if *flgHTTPS != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		pool, err := clientCAPool(ctx, storage)
+		cancel()
+		if err != nil {
+			log.ErrorExit(err)
+		}
+
+		ts := &well.HTTPServer{
+			Server: &http.Server{
+				Addr:    *flgHTTPS,
+				Handler: mux,
+				TLSConfig: &tls.Config{
+					ClientAuth: tls.VerifyClientCertIfGiven,
+					ClientCAs:  pool,
+					MinVersion: tls.VersionTLS12,
+				},
+			},
+			ShutdownTimeout: 3 * time.Minute,
+		}
+		err = ts.ListenAndServeTLS(*flgTLSCert, *flgTLSKey)
+		if err != nil {
+			log.ErrorExit(err)
+		}
+	}
 	err = well.Wait()
 	if err != nil && !well.IsSignaled(err) {
 		log.ErrorExit(err)
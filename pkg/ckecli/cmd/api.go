@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "api subcommand",
+	Long:  `api subcommand`,
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+var apiIssueOpts struct {
+	TTL    string
+	Output string
+}
+
+// apiIssueCmd represents the "api issue" command
+var apiIssueCmd = &cobra.Command{
+	Use:   "issue NAME",
+	Short: "issue client certificates to access CKE REST API",
+	Long: `Issue TLS client certificates to access the write API of cke-server.
+
+NAME is the name of the client.  It is recorded in the logs of cke-server
+as the identity of the client.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if len(name) == 0 {
+			return errors.New("name is empty")
+		}
+
+		outputJSON := false
+		switch apiIssueOpts.Output {
+		case "json":
+			outputJSON = true
+		case "file":
+		default:
+			return errors.New("invalid option: output=" + apiIssueOpts.Output)
+		}
+
+		cert, key, err := cke.IssueAPIClientCertificate(inf, name, apiIssueOpts.TTL)
+		if err != nil {
+			return err
+		}
+
+		cacert, err := storage.GetCACertificate(cmd.Context(), cke.CAServer)
+		if err != nil {
+			return err
+		}
+
+		if outputJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(cke.IssueResponse{
+				Cert:   cert,
+				Key:    key,
+				CACert: cacert,
+			})
+		}
+
+		cacertFile := "cke-ca.crt"
+		certFile := fmt.Sprintf("cke-api-%s.crt", name)
+		keyFile := fmt.Sprintf("cke-api-%s.key", name)
+		err = os.WriteFile(cacertFile, []byte(cacert), 0o644)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(certFile, []byte(cert), 0o644)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(keyFile, []byte(key), 0o600)
+		if err != nil {
+			return err
+		}
+		fmt.Println("cert files: ", cacertFile, certFile, keyFile)
+		return nil
+	},
+}
+
+func init() {
+	fs := apiIssueCmd.Flags()
+	fs.StringVar(&apiIssueOpts.TTL, "ttl", "720h", "TTL of the certificate")
+	fs.StringVar(&apiIssueOpts.Output, "output", "json", `output format ("json" or "file")`)
+	apiCmd.AddCommand(apiIssueCmd)
+}
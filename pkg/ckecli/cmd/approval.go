@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var approvalCmd = &cobra.Command{
+	Use:   "approval",
+	Short: "approval subcommand",
+	Long:  `approval subcommand`,
+}
+
+func init() {
+	rootCmd.AddCommand(approvalCmd)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var approvalGetPolicyCmd = &cobra.Command{
+	Use:   "get-policy",
+	Short: "dump stored approval policy",
+	Long:  `Dump the policy of operations that require approval.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := storage.GetApprovalPolicy(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		b, err := yaml.Marshal(p)
+		if err != nil {
+			return err
+		}
+
+		_, err = os.Stdout.Write(b)
+		return err
+	},
+}
+
+func init() {
+	approvalCmd.AddCommand(approvalGetPolicyCmd)
+}
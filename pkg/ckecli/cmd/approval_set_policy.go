@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cybozu-go/cke"
+)
+
+var approvalSetPolicyCmd = &cobra.Command{
+	Use:   "set-policy FILE",
+	Short: "load approval policy",
+	Long: `Load the policy of operations that require approval from FILE.
+
+The file must be either YAML or JSON.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		p := new(cke.ApprovalPolicy)
+		err = yaml.Unmarshal(b, p)
+		if err != nil {
+			return err
+		}
+		err = p.Validate()
+		if err != nil {
+			return err
+		}
+
+		return storage.SetApprovalPolicy(cmd.Context(), p)
+	},
+}
+
+func init() {
+	approvalCmd.AddCommand(approvalSetPolicyCmd)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var approvalShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show the current approval",
+	Long:  `Show the operations waiting for approval in JSON.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := storage.GetApproval(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		return enc.Encode(a)
+	},
+}
+
+func init() {
+	approvalCmd.AddCommand(approvalShowCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var approveOpts struct {
+	User string
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve ID",
+	Short: "approve operations waiting for approval",
+	Long: `Approve the operations waiting for approval.
+
+ID is the ID of the approval shown by "ckecli approval show".`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return decideApproval(cmd, args[0], true, approveOpts.User)
+	},
+}
+
+func decideApproval(cmd *cobra.Command, arg string, approve bool, user string) error {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	_, err = storage.DecideApproval(cmd.Context(), id, approve, user)
+	return err
+}
+
+func init() {
+	approveCmd.Flags().StringVar(&approveOpts.User, "user", os.Getenv("USER"), "the user who approves")
+	rootCmd.AddCommand(approveCmd)
+}
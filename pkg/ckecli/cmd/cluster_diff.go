@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cybozu-go/cke"
+)
+
+var clusterDiffOptions struct {
+	Output string
+}
+
+// clusterDiffCmd represents the "cluster diff" command
+var clusterDiffCmd = &cobra.Command{
+	Use:   "diff FILE",
+	Short: "compare cluster configuration with the stored one",
+	Long: `Compare cluster configuration in FILE with the one stored in etcd.
+
+This shows added or removed nodes, changes of nodes and options,
+and the actions that CKE will take on components of each node.
+
+If FILE violates the current constraints, this exits with non-zero status.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if clusterDiffOptions.Output != "json" && clusterDiffOptions.Output != "simple" {
+			return errors.New("invalid output format")
+		}
+		ctx := cmd.Context()
+
+		b, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		proposed := cke.NewCluster()
+		err = yaml.Unmarshal(b, proposed)
+		if err != nil {
+			return err
+		}
+		err = proposed.Validate(false)
+		if err != nil {
+			return err
+		}
+
+		current, err := storage.GetCluster(ctx)
+		switch err {
+		case nil:
+		case cke.ErrNotFound:
+			current = &cke.Cluster{}
+		default:
+			return err
+		}
+
+		constraints, err := storage.GetConstraints(ctx)
+		switch err {
+		case nil:
+		case cke.ErrNotFound:
+			constraints = cke.DefaultConstraints()
+		default:
+			return err
+		}
+
+		d := cke.DiffClusters(current, proposed)
+		if clusterDiffOptions.Output == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "    ")
+			if err := enc.Encode(d); err != nil {
+				return err
+			}
+		} else {
+			if err := printClusterDiff(os.Stdout, d); err != nil {
+				return err
+			}
+		}
+
+		if err := constraints.Check(proposed); err != nil {
+			return fmt.Errorf("constraints violation: %w", err)
+		}
+		return nil
+	},
+}
+
+func printClusterDiff(w io.Writer, d *cke.ClusterDiff) error {
+	if d.Empty() {
+		_, err := fmt.Fprintln(w, "No changes.")
+		return err
+	}
+
+	var sb strings.Builder
+	printFields := func(header string, fields []cke.FieldDiff) {
+		if len(fields) == 0 {
+			return
+		}
+		fmt.Fprintln(&sb, header)
+		for _, f := range fields {
+			fmt.Fprintf(&sb, "  %s\n", f)
+		}
+	}
+
+	if len(d.AddedNodes) > 0 {
+		fmt.Fprintf(&sb, "Added nodes: %s\n", strings.Join(d.AddedNodes, ", "))
+	}
+	if len(d.RemovedNodes) > 0 {
+		fmt.Fprintf(&sb, "Removed nodes: %s\n", strings.Join(d.RemovedNodes, ", "))
+	}
+	for _, n := range d.Nodes {
+		printFields("Node "+n.Address+":", n.Changes)
+	}
+	printFields("Cluster settings:", d.Settings)
+	for _, o := range d.Options {
+		printFields("Options of "+o.Component+":", o.Changes)
+	}
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return err
+	}
+
+	if len(d.Actions) == 0 {
+		_, err := fmt.Fprintln(w, "No components will be updated.")
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "Actions:"); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 1, 1, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "  Component\tAction\tNodes"); err != nil {
+		return err
+	}
+	for _, a := range d.Actions {
+		if _, err := fmt.Fprintf(tw, "  %s\t%s\t%s\n", a.Component, a.Action, strings.Join(a.Nodes, ",")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func init() {
+	clusterDiffCmd.Flags().StringVarP(&clusterDiffOptions.Output, "output", "o", "simple", "Output format [json,simple]")
+	clusterCmd.AddCommand(clusterDiffCmd)
+}
@@ -5,18 +5,34 @@ import (
 
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/yaml"
+
+	"github.com/cybozu-go/cke"
 )
 
+var clusterGetRevision int64
+
 // clusterGetCmd represents the "cluster get" command
 var clusterGetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "dump stored cluster configuration",
-	Long:  `Dump cluster configuration stored in etcd.`,
+	Long: `Dump cluster configuration stored in etcd.
+
+If --revision is specified, the configuration of the revision in the history is dumped.`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, err := storage.GetCluster(cmd.Context())
-		if err != nil {
-			return err
+		var cfg *cke.Cluster
+		if clusterGetRevision > 0 {
+			entry, err := storage.GetClusterHistoryEntry(cmd.Context(), clusterGetRevision)
+			if err != nil {
+				return err
+			}
+			cfg = entry.Cluster
+		} else {
+			c, err := storage.GetCluster(cmd.Context())
+			if err != nil {
+				return err
+			}
+			cfg = c
 		}
 
 		b, err := yaml.Marshal(cfg)
@@ -30,5 +46,6 @@ var clusterGetCmd = &cobra.Command{
 }
 
 func init() {
+	clusterGetCmd.Flags().Int64Var(&clusterGetRevision, "revision", 0, "the revision in the history to dump")
 	clusterCmd.AddCommand(clusterGetCmd)
 }
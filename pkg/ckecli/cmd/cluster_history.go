@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var clusterHistoryOpts struct {
+	Output string
+}
+
+// clusterHistoryCmd represents the "cluster history" command
+var clusterHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "list the history of cluster configuration",
+	Long: `List the history of cluster configuration stored in etcd.
+
+The latest revision is shown first.
+With "-o json", the output includes the cluster configurations.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if clusterHistoryOpts.Output != "json" && clusterHistoryOpts.Output != "simple" {
+			return errors.New("invalid output format")
+		}
+
+		entries, err := storage.GetClusterHistory(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		if clusterHistoryOpts.Output == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "    ")
+			return enc.Encode(entries)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 1, 1, ' ', 0)
+		if _, err := w.Write([]byte("Revision\tTime\tAuthor\tMessage\n")); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if _, err := fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", e.Revision, e.Time.Format(time.RFC3339), e.Author, e.Message); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	clusterHistoryCmd.Flags().StringVarP(&clusterHistoryOpts.Output, "output", "o", "simple", "Output format [json,simple]")
+	clusterCmd.AddCommand(clusterHistoryCmd)
+}
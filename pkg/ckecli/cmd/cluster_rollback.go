@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+var clusterRollbackOpts struct {
+	Author  string
+	Message string
+}
+
+// clusterRollbackCmd represents the "cluster rollback" command
+var clusterRollbackCmd = &cobra.Command{
+	Use:   "rollback REVISION",
+	Short: "restore cluster configuration from the history",
+	Long: `Restore cluster configuration of REVISION from the history.
+
+The restored configuration is validated and checked against the constraints
+in the same way as "ckecli cluster set", then stored as a new revision.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		rev, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		entry, err := storage.GetClusterHistoryEntry(ctx, rev)
+		if err == cke.ErrNotFound {
+			return fmt.Errorf("revision %d is not found in the history", rev)
+		}
+		if err != nil {
+			return err
+		}
+
+		cfg := entry.Cluster
+		err = cfg.Validate(false)
+		if err != nil {
+			return err
+		}
+
+		constraints, err := storage.GetConstraints(ctx)
+		switch err {
+		case cke.ErrNotFound:
+			constraints = cke.DefaultConstraints()
+			fallthrough
+		case nil:
+			err = constraints.Check(cfg)
+			if err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		message := clusterRollbackOpts.Message
+		if message == "" {
+			message = fmt.Sprintf("rollback to revision %d", rev)
+		}
+		return storage.PutClusterWithHistory(ctx, cfg, clusterRollbackOpts.Author, message)
+	},
+}
+
+func init() {
+	fs := clusterRollbackCmd.Flags()
+	fs.StringVar(&clusterRollbackOpts.Author, "author", os.Getenv("USER"), "the author of the rollback")
+	fs.StringVarP(&clusterRollbackOpts.Message, "message", "m", "", "the message recorded in the history")
+	clusterCmd.AddCommand(clusterRollbackCmd)
+}
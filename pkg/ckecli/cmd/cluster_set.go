@@ -9,13 +9,19 @@ import (
 	"github.com/cybozu-go/cke"
 )
 
+var clusterSetOpts struct {
+	Author  string
+	Message string
+}
+
 // clusterSetCmd represents the "cluster set" command
 var clusterSetCmd = &cobra.Command{
 	Use:   "set FILE",
 	Short: "load cluster configuration",
 	Long: `Load cluster configuration from FILE and store it in etcd.
 
-The file must be either YAML or JSON.`,
+The file must be either YAML or JSON.
+The configuration is recorded in the history with the author and message.`,
 
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -50,10 +56,13 @@ The file must be either YAML or JSON.`,
 			return err
 		}
 
-		return storage.PutCluster(ctx, cfg)
+		return storage.PutClusterWithHistory(ctx, cfg, clusterSetOpts.Author, clusterSetOpts.Message)
 	},
 }
 
 func init() {
+	fs := clusterSetCmd.Flags()
+	fs.StringVar(&clusterSetOpts.Author, "author", os.Getenv("USER"), "the author of the configuration")
+	fs.StringVarP(&clusterSetOpts.Message, "message", "m", "", "the message recorded in the history")
 	clusterCmd.AddCommand(clusterSetCmd)
 }
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// encryptionCmd represents the encryption command
+var encryptionCmd = &cobra.Command{
+	Use:   "encryption",
+	Short: "encryption subcommand",
+	Long:  `Manage encryption keys for Kubernetes resources at rest.`,
+}
+
+func init() {
+	rootCmd.AddCommand(encryptionCmd)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+var encryptionRotateProvider string
+
+var encryptionRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "start rotation of the encryption key",
+	Long: `Start rotation of the encryption key for Kubernetes resources at rest.
+
+This command generates a new key in Vault and records it in etcd.
+CKE then rotates the key in the following steps:
+
+1. Restart API servers to decrypt data with the new key.
+2. Restart API servers to encrypt data with the new key.
+3. Re-encrypt all the resources with the new key.
+4. Restart API servers without the old keys, and remove them from Vault.
+
+With --provider=kms, CKE runs the KMS v2 plugin backed by Vault Transit
+next to kube-apiserver.  Data encrypted with the old keys are migrated
+to the KMS provider by the same steps.
+
+The progress can be checked with "ckecli encryption status".`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		st, err := storage.GetEncryptionStatus(ctx)
+		switch err {
+		case nil:
+		case cke.ErrNotFound:
+			st = nil
+		default:
+			return err
+		}
+		if st != nil && st.Rotation.InProgress() {
+			return fmt.Errorf("key rotation is in progress: %s", st.Rotation.Phase)
+		}
+
+		vc, err := inf.Vault()
+		if err != nil {
+			return err
+		}
+		secrets, err := cke.ReadEncryptionKeySecrets(vc)
+		if err != nil {
+			return err
+		}
+		if st == nil {
+			// take over the keys created by "ckecli vault enckey".
+			keys := secrets.LegacyKeys()
+			if len(keys) == 0 {
+				return errors.New("no encryption keys; run \"ckecli vault init\" first")
+			}
+			st = &cke.EncryptionStatus{Keys: keys}
+		}
+
+		if encryptionRotateProvider == cke.EncryptionProviderKMS {
+			// the KMS plugin cannot start without the credentials.
+			if _, err := cke.ReadKMSCredentials(vc); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UTC()
+		newKey, err := secrets.Generate(encryptionRotateProvider, now)
+		if err != nil {
+			return err
+		}
+		if err := st.StartRotation(newKey, now); err != nil {
+			return err
+		}
+
+		// The key must be in Vault before API servers refer to it.
+		if err := cke.WriteEncryptionKeySecrets(vc, secrets); err != nil {
+			return err
+		}
+		return storage.PutEncryptionStatus(ctx, st)
+	},
+}
+
+func init() {
+	encryptionRotateCmd.Flags().StringVar(&encryptionRotateProvider, "provider", cke.EncryptionProviderAESCBC, "encryption provider of the new key (aescbc, aesgcm, secretbox or kms)")
+	encryptionCmd.AddCommand(encryptionRotateCmd)
+}
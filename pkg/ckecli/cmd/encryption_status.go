@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+var encryptionStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show the encryption keys and the progress of key rotation",
+	Long: `Show the encryption keys and the progress of key rotation in JSON.
+
+If keys have never been rotated by "ckecli encryption rotate", this fails.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, err := storage.GetEncryptionStatus(cmd.Context())
+		if err == cke.ErrNotFound {
+			return errors.New("encryption keys are not managed by CKE yet")
+		}
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		return enc.Encode(st)
+	},
+}
+
+func init() {
+	encryptionCmd.AddCommand(encryptionStatusCmd)
+}
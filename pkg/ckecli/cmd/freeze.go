@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var freezeCmd = &cobra.Command{
+	Use:   "freeze",
+	Short: "freeze subcommand",
+	Long:  `freeze subcommand`,
+}
+
+func init() {
+	rootCmd.AddCommand(freezeCmd)
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var freezeClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "resume operations of CKE",
+	Long:  `Clear the freeze to resume operations of CKE.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return storage.ClearFreeze(cmd.Context())
+	},
+}
+
+func init() {
+	freezeCmd.AddCommand(freezeClearCmd)
+}
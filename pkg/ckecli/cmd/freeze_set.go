@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+var freezeSetOpts struct {
+	Owner    string
+	Duration time.Duration
+}
+
+var freezeSetCmd = &cobra.Command{
+	Use:   "set REASON",
+	Short: "suspend all operations of CKE",
+	Long: `Suspend all operations of CKE.
+
+While the freeze is in effect, CKE keeps deciding operations and
+reports the phase, but it does not run any operations.
+The freeze is lifted by "ckecli freeze clear" or when it expires.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if freezeSetOpts.Duration < 0 {
+			return errors.New("duration must not be negative")
+		}
+
+		now := time.Now().UTC()
+		f := &cke.Freeze{
+			Reason:    args[0],
+			Owner:     freezeSetOpts.Owner,
+			CreatedAt: now,
+		}
+		if freezeSetOpts.Duration > 0 {
+			f.ExpiresAt = now.Add(freezeSetOpts.Duration)
+		}
+		if err := f.Validate(); err != nil {
+			return err
+		}
+
+		return storage.SetFreeze(cmd.Context(), f)
+	},
+}
+
+func init() {
+	fs := freezeSetCmd.Flags()
+	fs.StringVar(&freezeSetOpts.Owner, "owner", os.Getenv("USER"), "the owner of the freeze")
+	fs.DurationVar(&freezeSetOpts.Duration, "duration", 0, "the duration of the freeze; 0 means no expiration")
+	freezeCmd.AddCommand(freezeSetCmd)
+}
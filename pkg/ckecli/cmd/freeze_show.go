@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+type freezeStatus struct {
+	*cke.Freeze
+	Active bool `json:"active"`
+}
+
+var freezeShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show the current freeze",
+	Long: `Show the current freeze in JSON.
+
+If no freeze is set, this shows {"active": false}.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := storage.GetFreeze(cmd.Context())
+		switch err {
+		case nil:
+		case cke.ErrNotFound:
+			f = nil
+		default:
+			return err
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		return enc.Encode(freezeStatus{
+			Freeze: f,
+			Active: f.IsActive(time.Now()),
+		})
+	},
+}
+
+func init() {
+	freezeCmd.AddCommand(freezeShowCmd)
+}
@@ -2,14 +2,26 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
 )
 
 var (
-	historyCount int
-	followMode   bool
+	historyCount    int
+	followMode      bool
+	historyCommands bool
+	historyFilter   struct {
+		Operation string
+		Target    string
+		Status    string
+		Since     string
+		Until     string
+	}
 )
 
 // historyCmd represents the history command
@@ -21,6 +33,11 @@ var historyCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
+		filter, err := historyRecordFilter(time.Now())
+		if err != nil {
+			return err
+		}
+
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "    ")
 
@@ -31,7 +48,10 @@ var historyCmd = &cobra.Command{
 			}
 
 			for r := range recordCh {
-				err := enc.Encode(r)
+				if !filter.Match(r) {
+					continue
+				}
+				err := enc.Encode(historyRecord(r))
 				if err != nil {
 					return err
 				}
@@ -39,13 +59,13 @@ var historyCmd = &cobra.Command{
 			return nil
 		}
 
-		records, err := storage.GetRecords(ctx, int64(historyCount))
+		records, err := storage.SearchRecords(ctx, filter, int64(historyCount))
 		if err != nil {
 			return err
 		}
 
 		for _, r := range records {
-			err = enc.Encode(r)
+			err = enc.Encode(historyRecord(r))
 			if err != nil {
 				return err
 			}
@@ -54,8 +74,67 @@ var historyCmd = &cobra.Command{
 	},
 }
 
+// historyRecordFilter builds the filter from the command-line flags.
+// --since and --until accept either a duration relative to now or an RFC 3339 timestamp.
+func historyRecordFilter(now time.Time) (*cke.RecordFilter, error) {
+	f := &cke.RecordFilter{
+		Operation: historyFilter.Operation,
+		Target:    historyFilter.Target,
+		Status:    cke.RecordStatus(historyFilter.Status),
+	}
+
+	switch f.Status {
+	case "", cke.StatusNew, cke.StatusRunning, cke.StatusCancelled, cke.StatusCompleted, cke.StatusRejected:
+	default:
+		return nil, fmt.Errorf("unknown status: %s", f.Status)
+	}
+
+	since, err := parseHistoryTime("--since", historyFilter.Since, now)
+	if err != nil {
+		return nil, err
+	}
+	f.Since = since
+
+	until, err := parseHistoryTime("--until", historyFilter.Until, now)
+	if err != nil {
+		return nil, err
+	}
+	f.Until = until
+	return f, nil
+}
+
+// parseHistoryTime parses v as a duration before now or an RFC 3339 timestamp.
+// If v is empty, this returns the zero time.
+func parseHistoryTime(flag, v string, now time.Time) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid %s: %s", flag, v)
+}
+
+// historyRecord omits the executed commands unless --commands is specified.
+func historyRecord(r *cke.Record) *cke.Record {
+	if historyCommands {
+		return r
+	}
+	r.Commands = nil
+	return r
+}
+
 func init() {
 	historyCmd.Flags().IntVarP(&historyCount, "count", "n", 0, "limit the number of operations to show")
 	historyCmd.Flags().BoolVarP(&followMode, "follow", "f", false, "show operations continuously")
+	historyCmd.Flags().BoolVar(&historyCommands, "commands", false, "show executed commands with their timing and output")
+	historyCmd.Flags().StringVar(&historyFilter.Operation, "op", "", "show only operations of this name")
+	historyCmd.Flags().StringVar(&historyFilter.Target, "target", "", "show only operations targeting this address")
+	historyCmd.Flags().StringVar(&historyFilter.Status, "status", "", "show only operations of this status")
+	historyCmd.Flags().StringVar(&historyFilter.Since, "since", "", "show only operations started since this duration ago (e.g. 24h) or RFC 3339 time")
+	historyCmd.Flags().StringVar(&historyFilter.Until, "until", "", "show only operations started before this duration ago (e.g. 1h) or RFC 3339 time")
 	rootCmd.AddCommand(historyCmd)
 }
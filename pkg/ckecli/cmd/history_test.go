@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryRecordFilter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		name    string
+		status  string
+		since   string
+		until   string
+		want    time.Time
+		wantEnd time.Time
+		succeed bool
+	}{
+		{
+			name:    "no filter",
+			succeed: true,
+		},
+		{
+			name:    "duration",
+			since:   "24h",
+			want:    now.Add(-24 * time.Hour),
+			succeed: true,
+		},
+		{
+			name:    "timestamp",
+			since:   "2024-01-01T00:00:00Z",
+			want:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			succeed: true,
+		},
+		{
+			name:    "until",
+			since:   "2024-01-01T00:00:00Z",
+			until:   "1h",
+			want:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd: now.Add(-time.Hour),
+			succeed: true,
+		},
+		{
+			name:    "invalid until",
+			until:   "tomorrow",
+			succeed: false,
+		},
+		{
+			name:    "invalid since",
+			since:   "yesterday",
+			succeed: false,
+		},
+		{
+			name:    "valid status",
+			status:  "completed",
+			succeed: true,
+		},
+		{
+			name:    "invalid status",
+			status:  "done",
+			succeed: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			historyFilter.Status = tc.status
+			historyFilter.Since = tc.since
+			historyFilter.Until = tc.until
+			f, err := historyRecordFilter(now)
+			if !tc.succeed {
+				if err == nil {
+					t.Error("should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !f.Since.Equal(tc.want) {
+				t.Errorf("unexpected since: %s", f.Since)
+			}
+			if !f.Until.Equal(tc.wantEnd) {
+				t.Errorf("unexpected until: %s", f.Until)
+			}
+		})
+	}
+}
@@ -2,27 +2,52 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 
 	"github.com/cybozu-go/cke"
 )
 
+var imagesCluster string
+
 // imagesCmd represents the images command
 var imagesCmd = &cobra.Command{
 	Use:   "images",
 	Short: "list container image names used by cke",
-	Long:  `List container image names used by cke.`,
+	Long: `List container image names used by cke.
+
+If --cluster is given, the image names are replaced as specified by
+"images" of the cluster configuration file.`,
 
 	// Override rootCmd.PersistentPreRunE.
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var overrides *cke.ImageOverrides
+		if imagesCluster != "" {
+			b, err := os.ReadFile(imagesCluster)
+			if err != nil {
+				return err
+			}
+			cfg := cke.NewCluster()
+			if err := yaml.Unmarshal(b, cfg); err != nil {
+				return err
+			}
+			if err := cfg.Validate(false); err != nil {
+				return err
+			}
+			overrides = cfg.Images
+		}
+
 		for _, img := range cke.AllImages() {
-			fmt.Println(img)
+			fmt.Println(overrides.Resolve(cke.Image(img)).Name())
 		}
+		return nil
 	},
 }
 
 func init() {
+	imagesCmd.Flags().StringVar(&imagesCluster, "cluster", "", "cluster configuration file")
 	rootCmd.AddCommand(imagesCmd)
 }
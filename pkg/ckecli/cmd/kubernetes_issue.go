@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"slices"
 
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/cybozu-go/cke"
 )
@@ -16,19 +18,28 @@ var kubernetesIssueOpts struct {
 	TTL       string
 	GroupName string
 	UserName  string
+
+	OIDC             bool
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCExtraScopes  []string
 }
 
 // kubernetesIssueCmd represents the "kubernetes issue" command
 var kubernetesIssueCmd = &cobra.Command{
 	Use:   "issue",
 	Short: "issue client certificates for k8s user",
-	Long:  `Issue TLS client certificates for k8s user.`,
+	Long: `Issue TLS client certificates for k8s user.
+
+With --oidc, this writes kubeconfig that obtains ID tokens from an OpenID
+Connect provider configured in authentication_config of kube-api options.`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
 		// TODO: delete this notice after CKE 1.35.5.
-		if !cmd.Flags().Changed("user") {
+		if !kubernetesIssueOpts.OIDC && !cmd.Flags().Changed("user") {
 			// stderr, because stdout carries the kubeconfig.
 			fmt.Fprintf(os.Stderr,
 				"issuing a certificate for user %q (previously %q)\n"+
@@ -55,18 +66,64 @@ var kubernetesIssueCmd = &cobra.Command{
 			return err
 		}
 
+		if kubernetesIssueOpts.OIDC {
+			issuerURL, clientID, err := oidcClient(cluster)
+			if err != nil {
+				return err
+			}
+			cfg := cke.OIDCKubeconfig(cluster.Name, cacert, server, issuerURL, clientID,
+				kubernetesIssueOpts.OIDCClientSecret, kubernetesIssueOpts.OIDCExtraScopes)
+			return writeKubeconfig(cfg)
+		}
+
 		cert, key, err := cke.KubernetesCA{}.IssueUserCert(ctx, inf, kubernetesIssueOpts.UserName, kubernetesIssueOpts.GroupName, kubernetesIssueOpts.TTL)
 		if err != nil {
 			return err
 		}
 		cfg := cke.UserKubeconfig(cluster.Name, kubernetesIssueOpts.UserName, cacert, cert, key, server)
-		src, err := clientcmd.Write(*cfg)
-		if err != nil {
-			return err
+		return writeKubeconfig(cfg)
+	},
+}
+
+// oidcClient returns the issuer URL and the client ID for the OIDC kubeconfig.
+// Unless given by flags, they are taken from the first JWT authenticator in
+// the authentication config of the cluster.
+func oidcClient(cluster *cke.Cluster) (string, string, error) {
+	authncfg, err := cluster.Options.APIServer.GetAuthenticationConfig()
+	if err != nil {
+		return "", "", err
+	}
+	if authncfg == nil || len(authncfg.JWT) == 0 {
+		return "", "", errors.New("no JWT authenticator in authentication_config")
+	}
+
+	issuerURL := kubernetesIssueOpts.OIDCIssuerURL
+	if issuerURL == "" {
+		issuerURL = authncfg.JWT[0].Issuer.URL
+	}
+	for _, jwt := range authncfg.JWT {
+		if jwt.Issuer.URL != issuerURL {
+			continue
+		}
+		clientID := kubernetesIssueOpts.OIDCClientID
+		if clientID == "" {
+			clientID = jwt.Issuer.Audiences[0]
 		}
-		_, err = fmt.Println(string(src))
+		if !slices.Contains(jwt.Issuer.Audiences, clientID) {
+			return "", "", fmt.Errorf("client ID %s is not in the audiences of %s", clientID, issuerURL)
+		}
+		return issuerURL, clientID, nil
+	}
+	return "", "", fmt.Errorf("issuer %s is not configured in authentication_config", issuerURL)
+}
+
+func writeKubeconfig(cfg *api.Config) error {
+	src, err := clientcmd.Write(*cfg)
+	if err != nil {
 		return err
-	},
+	}
+	_, err = fmt.Println(string(src))
+	return err
 }
 
 func init() {
@@ -74,5 +131,10 @@ func init() {
 	fs.StringVar(&kubernetesIssueOpts.TTL, "ttl", "2h", "TTL of the certificate")
 	fs.StringVarP(&kubernetesIssueOpts.GroupName, "group", "g", cke.AdminGroup, "Group name of the issuing config")
 	fs.StringVarP(&kubernetesIssueOpts.UserName, "user", "u", cke.DefaultUserName, "User name of the issuing config")
+	fs.BoolVar(&kubernetesIssueOpts.OIDC, "oidc", false, "Issue kubeconfig that authenticates with OpenID Connect instead of a client certificate")
+	fs.StringVar(&kubernetesIssueOpts.OIDCIssuerURL, "oidc-issuer-url", "", "Issuer URL of the OpenID Connect provider")
+	fs.StringVar(&kubernetesIssueOpts.OIDCClientID, "oidc-client-id", "", "Client ID of the OpenID Connect provider")
+	fs.StringVar(&kubernetesIssueOpts.OIDCClientSecret, "oidc-client-secret", "", "Client secret of the OpenID Connect provider")
+	fs.StringSliceVar(&kubernetesIssueOpts.OIDCExtraScopes, "oidc-extra-scope", nil, "Extra scopes to request")
 	kubernetesCmd.AddCommand(kubernetesIssueCmd)
 }
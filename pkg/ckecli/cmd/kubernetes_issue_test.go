@@ -3,6 +3,9 @@ package cmd
 import (
 	"testing"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+
 	"github.com/cybozu-go/cke"
 )
 
@@ -23,3 +26,53 @@ func TestKubernetesIssueDefaults(t *testing.T) {
 		t.Errorf("--group: expected %q, actual %q", cke.AdminGroup, got)
 	}
 }
+
+func TestOIDCClient(t *testing.T) {
+	cfg := &unstructured.Unstructured{}
+	cfg.SetGroupVersionKind(apiserverv1.SchemeGroupVersion.WithKind("AuthenticationConfiguration"))
+	cfg.Object["jwt"] = []any{
+		map[string]any{"issuer": map[string]any{"url": "https://a.example.com", "audiences": []any{"kubernetes", "other"}}},
+		map[string]any{"issuer": map[string]any{"url": "https://b.example.com", "audiences": []any{"cke"}}},
+	}
+	cluster := &cke.Cluster{}
+	cluster.Options.APIServer.AuthenticationConfig = cfg
+
+	saved := kubernetesIssueOpts
+	t.Cleanup(func() { kubernetesIssueOpts = saved })
+
+	tests := []struct {
+		issuerURL  string
+		clientID   string
+		wantIssuer string
+		wantClient string
+		wantErr    bool
+	}{
+		{wantIssuer: "https://a.example.com", wantClient: "kubernetes"},
+		{clientID: "other", wantIssuer: "https://a.example.com", wantClient: "other"},
+		{issuerURL: "https://b.example.com", wantIssuer: "https://b.example.com", wantClient: "cke"},
+		{issuerURL: "https://b.example.com", clientID: "kubernetes", wantErr: true},
+		{issuerURL: "https://c.example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		kubernetesIssueOpts.OIDCIssuerURL = tt.issuerURL
+		kubernetesIssueOpts.OIDCClientID = tt.clientID
+		issuer, client, err := oidcClient(cluster)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s %s: error is expected", tt.issuerURL, tt.clientID)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.issuerURL, tt.clientID, err)
+			continue
+		}
+		if issuer != tt.wantIssuer || client != tt.wantClient {
+			t.Errorf("%s %s: unexpected result %s %s", tt.issuerURL, tt.clientID, issuer, client)
+		}
+	}
+
+	if _, _, err := oidcClient(&cke.Cluster{}); err == nil {
+		t.Error("error is expected without authentication_config")
+	}
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var notifierCmd = &cobra.Command{
+	Use:   "notifier",
+	Short: "notifier subcommand",
+	Long:  `notifier subcommand`,
+}
+
+func init() {
+	rootCmd.AddCommand(notifierCmd)
+}
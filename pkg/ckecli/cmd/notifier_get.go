@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var notifierGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "dump stored notifier configuration",
+	Long:  `Dump the webhook configuration of the notifier.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := storage.GetNotifierConfig(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		b, err := yaml.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+
+		_, err = os.Stdout.Write(b)
+		return err
+	},
+}
+
+func init() {
+	notifierCmd.AddCommand(notifierGetCmd)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cybozu-go/cke"
+)
+
+var notifierSetCmd = &cobra.Command{
+	Use:   "set FILE",
+	Short: "load notifier configuration",
+	Long: `Load the webhook configuration of the notifier from FILE.
+
+The file must be either YAML or JSON.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		cfg := new(cke.NotifierConfig)
+		err = yaml.Unmarshal(b, cfg)
+		if err != nil {
+			return err
+		}
+		err = cfg.Validate()
+		if err != nil {
+			return err
+		}
+
+		return storage.SetNotifierConfig(cmd.Context(), cfg)
+	},
+}
+
+func init() {
+	notifierCmd.AddCommand(notifierSetCmd)
+}
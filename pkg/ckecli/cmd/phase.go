@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+var phaseCmd = &cobra.Command{
+	Use:   "phase",
+	Short: "phase subcommand",
+	Long:  `phase subcommand`,
+}
+
+func parsePhases(args []string) ([]cke.OperationPhase, error) {
+	phases := make([]cke.OperationPhase, len(args))
+	for i, a := range args {
+		p := cke.OperationPhase(a)
+		if !p.IsSuspendable() {
+			return nil, fmt.Errorf("phase %s cannot be suspended", a)
+		}
+		phases[i] = p
+	}
+	return phases, nil
+}
+
+func init() {
+	rootCmd.AddCommand(phaseCmd)
+}
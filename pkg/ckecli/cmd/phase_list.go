@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+var phaseListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list suspendable phases and whether they are suspended",
+	Long:  `List suspendable phases and whether they are suspended in JSON.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		suspended, err := storage.GetSuspendedPhases(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		phases := make(map[cke.OperationPhase]bool, len(cke.SuspendablePhases))
+		for _, p := range cke.SuspendablePhases {
+			phases[p] = false
+		}
+		for _, p := range suspended {
+			phases[p] = true
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		return enc.Encode(phases)
+	},
+}
+
+func init() {
+	phaseCmd.AddCommand(phaseListCmd)
+}
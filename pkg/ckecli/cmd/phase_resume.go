@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+var phaseResumeCmd = &cobra.Command{
+	Use:   "resume PHASE...",
+	Short: "resume operations of the phases",
+	Long:  `Resume operations of the phases suspended by "ckecli phase suspend".`,
+
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		phases, err := parsePhases(args)
+		if err != nil {
+			return err
+		}
+
+		suspended, err := storage.GetSuspendedPhases(cmd.Context())
+		if err != nil {
+			return err
+		}
+		suspended = slices.DeleteFunc(suspended, func(p cke.OperationPhase) bool {
+			return slices.Contains(phases, p)
+		})
+		return storage.SetSuspendedPhases(cmd.Context(), suspended)
+	},
+}
+
+func init() {
+	phaseCmd.AddCommand(phaseResumeCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+var phaseSuspendCmd = &cobra.Command{
+	Use:   "suspend PHASE...",
+	Short: "suspend operations of the phases",
+	Long: `Suspend operations of the phases.
+
+Operations of the phases up to k8s-start are prerequisites of the others,
+so CKE does nothing while these phases are suspended and have operations.
+Operations of the other phases are skipped and the next phase is examined.`,
+
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		phases, err := parsePhases(args)
+		if err != nil {
+			return err
+		}
+
+		suspended, err := storage.GetSuspendedPhases(cmd.Context())
+		if err != nil {
+			return err
+		}
+		for _, p := range phases {
+			if !slices.Contains(suspended, p) {
+				suspended = append(suspended, p)
+			}
+		}
+		return storage.SetSuspendedPhases(cmd.Context(), suspended)
+	},
+}
+
+func init() {
+	phaseCmd.AddCommand(phaseSuspendCmd)
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	k8sYaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cybozu-go/cke"
+	"github.com/cybozu-go/cke/server"
+)
+
+var planOpts struct {
+	ClusterFile          string
+	ConstraintsFile      string
+	ResourcesFile        string
+	MaxConcurrentUpdates int
+	Output               string
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "show operations that CKE would run",
+	Long: `Show the operation phase and operations that CKE would run next.
+
+The current cluster status is collected from the nodes as CKE does,
+and the operations are decided against the proposed configurations.
+The stored configurations are used unless the files are specified.
+No operations are executed.
+
+Note that only the first step is shown because CKE decides the next
+operations after the current ones complete.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if planOpts.Output != "json" && planOpts.Output != "simple" {
+			return errors.New("invalid output format")
+		}
+		if planOpts.MaxConcurrentUpdates <= 0 {
+			return errors.New("max-concurrent-updates must be greater than 0")
+		}
+
+		ctx := cmd.Context()
+
+		cluster, err := planCluster(ctx)
+		if err != nil {
+			return err
+		}
+		constraints, err := planConstraints(ctx)
+		if err != nil {
+			return err
+		}
+		if err := constraints.Check(cluster); err != nil {
+			return err
+		}
+		resources, err := planResources(ctx)
+		if err != nil {
+			return err
+		}
+
+		vc, err := storage.GetVaultConfig(ctx)
+		if err != nil {
+			return err
+		}
+		vcData, err := json.Marshal(vc)
+		if err != nil {
+			return err
+		}
+		if err := cke.ConnectVault(ctx, vcData); err != nil {
+			return err
+		}
+
+		ckeInf, err := cke.NewInfrastructure(ctx, cluster, storage)
+		if err != nil {
+			return err
+		}
+		defer ckeInf.Close()
+
+		plan, err := server.NewPlan(ctx, ckeInf, cluster, constraints, resources, &server.Config{
+			MaxConcurrentUpdates: planOpts.MaxConcurrentUpdates,
+		})
+		if err != nil {
+			return err
+		}
+
+		if planOpts.Output == "json" {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "    ")
+			return enc.Encode(plan)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Phase: %s\n", plan.Phase)
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 1, 1, ' ', 0)
+		if _, err := w.Write([]byte("Operation\tTargets\n")); err != nil {
+			return err
+		}
+		for _, op := range plan.Operations {
+			if _, err := fmt.Fprintf(w, "%s\t%s\n", op.Name, strings.Join(op.Targets, ",")); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	},
+}
+
+func planCluster(ctx context.Context) (*cke.Cluster, error) {
+	if planOpts.ClusterFile == "" {
+		cluster, err := storage.GetCluster(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := cluster.Validate(false); err != nil {
+			return nil, err
+		}
+		return cluster, nil
+	}
+
+	b, err := os.ReadFile(planOpts.ClusterFile)
+	if err != nil {
+		return nil, err
+	}
+	cluster := cke.NewCluster()
+	if err := yaml.Unmarshal(b, cluster); err != nil {
+		return nil, err
+	}
+	if err := cluster.Validate(false); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+func planConstraints(ctx context.Context) (*cke.Constraints, error) {
+	if planOpts.ConstraintsFile == "" {
+		cstr, err := storage.GetConstraints(ctx)
+		if err == cke.ErrNotFound {
+			return cke.DefaultConstraints(), nil
+		}
+		return cstr, err
+	}
+
+	b, err := os.ReadFile(planOpts.ConstraintsFile)
+	if err != nil {
+		return nil, err
+	}
+	cstr := cke.DefaultConstraints()
+	if err := yaml.Unmarshal(b, cstr); err != nil {
+		return nil, err
+	}
+	return cstr, nil
+}
+
+// planResources returns the stored resources overwritten by the proposed ones.
+// Proposed resources whose definitions differ from the stored ones are given
+// an invalid revision so that they are planned to be applied.
+func planResources(ctx context.Context) ([]cke.ResourceDefinition, error) {
+	resources, err := storage.GetAllResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if planOpts.ResourcesFile == "" {
+		return resources, nil
+	}
+
+	f, err := os.Open(planOpts.ResourcesFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	index := make(map[string]int, len(resources))
+	for i, r := range resources {
+		index[r.Key] = i
+	}
+
+	y := k8sYaml.NewYAMLReader(bufio.NewReader(f))
+	for {
+		data, err := y.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := cke.ParseResource(data)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[key]; ok {
+			if !bytes.Equal(resources[i].Definition, data) {
+				resources[i].Definition = data
+				resources[i].Revision = -1
+			}
+			continue
+		}
+
+		parts := strings.Split(key, "/")
+		rd := cke.ResourceDefinition{
+			Key:        key,
+			Kind:       parts[0],
+			Name:       parts[len(parts)-1],
+			Revision:   -1,
+			Definition: data,
+		}
+		if len(parts) == 3 {
+			rd.Namespace = parts[1]
+		}
+		index[key] = len(resources)
+		resources = append(resources, rd)
+	}
+
+	cke.SortResources(resources)
+	return resources, nil
+}
+
+func init() {
+	fs := planCmd.Flags()
+	fs.StringVar(&planOpts.ClusterFile, "cluster", "", "proposed cluster configuration file")
+	fs.StringVar(&planOpts.ConstraintsFile, "constraints", "", "proposed constraints file")
+	fs.StringVar(&planOpts.ResourcesFile, "resources", "", "proposed user-defined resources file")
+	fs.IntVar(&planOpts.MaxConcurrentUpdates, "max-concurrent-updates", 10, "the value of --max-concurrent-updates of cke")
+	fs.StringVarP(&planOpts.Output, "output", "o", "simple", "Output format [json,simple]")
+	rootCmd.AddCommand(planCmd)
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var recordRetentionCmd = &cobra.Command{
+	Use:   "record-retention",
+	Short: "record-retention subcommand",
+	Long:  `record-retention subcommand`,
+}
+
+func init() {
+	rootCmd.AddCommand(recordRetentionCmd)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+var recordRetentionGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "show the retention policy of operation records",
+	Long:  `Show the retention policy of operation records.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rr, err := storage.GetRecordRetention(cmd.Context())
+		switch err {
+		case nil:
+		case cke.ErrNotFound:
+			rr = &cke.RecordRetention{}
+		default:
+			return err
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		return enc.Encode(rr)
+	},
+}
+
+func init() {
+	recordRetentionCmd.AddCommand(recordRetentionGetCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cybozu-go/cke"
+)
+
+var recordRetentionSetOpts struct {
+	MaxRecords int64
+	MaxAge     time.Duration
+}
+
+var recordRetentionSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "set the retention policy of operation records",
+	Long: `Set the retention policy of operation records.
+
+CKE prunes records that exceed --max-records or are older than --max-age.
+The latest record is always kept.  If cke is started with --record-archive,
+pruned records are appended to the file in JSON Lines format.`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rr := &cke.RecordRetention{
+			MaxRecords:    recordRetentionSetOpts.MaxRecords,
+			MaxAgeSeconds: int64(recordRetentionSetOpts.MaxAge.Seconds()),
+		}
+		if err := rr.Validate(); err != nil {
+			return err
+		}
+
+		return storage.SetRecordRetention(cmd.Context(), rr)
+	},
+}
+
+func init() {
+	fs := recordRetentionSetCmd.Flags()
+	fs.Int64Var(&recordRetentionSetOpts.MaxRecords, "max-records", 0, "the maximum number of records; 0 means the default (1000)")
+	fs.DurationVar(&recordRetentionSetOpts.MaxAge, "max-age", 0, "the maximum age of records; 0 means no limit")
+	recordRetentionCmd.AddCommand(recordRetentionSetCmd)
+}
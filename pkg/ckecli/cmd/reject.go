@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var rejectOpts struct {
+	User string
+}
+
+var rejectCmd = &cobra.Command{
+	Use:   "reject ID",
+	Short: "reject operations waiting for approval",
+	Long: `Reject the operations waiting for approval.
+
+The rejected operations are recorded and dropped.  If CKE decides the
+same operations again, a new approval is requested.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return decideApproval(cmd, args[0], false, rejectOpts.User)
+	},
+}
+
+func init() {
+	rejectCmd.Flags().StringVar(&rejectOpts.User, "user", os.Getenv("USER"), "the user who rejects")
+	rootCmd.AddCommand(rejectCmd)
+}
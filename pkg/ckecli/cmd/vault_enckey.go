@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -19,13 +20,24 @@ var vaultEncKeyCmd = &cobra.Command{
 	Short: "generate new encryption key for Kubernetes Secrets",
 	Long: `Generate or rotate encryption keys for Kubernetes Secrets.
 
-WARNING: Key rotation is not fully implemented in this version!!
-
 This command generates new encryption keys for Kubernetes Secrets and
 rotate old keys.  The current key, if any, is retained to decrypt
-existing data.  Other old keys are removed.`,
+existing data.  Other old keys are removed.
+
+This command does not re-encrypt existing data.  Use "ckecli encryption rotate"
+instead to rotate keys safely.  Once keys are rotated by "ckecli encryption rotate",
+this command cannot be used any longer.`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
+		_, err := storage.GetEncryptionStatus(cmd.Context())
+		switch err {
+		case nil:
+			return errors.New("encryption keys are managed by \"ckecli encryption rotate\"")
+		case cke.ErrNotFound:
+		default:
+			return err
+		}
+
 		vc, err := inf.Vault()
 		if err != nil {
 			return err
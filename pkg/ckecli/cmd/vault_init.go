@@ -57,6 +57,20 @@ path "cke/*"
 {
   capabilities = ["create", "read", "update", "delete", "list", "sudo"]
 }`
+
+	kmsPolicy = `
+path "` + cke.KMSTransitPath + `/encrypt/` + cke.KMSTransitKey + `"
+{
+  capabilities = ["update"]
+}
+path "` + cke.KMSTransitPath + `/decrypt/` + cke.KMSTransitKey + `"
+{
+  capabilities = ["update"]
+}
+path "` + cke.KMSTransitPath + `/keys/` + cke.KMSTransitKey + `"
+{
+  capabilities = ["read"]
+}`
 )
 
 func readPasswordFromStdTerminal(prompt string) (string, error) {
@@ -144,6 +158,11 @@ func initVault(ctx context.Context) error {
 		return err
 	}
 
+	err = createKMS(ctx, vc)
+	if err != nil {
+		return err
+	}
+
 	cfg, err := storage.GetVaultConfig(ctx)
 	switch err {
 	case nil:
@@ -291,6 +310,74 @@ func createKV(ctx context.Context, vc *vault.Client) error {
 	return nil
 }
 
+// createKMS configures the transit secrets engine and AppRole for the KMS plugin.
+func createKMS(ctx context.Context, vc *vault.Client) error {
+	mounts, err := vc.Sys().ListMounts()
+	if err != nil {
+		return err
+	}
+	_, ok1 := mounts[cke.KMSTransitPath]
+	_, ok2 := mounts[cke.KMSTransitPath+"/"]
+	if !ok1 && !ok2 {
+		err = vc.Sys().Mount(cke.KMSTransitPath, &vault.MountInput{Type: "transit"})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("mounted transit on %s\n", cke.KMSTransitPath)
+	}
+
+	keyPath := path.Join(cke.KMSTransitPath, "keys", cke.KMSTransitKey)
+	secret, err := vc.Logical().Read(keyPath)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		_, err = vc.Logical().Write(keyPath, map[string]any{"type": "aes256-gcm96"})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created transit key %s\n", keyPath)
+	}
+
+	err = vc.Sys().PutPolicy("cke-kms", kmsPolicy)
+	if err != nil {
+		return err
+	}
+
+	secret, err = vc.Logical().Read(cke.KMSSecret)
+	if err != nil {
+		return err
+	}
+	if secret != nil {
+		return nil
+	}
+
+	_, err = vc.Logical().Write("auth/approle/role/cke-kms", map[string]any{
+		"policies": "cke-kms",
+		"period":   "1h",
+	})
+	if err != nil {
+		return err
+	}
+	secret, err = vc.Logical().Read("auth/approle/role/cke-kms/role-id")
+	if err != nil {
+		return err
+	}
+	roleID := secret.Data["role_id"].(string)
+
+	secret, err = vc.Logical().Write("auth/approle/role/cke-kms/secret-id", map[string]any{})
+	if err != nil {
+		return err
+	}
+	secretID := secret.Data["secret_id"].(string)
+
+	_, err = vc.Logical().Write(cke.KMSSecret, map[string]any{
+		"role-id":   roleID,
+		"secret-id": secretID,
+	})
+	return err
+}
+
 var vaultInitCfg struct {
 	caCertFile string
 	endpoint   string
@@ -309,6 +396,7 @@ Vault will be configured to:
       PKI secrets under cke/.
     * creates AppRole for CKE.
     * have initial encryption key for Kubernetes Secrets.
+    * have transit secrets engine under cke/ and AppRole for the KMS plugin.
 
 This command will ask username and password for Vault authentication
 when VAULT_TOKEN environment variable is not set.`,
@@ -49,8 +49,14 @@ const (
 	RoleKubelet               = "kubelet"
 	RoleKubeProxy             = "kube-proxy"
 	RoleServiceAccount        = "service-account"
+	RoleAPIClient             = "api-client"
 )
 
+// APIClientOU is the organizational unit of client certificates for CKE REST API.
+// The server CA also issues certificates for etcd, so the write API accepts
+// only certificates having this OU.
+const APIClientOU = "cke-api-client"
+
 // AdminGroup is the group name of cluster admin users
 const AdminGroup = "system:masters"
 
@@ -135,7 +141,7 @@ func (e EtcdCA) IssueServerCert(ctx context.Context, inf Infrastructure, node *N
 		map[string]any{
 			"common_name": node.Nodename(),
 			"alt_names":   strings.Join(altNames, ","),
-			"ip_sans":     "127.0.0.1," + node.Address,
+			"ip_sans":     nodeIPSANs(node),
 		})
 }
 
@@ -149,7 +155,7 @@ func (e EtcdCA) IssuePeerCert(ctx context.Context, inf Infrastructure, node *Nod
 		},
 		map[string]any{
 			"common_name":          node.Nodename(),
-			"ip_sans":              "127.0.0.1," + node.Address,
+			"ip_sans":              nodeIPSANs(node),
 			"exclude_cn_from_sans": "true",
 		})
 }
@@ -201,6 +207,24 @@ func IssueEtcdClientCertificate(inf Infrastructure, username, ttl string) (cert,
 		})
 }
 
+// IssueAPIClientCertificate issues TLS client certificate to access CKE REST API.
+// The common name of the certificate is used as the identity of the client.
+func IssueAPIClientCertificate(inf Infrastructure, username, ttl string) (cert, key string, err error) {
+	return issueCertificate(inf, CAServer, RoleAPIClient, false,
+		map[string]any{
+			"ttl":            "8760h",
+			"max_ttl":        "8760h",
+			"server_flag":    "false",
+			"allow_any_name": "true",
+			"ou":             APIClientOU,
+		},
+		map[string]any{
+			"common_name":          username,
+			"exclude_cn_from_sans": "true",
+			"ttl":                  ttl,
+		})
+}
+
 // KubernetesCA is a certificate authority for k8s cluster.
 type KubernetesCA struct{}
 
@@ -222,7 +246,8 @@ func (k KubernetesCA) IssueUserCert(ctx context.Context, inf Infrastructure, use
 }
 
 // IssueForAPIServer issues TLS certificate for API servers.
-func (k KubernetesCA) IssueForAPIServer(ctx context.Context, inf Infrastructure, n *Node, serviceSubnet, clusterDomain string) (crt, key string, err error) {
+// The certificate includes the addresses of the node and the first IP address of each service subnet.
+func (k KubernetesCA) IssueForAPIServer(ctx context.Context, inf Infrastructure, n *Node, serviceSubnets []string, clusterDomain string) (crt, key string, err error) {
 	altNames := []string{
 		"localhost",
 		"kubernetes",
@@ -230,11 +255,14 @@ func (k KubernetesCA) IssueForAPIServer(ctx context.Context, inf Infrastructure,
 		"kubernetes.default.svc",
 		"kubernetes.default.svc." + clusterDomain,
 	}
-	ip, _, err := net.ParseCIDR(serviceSubnet)
-	if err != nil {
-		return "", "", err
+	ipSANs := nodeIPSANs(n)
+	for _, subnet := range serviceSubnets {
+		ip, _, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return "", "", err
+		}
+		ipSANs += "," + netutil.IPAdd(ip, 1).String()
 	}
-	kubeSvcAddr := netutil.IPAdd(ip, 1)
 
 	return issueCertificate(inf, CAKubernetes, RoleSystem, false,
 		map[string]any{
@@ -246,7 +274,7 @@ func (k KubernetesCA) IssueForAPIServer(ctx context.Context, inf Infrastructure,
 		map[string]any{
 			"common_name":          "kubernetes",
 			"alt_names":            strings.Join(altNames, ","),
-			"ip_sans":              "127.0.0.1," + n.Address + "," + kubeSvcAddr.String(),
+			"ip_sans":              ipSANs,
 			"exclude_cn_from_sans": "true",
 		})
 }
@@ -302,7 +330,7 @@ func (k KubernetesCA) IssueForKubelet(ctx context.Context, inf Infrastructure, n
 		map[string]any{
 			"common_name":          "system:node:" + nodename,
 			"alt_names":            altNames,
-			"ip_sans":              "127.0.0.1," + node.Address,
+			"ip_sans":              nodeIPSANs(node),
 			"exclude_cn_from_sans": "true",
 		})
 }
@@ -382,6 +410,11 @@ func (WebhookCA) IssueCertificate(ctx context.Context, inf Infrastructure, names
 		})
 }
 
+// nodeIPSANs returns IP SANs for the certificates of the node.
+func nodeIPSANs(n *Node) string {
+	return "127.0.0.1," + strings.Join(n.Addresses(), ",")
+}
+
 func issueCertificate(inf Infrastructure, ca, role string, onetime bool, roleOpts, certOpts map[string]any) (crt, key string, err error) {
 	pkiKey := VaultPKIKey(ca)
 	client, err := inf.Vault()
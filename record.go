@@ -1,6 +1,10 @@
 package cke
 
 import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -13,8 +17,29 @@ const (
 	StatusRunning   = RecordStatus("running")
 	StatusCancelled = RecordStatus("cancelled")
 	StatusCompleted = RecordStatus("completed")
+	StatusRejected  = RecordStatus("rejected")
+
+	// StatusFailed is the status of a failed command in CommandRecord.
+	StatusFailed = RecordStatus("failed")
 )
 
+// recordStatuses lists all statuses of records.
+var recordStatuses = []RecordStatus{StatusNew, StatusRunning, StatusCancelled, StatusCompleted, StatusRejected}
+
+// MaxCommandOutputLength is the maximum length of stdout, stderr, and errors kept in records.
+const MaxCommandOutputLength = 4096
+
+// CommandRecord represents a record of a command executed in an operation
+type CommandRecord struct {
+	Command Command      `json:"command"`
+	Status  RecordStatus `json:"status"`
+	Stdout  string       `json:"stdout,omitempty"`
+	Stderr  string       `json:"stderr,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	StartAt time.Time    `json:"start-at"`
+	EndAt   time.Time    `json:"end-at"`
+}
+
 // Record represents a record of an operation
 type Record struct {
 	ID        int64        `json:"id,string"`
@@ -26,6 +51,11 @@ type Record struct {
 	Error     string       `json:"error"`
 	StartAt   time.Time    `json:"start-at"`
 	EndAt     time.Time    `json:"end-at"`
+	Approval  *Approval    `json:"approval,omitempty"`
+	// User is the client who made the change for records of the write API.
+	User string `json:"user,omitempty"`
+	// Commands are the executed commands in order.
+	Commands []CommandRecord `json:"commands,omitempty"`
 }
 
 // NewRecord creates new `Record`
@@ -39,6 +69,22 @@ func NewRecord(id int64, op string, targets []string) *Record {
 	}
 }
 
+// NewAuditRecord creates a completed `Record` of a change made by user via the write API.
+// info describes the change.  The ID is assigned when the record is registered.
+func NewAuditRecord(user, action string, targets []string, info string) *Record {
+	now := time.Now().UTC()
+	return &Record{
+		Status:    StatusCompleted,
+		Operation: action,
+		Command:   Command{Name: action, Target: strings.Join(targets, " ")},
+		Targets:   targets,
+		Info:      info,
+		User:      user,
+		StartAt:   now,
+		EndAt:     now,
+	}
+}
+
 // Cancel cancels the operation
 func (r *Record) Cancel() {
 	r.Status = StatusCancelled
@@ -51,10 +97,46 @@ func (r *Record) Complete() {
 	r.EndAt = time.Now().UTC()
 }
 
+// Reject marks the operation as rejected by the approval
+func (r *Record) Reject(a *Approval) {
+	r.Status = StatusRejected
+	r.Approval = a
+	r.EndAt = time.Now().UTC()
+}
+
 // SetCommand updates the record for the new command
 func (r *Record) SetCommand(c Command) {
 	r.Status = StatusRunning
 	r.Command = c
+	r.Commands = append(r.Commands, CommandRecord{
+		Command: c,
+		Status:  StatusRunning,
+		StartAt: time.Now().UTC(),
+	})
+}
+
+// FinishCommand records the result of the current command.
+// stdout, stderr, and the error are truncated to MaxCommandOutputLength from the end.
+func (r *Record) FinishCommand(stdout, stderr string, err error) {
+	if len(r.Commands) == 0 {
+		return
+	}
+	cr := &r.Commands[len(r.Commands)-1]
+	cr.Status = StatusCompleted
+	if err != nil {
+		cr.Status = StatusFailed
+		cr.Error = truncateOutput(err.Error())
+	}
+	cr.Stdout = truncateOutput(stdout)
+	cr.Stderr = truncateOutput(stderr)
+	cr.EndAt = time.Now().UTC()
+}
+
+func truncateOutput(s string) string {
+	if len(s) <= MaxCommandOutputLength {
+		return s
+	}
+	return "..." + strings.ToValidUTF8(s[len(s)-MaxCommandOutputLength:], "")
 }
 
 // SetInfo records some information of the operation result
@@ -65,6 +147,92 @@ func (r *Record) SetInfo(i string) {
 // SetError cancels the operation with error information
 func (r *Record) SetError(e error) {
 	r.Status = StatusCancelled
-	r.Error = e.Error()
+	r.Error = truncateOutput(e.Error())
 	r.EndAt = time.Now().UTC()
 }
+
+// RecordFilter specifies conditions to search records.
+// Zero-valued fields match any records.
+type RecordFilter struct {
+	// Operation matches the operation name.
+	Operation string
+	// Target matches one of the targets.
+	Target string
+	Status RecordStatus
+	// Since matches records started at or after this time.
+	Since time.Time
+	// Until matches records started before this time.
+	Until time.Time
+}
+
+// Match returns true if r satisfies all conditions of f.
+func (f *RecordFilter) Match(r *Record) bool {
+	if f.Operation != "" && r.Operation != f.Operation {
+		return false
+	}
+	if f.Target != "" && !slices.Contains(r.Targets, f.Target) {
+		return false
+	}
+	if f.Status != "" && r.Status != f.Status {
+		return false
+	}
+	if !f.Since.IsZero() && r.StartAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !r.StartAt.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// DefaultMaxRecords is the default number of records to keep.
+const DefaultMaxRecords = 1000
+
+// RecordRetention is the retention policy of operation records.
+// Records exceeding either limit are pruned, except for the latest one.
+type RecordRetention struct {
+	// MaxRecords is the maximum number of records.  Zero means DefaultMaxRecords.
+	MaxRecords int64 `json:"max_records,omitempty"`
+	// MaxAgeSeconds is the maximum age of records.  Zero means no limit.
+	MaxAgeSeconds int64 `json:"max_age_seconds,omitempty"`
+}
+
+// Validate validates the retention policy.
+func (r *RecordRetention) Validate() error {
+	if r.MaxRecords < 0 {
+		return errors.New("max_records must not be negative")
+	}
+	if r.MaxAgeSeconds < 0 {
+		return errors.New("max_age_seconds must not be negative")
+	}
+	return nil
+}
+
+// GetMaxRecords returns the maximum number of records with the default applied.
+func (r *RecordRetention) GetMaxRecords() int64 {
+	if r == nil || r.MaxRecords == 0 {
+		return DefaultMaxRecords
+	}
+	return r.MaxRecords
+}
+
+// Expired returns true if rec is older than the retention period at now.
+func (r *RecordRetention) Expired(rec *Record, now time.Time) bool {
+	if r == nil || r.MaxAgeSeconds == 0 {
+		return false
+	}
+	return rec.StartAt.Before(now.Add(-time.Duration(r.MaxAgeSeconds) * time.Second))
+}
+
+type recordIDKey struct{}
+
+// WithRecordID returns a copy of ctx that carries the ID of the running operation record.
+func WithRecordID(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, recordIDKey{}, id)
+}
+
+// RecordIDFromContext returns the ID of the operation record carried by ctx.
+func RecordIDFromContext(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(recordIDKey{}).(int64)
+	return id, ok
+}
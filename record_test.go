@@ -0,0 +1,52 @@
+package cke
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRecordCommands(t *testing.T) {
+	r := NewRecord(1, "test", []string{"10.0.0.11"})
+
+	r.FinishCommand("ignored", "", nil)
+	if len(r.Commands) != 0 {
+		t.Fatal("command is recorded without SetCommand")
+	}
+
+	r.SetCommand(Command{Name: "first", Target: "10.0.0.11"})
+	r.FinishCommand("ok\n", "", nil)
+	r.SetCommand(Command{Name: "second", Target: "10.0.0.11"})
+	if r.Command.Name != "second" {
+		t.Error("current command is not updated:", r.Command)
+	}
+	r.FinishCommand("", strings.Repeat("x", MaxCommandOutputLength+10), errors.New("failed"))
+
+	if len(r.Commands) != 2 {
+		t.Fatal("unexpected commands:", r.Commands)
+	}
+
+	first := r.Commands[0]
+	if first.Command.Name != "first" || first.Status != StatusCompleted || first.Stdout != "ok\n" || first.Error != "" {
+		t.Error("unexpected first command:", first)
+	}
+	if first.StartAt.IsZero() || first.EndAt.Before(first.StartAt) {
+		t.Error("unexpected timing:", first.StartAt, first.EndAt)
+	}
+
+	second := r.Commands[1]
+	if second.Status != StatusFailed || second.Error != "failed" {
+		t.Error("unexpected second command:", second)
+	}
+	if len(second.Stderr) != MaxCommandOutputLength+3 || !strings.HasPrefix(second.Stderr, "...") {
+		t.Error("stderr is not truncated:", len(second.Stderr))
+	}
+
+	r.SetError(errors.New(strings.Repeat("x", MaxCommandOutputLength+10)))
+	if r.Status != StatusCancelled {
+		t.Error("operation with error should be cancelled:", r.Status)
+	}
+	if len(r.Error) != MaxCommandOutputLength+3 || !strings.HasPrefix(r.Error, "...") {
+		t.Error("error is not truncated:", len(r.Error))
+	}
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/cybozu-go/cke"
+)
+
+const (
+	defaultRecordsCount = 20
+	maxRecordsCount     = 1000
+)
+
+type rebootQueue struct {
+	Enabled bool                    `json:"enabled"`
+	Running bool                    `json:"running"`
+	Entries []*cke.RebootQueueEntry `json:"entries"`
+}
+
+type repairQueue struct {
+	Enabled bool                    `json:"enabled"`
+	Entries []*cke.RepairQueueEntry `json:"entries"`
+}
+
+type records struct {
+	Records []*cke.Record `json:"records"`
+	// Next is the value for "before" parameter to retrieve the next page.
+	// Zero means there are no more records.
+	Next int64 `json:"next,string"`
+}
+
+func (s Server) storage() cke.Storage {
+	return cke.Storage{Client: s.EtcdClient}
+}
+
+func (s Server) withTimeout(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(r.Context(), s.Timeout)
+}
+
+func (s Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	st, err := s.storage().GetStatus(ctx)
+	switch err {
+	case nil:
+	case cke.ErrNotFound:
+		renderError(r.Context(), w, APIErrNotFound)
+		return
+	default:
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+
+	renderJSON(w, st, http.StatusOK)
+}
+
+func (s Server) handleRebootQueue(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	storage := s.storage()
+	disabled, err := storage.IsRebootQueueDisabled(ctx)
+	if err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	running, err := storage.IsRebootQueueRunning(ctx)
+	if err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	entries, err := storage.GetRebootsEntries(ctx)
+	if err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	if entries == nil {
+		entries = []*cke.RebootQueueEntry{}
+	}
+
+	renderJSON(w, rebootQueue{
+		Enabled: !disabled,
+		Running: running,
+		Entries: entries,
+	}, http.StatusOK)
+}
+
+func (s Server) handleRepairQueue(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	storage := s.storage()
+	disabled, err := storage.IsRepairQueueDisabled(ctx)
+	if err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	entries, err := storage.GetRepairsEntries(ctx)
+	if err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	if entries == nil {
+		entries = []*cke.RepairQueueEntry{}
+	}
+
+	renderJSON(w, repairQueue{
+		Enabled: !disabled,
+		Entries: entries,
+	}, http.StatusOK)
+}
+
+func (s Server) handleConstraints(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	cstr, err := s.storage().GetConstraints(ctx)
+	switch err {
+	case nil:
+	case cke.ErrNotFound:
+		renderError(r.Context(), w, APIErrNotFound)
+		return
+	default:
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+
+	renderJSON(w, cstr, http.StatusOK)
+}
+
+func (s Server) handleResources(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	keys, err := s.storage().ListResources(ctx)
+	if err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+
+	renderJSON(w, keys, http.StatusOK)
+}
+
+func (s Server) handleRecords(w http.ResponseWriter, r *http.Request) {
+	count, before, apiErr := parseRecordsQuery(r)
+	if apiErr != nil {
+		renderError(r.Context(), w, *apiErr)
+		return
+	}
+
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	rs, err := s.storage().GetRecordsBefore(ctx, before, count)
+	if err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	if rs == nil {
+		rs = []*cke.Record{}
+	}
+
+	var next int64
+	if int64(len(rs)) == count && rs[len(rs)-1].ID > 1 {
+		next = rs[len(rs)-1].ID
+	}
+
+	renderJSON(w, records{
+		Records: rs,
+		Next:    next,
+	}, http.StatusOK)
+}
+
+func parseRecordsQuery(r *http.Request) (count, before int64, apiErr *APIError) {
+	count = defaultRecordsCount
+	q := r.URL.Query()
+
+	if v := q.Get("count"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			e := BadRequest("count must be a positive integer")
+			return 0, 0, &e
+		}
+		count = min(n, maxRecordsCount)
+	}
+
+	if v := q.Get("before"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			e := BadRequest("before must be a positive integer")
+			return 0, 0, &e
+		}
+		before = n
+	}
+
+	return count, before, nil
+}
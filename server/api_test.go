@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRecordsQuery(t *testing.T) {
+	cases := []struct {
+		name   string
+		query  string
+		count  int64
+		before int64
+		err    bool
+	}{
+		{"default", "", defaultRecordsCount, 0, false},
+		{"count", "?count=5", 5, 0, false},
+		{"capped", "?count=100000", maxRecordsCount, 0, false},
+		{"before", "?count=5&before=100", 5, 100, false},
+		{"zero count", "?count=0", 0, 0, true},
+		{"bad count", "?count=a", 0, 0, true},
+		{"negative before", "?before=-1", 0, 0, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/records"+c.query, nil)
+			count, before, apiErr := parseRecordsQuery(r)
+			if c.err {
+				if apiErr == nil {
+					t.Error("error is expected")
+				}
+				return
+			}
+			if apiErr != nil {
+				t.Fatal(apiErr)
+			}
+			if count != c.count {
+				t.Errorf("count: expected %d, actual %d", c.count, count)
+			}
+			if before != c.before {
+				t.Errorf("before: expected %d, actual %d", c.before, before)
+			}
+		})
+	}
+}
+
+func TestServeHTTPRouting(t *testing.T) {
+	cases := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/version", http.StatusOK},
+		{http.MethodPost, "/version", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/records", http.StatusMethodNotAllowed},
+		{http.MethodPut, "/reboots", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/no-such-path", http.StatusNotFound},
+		{http.MethodPost, "/no-such-path", http.StatusNotFound},
+		{http.MethodPut, "/records/no-such-path", http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Server{}.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+			if w.Code != c.status {
+				t.Errorf("expected %d, actual %d", c.status, w.Code)
+			}
+		})
+	}
+}
@@ -10,4 +10,7 @@ type Config struct {
 	CertsGCInterval time.Duration
 	// MaxConcurrentUpdates is the maximum number of concurrent updates.
 	MaxConcurrentUpdates int
+	// RecordArchivePath is the file to archive pruned operation records.
+	// If empty, pruned records are discarded.
+	RecordArchivePath string
 }
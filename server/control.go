@@ -15,6 +15,7 @@ import (
 
 	"github.com/cybozu-go/cke"
 	"github.com/cybozu-go/cke/metrics"
+	"github.com/cybozu-go/cke/notifier"
 )
 
 var errCommandFailure = errors.New("command failed")
@@ -138,6 +139,10 @@ func (c Controller) runLoop(ctx context.Context, leaderKey string) error {
 		return startWatcher(ctx, c.session.Client(), watchChan)
 	})
 
+	env.Go(func(ctx context.Context) error {
+		return notifier.New(c.session.Client()).Run(ctx)
+	})
+
 	env.Go(func(ctx context.Context) error {
 		select {
 		case <-watchChan:
@@ -159,6 +164,22 @@ func (c Controller) runLoop(ctx context.Context, leaderKey string) error {
 		}
 	})
 
+	env.Go(func(ctx context.Context) error {
+		ticker := time.NewTicker(c.config.Interval)
+		defer ticker.Stop()
+		for {
+			err := c.runPruneRecords(ctx, leaderKey)
+			if err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+
 	env.Go(func(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
@@ -197,7 +218,8 @@ func (c Controller) checkLastOp(ctx context.Context, leaderKey string) error {
 	}
 
 	r := records[0]
-	if r.Status == cke.StatusCancelled || r.Status == cke.StatusCompleted {
+	switch r.Status {
+	case cke.StatusCancelled, cke.StatusCompleted, cke.StatusRejected:
 		return nil
 	}
 
@@ -322,15 +344,44 @@ func (c Controller) runOnce(ctx context.Context, leaderKey string, tick <-chan t
 
 	ops, phase := DecideOps(cluster, status, constraints, rcs, c.config)
 
+	freeze, err := storage.GetFreeze(ctx)
+	switch err {
+	case nil:
+	case cke.ErrNotFound:
+		freeze = nil
+	default:
+		return err
+	}
+	frozen := freeze.IsActive(ts)
+
 	st := &cke.ServerStatus{
 		Phase:     phase,
 		Timestamp: ts,
+		Frozen:    frozen,
+
+		SuspendedPhases: status.SuspendedPhases,
+	}
+	if status.Encryption != nil {
+		st.EncryptionRotation = status.Encryption.Rotation
 	}
 	err = storage.SetStatus(ctx, c.session.Lease(), st)
 	if err != nil {
 		return err
 	}
 	metrics.UpdateOperationPhase(phase, ts)
+	metrics.UpdateFreeze(frozen, freeze)
+
+	if frozen {
+		wait = true
+		if len(ops) > 0 {
+			log.Info("operations are suspended by freeze", map[string]any{
+				"phase":  phase,
+				"reason": freeze.Reason,
+				"owner":  freeze.Owner,
+			})
+		}
+		return nil
+	}
 
 	if len(ops) == 0 {
 		wait = true
@@ -348,8 +399,28 @@ func (c Controller) runOnce(ctx context.Context, leaderKey string, tick <-chan t
 		}
 	}
 
+	policy, err := storage.GetApprovalPolicy(ctx)
+	switch err {
+	case nil:
+	case cke.ErrNotFound:
+		policy = nil
+	default:
+		return err
+	}
+	var approval *cke.Approval
+	if policy.Requires(phase, ops) {
+		approval, err = checkApproval(ctx, phase, ops, leaderKey, storage)
+		if err != nil {
+			return err
+		}
+		if approval == nil {
+			wait = true
+			return nil
+		}
+	}
+
 	for _, op := range ops {
-		err := runOp(ctx, op, leaderKey, storage, inf)
+		err := runOp(ctx, op, approval, leaderKey, storage, inf)
 		switch err {
 		case nil:
 		case errCommandFailure:
@@ -363,17 +434,94 @@ func (c Controller) runOnce(ctx context.Context, leaderKey string, tick <-chan t
 	return nil
 }
 
-func runOp(ctx context.Context, op cke.Operator, leaderKey string, storage cke.Storage, inf cke.Infrastructure) error {
+// checkApproval returns the approval for ops if it has been approved.
+// Otherwise, this returns nil after requesting approval or recording the rejected operations.
+func checkApproval(ctx context.Context, phase cke.OperationPhase, ops []cke.Operator, leaderKey string, storage cke.Storage) (*cke.Approval, error) {
+	approval, err := storage.GetApproval(ctx)
+	switch err {
+	case nil:
+	case cke.ErrNotFound:
+		approval = nil
+	default:
+		return nil, err
+	}
+
+	if approval == nil || !approval.Matches(phase, ops) {
+		approval = cke.NewApproval(phase, ops)
+		err := storage.RegisterApproval(ctx, leaderKey, approval)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("operations are waiting for approval", map[string]any{
+			"id":    approval.ID,
+			"phase": phase,
+		})
+		return nil, nil
+	}
+
+	switch approval.Status {
+	case cke.ApprovalApproved:
+		err := storage.DeleteApproval(ctx, leaderKey)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("operations have been approved", map[string]any{
+			"id":   approval.ID,
+			"user": approval.DecidedBy,
+		})
+		return approval, nil
+	case cke.ApprovalRejected:
+		// the rejection is kept until different operations are decided.
+		if approval.Recorded {
+			return nil, nil
+		}
+		// mark the approval first so that the rejected operations are recorded only once.
+		approval.Recorded = true
+		err := storage.UpdateApproval(ctx, leaderKey, approval)
+		switch err {
+		case nil:
+		case cke.ErrConflict, cke.ErrNotFound:
+			// modified by an administrator; check it again in the next loop.
+			log.Info("approval has been modified concurrently", map[string]any{
+				"id": approval.ID,
+			})
+			return nil, nil
+		default:
+			return nil, err
+		}
+		for _, op := range ops {
+			id, err := storage.NextRecordID(ctx)
+			if err != nil {
+				return nil, err
+			}
+			record := cke.NewRecord(id, op.Name(), op.Targets())
+			record.Reject(approval)
+			err = storage.RegisterRecord(ctx, leaderKey, record)
+			if err != nil {
+				return nil, err
+			}
+		}
+		log.Info("operations have been rejected", map[string]any{
+			"id":   approval.ID,
+			"user": approval.DecidedBy,
+		})
+	}
+	return nil, nil
+}
+
+func runOp(ctx context.Context, op cke.Operator, approval *cke.Approval, leaderKey string, storage cke.Storage, inf cke.Infrastructure) error {
 	// register operation record
 	id, err := storage.NextRecordID(ctx)
 	if err != nil {
 		return err
 	}
 	record := cke.NewRecord(id, op.Name(), op.Targets())
+	record.Approval = approval
 	err = storage.RegisterRecord(ctx, leaderKey, record)
 	if err != nil {
 		return err
 	}
+	ctx = cke.WithRecordID(ctx, record.ID)
 	log.Info("begin new operation", map[string]any{
 		"op": op.Name(),
 	})
@@ -414,6 +562,11 @@ func runOp(ctx context.Context, op cke.Operator, leaderKey string, storage cke.S
 			"command": commander.Command().String(),
 		})
 		err = commander.Run(ctx, inf, leaderKey)
+		var stdout, stderr string
+		if oc, ok := commander.(cke.OutputCommander); ok {
+			stdout, stderr = oc.Output()
+		}
+		record.FinishCommand(stdout, stderr, err)
 		if err == nil {
 			continue
 		}
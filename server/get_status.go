@@ -46,6 +46,21 @@ func (c Controller) GetClusterStatus(ctx context.Context, cluster *cke.Cluster,
 	cs.ConfigVersion = version
 	cs.NodeStatuses = statuses
 
+	suspended, err := inf.Storage().GetSuspendedPhases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cs.SuspendedPhases = suspended
+
+	encryption, err := inf.Storage().GetEncryptionStatus(ctx)
+	switch err {
+	case nil:
+		cs.Encryption = encryption
+	case cke.ErrNotFound:
+	default:
+		return nil, err
+	}
+
 	var etcdRunning bool
 	for _, n := range cke.ControlPlanes(cluster.Nodes) {
 		ns := statuses[n.Address]
@@ -9,6 +9,7 @@ import (
 	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	proxyv1alpha1 "k8s.io/kube-proxy/config/v1alpha1"
 
 	"github.com/cybozu-go/cke"
 	"github.com/cybozu-go/cke/op"
@@ -91,7 +92,7 @@ func (nf *NodeFilter) RiversOutdated(targets []*cke.Node) (nodes []*cke.Node) {
 		switch {
 		case !st.Running:
 			// stopped nodes are excluded
-		case cke.ToolsImage.Name() != st.Image:
+		case nf.cluster.Images.Resolve(cke.ToolsImage).Name() != st.Image:
 			fallthrough
 		case !currentBuiltIn.Equal(st.BuiltInParams):
 			fallthrough
@@ -122,7 +123,7 @@ func (nf *NodeFilter) EtcdRiversOutdated(targets []*cke.Node) (nodes []*cke.Node
 		switch {
 		case !st.Running:
 			// stopped nodes are excluded
-		case cke.ToolsImage.Name() != st.Image:
+		case nf.cluster.Images.Resolve(cke.ToolsImage).Name() != st.Image:
 			fallthrough
 		case !currentBuiltIn.Equal(st.BuiltInParams):
 			fallthrough
@@ -328,7 +329,7 @@ func (nf *NodeFilter) EtcdOutdatedMembers() (nodes []*cke.Node) {
 		}
 		currentBuiltIn := etcd.BuiltInParams(n, []string{}, "new")
 		switch {
-		case cke.EtcdImage.Name() != st.Image:
+		case nf.cluster.Images.Resolve(cke.EtcdImage).Name() != st.Image:
 			fallthrough
 		case !etcdEqualParams(st.BuiltInParams, currentBuiltIn):
 			fallthrough
@@ -339,6 +340,40 @@ func (nf *NodeFilter) EtcdOutdatedMembers() (nodes []*cke.Node) {
 	return nodes
 }
 
+// KMSPluginStopped filters nodes that are not running the KMS plugin.
+func (nf *NodeFilter) KMSPluginStopped(targets []*cke.Node) (nodes []*cke.Node) {
+	for _, n := range targets {
+		if !nf.nodeStatus(n).KMSPlugin.Running {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// KMSPluginOutdated filters nodes that are running the KMS plugin with outdated image or params.
+func (nf *NodeFilter) KMSPluginOutdated(targets []*cke.Node) (nodes []*cke.Node) {
+	currentBuiltIn := k8s.KMSPluginParams()
+	var currentExtra cke.ServiceParams
+	if kms := nf.cluster.Options.APIServer.KMS; kms != nil {
+		currentExtra = kms.ServiceParams
+	}
+
+	for _, n := range targets {
+		st := nf.nodeStatus(n).KMSPlugin
+		switch {
+		case !st.Running:
+			// stopped nodes are excluded
+		case nf.cluster.Images.Resolve(cke.ToolsImage).Name() != st.Image:
+			fallthrough
+		case !currentBuiltIn.Equal(st.BuiltInParams):
+			fallthrough
+		case !currentExtra.Equal(st.ExtraParams):
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
 // HealthyAPIServer returns one of the control plane nodes that is running healthy API server.
 // If there is no healthy API server, it returns `nil`.
 func (nf *NodeFilter) HealthyAPIServer() *cke.Node {
@@ -382,18 +417,19 @@ func (nf *NodeFilter) APIServerStopped(targets []*cke.Node) (nodes []*cke.Node)
 }
 
 // APIServerOutdated filters nodes that are running API server with outdated image or params.
+// Files rendered for API server, such as the authorization config, have hashes of
+// their contents in the paths, so changes in them are detected as outdated built-in params.
 func (nf *NodeFilter) APIServerOutdated(targets []*cke.Node) (nodes []*cke.Node) {
 	currentExtra := nf.cluster.Options.APIServer
 	kubeletConfig := k8s.GenerateKubeletConfiguration(nf.cluster.Options.Kubelet, "0.0.0.0", nil)
 
 	for _, n := range targets {
 		st := nf.nodeStatus(n).APIServer
-		currentBuiltIn := k8s.APIServerParams(n.Address, nf.cluster.ServiceSubnet,
-			currentExtra.AuditLogEnabled, currentExtra.AuditLogPolicy, currentExtra.AuditLogPath, kubeletConfig.ClusterDomain)
+		currentBuiltIn := k8s.APIServerParams(n.Address, nf.cluster.GetServiceSubnets(), nf.cluster.FeatureGates, currentExtra, nf.status.Encryption, kubeletConfig.ClusterDomain)
 		switch {
 		case !st.Running:
 			// stopped nodes are excluded
-		case cke.KubernetesImage.Name() != st.Image:
+		case nf.cluster.Images.Resolve(cke.KubernetesImage).Name() != st.Image:
 			fallthrough
 		case !currentBuiltIn.Equal(st.BuiltInParams):
 			fallthrough
@@ -416,15 +452,15 @@ func (nf *NodeFilter) ControllerManagerStopped(targets []*cke.Node) (nodes []*ck
 
 // ControllerManagerOutdated filters nodes that are running controller manager with outdated image or params.
 func (nf *NodeFilter) ControllerManagerOutdated(targets []*cke.Node) (nodes []*cke.Node) {
-	currentBuiltIn := k8s.ControllerManagerParams(nf.cluster.Name, nf.cluster.ServiceSubnet)
-	currentExtra := nf.cluster.Options.ControllerManager
+	currentBuiltIn := k8s.ControllerManagerParams(nf.cluster.Name, nf.cluster.GetServiceSubnets(), nf.cluster.FeatureGates, nf.cluster.Options.ControllerManager)
+	currentExtra := nf.cluster.Options.ControllerManager.ServiceParams
 
 	for _, n := range targets {
 		st := nf.nodeStatus(n).ControllerManager
 		switch {
 		case !st.Running:
 			// stopped nodes are excluded
-		case cke.KubernetesImage.Name() != st.Image:
+		case nf.cluster.Images.Resolve(cke.KubernetesImage).Name() != st.Image:
 			fallthrough
 		case !currentBuiltIn.Equal(st.BuiltInParams):
 			fallthrough
@@ -447,7 +483,7 @@ func (nf *NodeFilter) SchedulerStopped(targets []*cke.Node) (nodes []*cke.Node)
 
 // SchedulerOutdated filters nodes that are running kube-scheduler with outdated image or params.
 func (nf *NodeFilter) SchedulerOutdated(targets []*cke.Node, params cke.SchedulerParams) (nodes []*cke.Node) {
-	currentBuiltIn := k8s.SchedulerParams()
+	currentBuiltIn := k8s.SchedulerParams(nf.cluster.FeatureGates)
 	currentExtra := nf.cluster.Options.Scheduler
 	currentConfig := k8s.GenerateSchedulerConfiguration(params)
 
@@ -458,7 +494,7 @@ func (nf *NodeFilter) SchedulerOutdated(targets []*cke.Node, params cke.Schedule
 		switch {
 		case !st.Running:
 			// stopped nodes are excluded
-		case cke.KubernetesImage.Name() != st.Image:
+		case nf.cluster.Images.Resolve(cke.KubernetesImage).Name() != st.Image:
 			fallthrough
 		case !currentBuiltIn.Equal(st.BuiltInParams):
 			fallthrough
@@ -510,11 +546,11 @@ func (nf *NodeFilter) RegisteredNodes(targets []*cke.Node) (nodes []*cke.Node) {
 }
 
 // KubeletOutdated filters nodes that are running kubelet with outdated image or params.
+// The params are evaluated for each node considering node groups.
 func (nf *NodeFilter) KubeletOutdated(targets []*cke.Node) (nodes []*cke.Node) {
-	currentOpts := nf.cluster.Options.Kubelet
-	currentExtra := nf.cluster.Options.Kubelet.ServiceParams
-
 	for _, n := range targets {
+		currentOpts := nf.cluster.KubeletParamsFor(n)
+		currentExtra := currentOpts.ServiceParams
 		st := nf.nodeStatus(n).Kubelet
 		currentConfig := k8s.GenerateKubeletConfiguration(currentOpts, n.Address, st.Config)
 		currentBuiltIn := k8s.KubeletServiceParams(n, currentOpts)
@@ -525,7 +561,7 @@ func (nf *NodeFilter) KubeletOutdated(targets []*cke.Node) (nodes []*cke.Node) {
 			// stopped nodes are excluded
 		case kubeletRuntimeChanged(st.BuiltInParams, currentBuiltIn):
 			log.Warn("kubelet's container runtime cannot be changed", nil)
-		case cke.KubernetesImage.Name() != st.Image:
+		case nf.cluster.Images.Resolve(cke.KubernetesImage).Name() != st.Image:
 			fallthrough
 		case !currentBuiltIn.Equal(st.BuiltInParams):
 			fallthrough
@@ -635,13 +671,15 @@ func (nf *NodeFilter) ProxyRunningUnexpectedly(targets []*cke.Node) (nodes []*ck
 }
 
 // ProxyOutdated filters nodes that are running kube-proxy with outdated image or params.
-func (nf *NodeFilter) ProxyOutdated(targets []*cke.Node, params cke.ProxyParams) (nodes []*cke.Node) {
+// The params are evaluated for each node considering node groups.
+func (nf *NodeFilter) ProxyOutdated(targets []*cke.Node) (nodes []*cke.Node) {
 	if nf.cluster.Options.Proxy.Disable {
 		return nil
 	}
-	currentExtra := nf.cluster.Options.Proxy
 
 	for _, n := range targets {
+		params := nf.cluster.ProxyParamsFor(n)
+		currentExtra := params.ServiceParams
 		st := nf.nodeStatus(n).Proxy
 		currentBuiltIn := k8s.ProxyParams()
 		currentConfig := k8s.GenerateProxyConfiguration(params, n)
@@ -649,12 +687,14 @@ func (nf *NodeFilter) ProxyOutdated(targets []*cke.Node, params cke.ProxyParams)
 		switch {
 		case !st.Running:
 			// stopped nodes are excluded
-		case cke.KubernetesImage.Name() != st.Image:
+		case nf.cluster.Images.Resolve(cke.KubernetesImage).Name() != st.Image:
 			fallthrough
 		case !currentBuiltIn.Equal(st.BuiltInParams):
 			fallthrough
 		case !currentExtra.Equal(st.ExtraParams):
 			fallthrough
+		case proxyModeChanged(currentConfig, runningConfig):
+			fallthrough
 		case !equality.Semantic.DeepEqual(currentConfig, runningConfig):
 			log.Debug("proxy outdated", map[string]any{
 				"node":                 n.Nodename(),
@@ -675,6 +715,34 @@ func (nf *NodeFilter) ProxyOutdated(targets []*cke.Node, params cke.ProxyParams)
 	return nodes
 }
 
+// ProxyModeChanged filters nodes that are running kube-proxy in a different proxy mode.
+func (nf *NodeFilter) ProxyModeChanged(targets []*cke.Node) (nodes []*cke.Node) {
+	if nf.cluster.Options.Proxy.Disable {
+		return nil
+	}
+
+	for _, n := range targets {
+		st := nf.nodeStatus(n).Proxy
+		if !st.Running {
+			continue
+		}
+		currentConfig := k8s.GenerateProxyConfiguration(nf.cluster.ProxyParamsFor(n), n)
+		if proxyModeChanged(currentConfig, st.Config) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// proxyModeChanged returns true if kube-proxy runs in a different mode from the current config.
+// If the running config is unknown, the mode is not considered changed.
+func proxyModeChanged(currentConfig, runningConfig *proxyv1alpha1.KubeProxyConfiguration) bool {
+	if runningConfig == nil {
+		return false
+	}
+	return cke.EffectiveProxyMode(currentConfig) != cke.EffectiveProxyMode(runningConfig)
+}
+
 func isInternal(name string, n *cke.Node) bool {
 	if name == op.CKEAnnotationReboot {
 		return false
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+
+	"github.com/cybozu-go/cke"
+)
+
+// PlannedOperation is an operation that DecideOps would run.
+type PlannedOperation struct {
+	Name    string   `json:"name"`
+	Targets []string `json:"targets"`
+}
+
+// Plan is the result of Plan.
+type Plan struct {
+	Phase      cke.OperationPhase `json:"phase"`
+	Operations []PlannedOperation `json:"operations"`
+}
+
+// NewPlan collects the current cluster status for the given cluster configuration
+// and returns operations that would be run by the leader.
+// No commands of the operations are executed.
+func NewPlan(ctx context.Context, inf cke.Infrastructure, cluster *cke.Cluster, constraints *cke.Constraints, resources []cke.ResourceDefinition, config *Config) (*Plan, error) {
+	status, err := Controller{}.GetClusterStatus(ctx, cluster, inf)
+	if err != nil {
+		return nil, err
+	}
+
+	return planOps(cluster, status, constraints, resources, config), nil
+}
+
+func planOps(cluster *cke.Cluster, status *cke.ClusterStatus, constraints *cke.Constraints, resources []cke.ResourceDefinition, config *Config) *Plan {
+	ops, phase := DecideOps(cluster, status, constraints, resources, config)
+
+	plan := &Plan{
+		Phase:      phase,
+		Operations: make([]PlannedOperation, len(ops)),
+	}
+	for i, op := range ops {
+		plan.Operations[i] = PlannedOperation{
+			Name:    op.Name(),
+			Targets: op.Targets(),
+		}
+	}
+	return plan
+}
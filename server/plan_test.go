@@ -0,0 +1,28 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/cybozu-go/cke"
+)
+
+func TestPlanOps(t *testing.T) {
+	d := newData()
+	plan := planOps(d.Cluster, d.Status, d.Constraints, d.Resources, &Config{
+		MaxConcurrentUpdates: testMaxConcurrentUpdates,
+	})
+
+	if plan.Phase != cke.PhaseRivers {
+		t.Error("unexpected phase:", plan.Phase)
+	}
+
+	expected := []PlannedOperation{
+		{Name: "rivers-bootstrap", Targets: []string{nodeNames[0], nodeNames[1], nodeNames[2], nodeNames[3], nodeNames[4]}},
+		{Name: "etcd-rivers-bootstrap", Targets: []string{nodeNames[0], nodeNames[1], nodeNames[2]}},
+	}
+	if !cmp.Equal(expected, plan.Operations) {
+		t.Error("unexpected operations:", cmp.Diff(expected, plan.Operations))
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/cybozu-go/log"
+
+	"github.com/cybozu-go/cke"
+)
+
+// runPruneRecords deletes operation records exceeding the retention policy.
+// Before that, this builds the index of the records stored by older versions of CKE.
+// If an archive file is configured, the records are appended to it before deletion.
+func (c Controller) runPruneRecords(ctx context.Context, leaderKey string) error {
+	storage := cke.Storage{
+		Client: c.session.Client(),
+	}
+
+	err := storage.BuildRecordIndex(ctx, leaderKey)
+	if err != nil {
+		return err
+	}
+
+	rr, err := storage.GetRecordRetention(ctx)
+	switch err {
+	case nil:
+	case cke.ErrNotFound:
+		rr = nil
+	default:
+		return err
+	}
+
+	records, err := storage.GetRecordsToPrune(ctx, rr, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	if c.config.RecordArchivePath != "" {
+		err := archiveRecords(c.config.RecordArchivePath, records)
+		if err != nil {
+			log.Error("failed to archive records. skip pruning", map[string]any{
+				log.FnError: err,
+				"path":      c.config.RecordArchivePath,
+			})
+			// lint:ignore nilerr  Records are kept until they are archived.
+			return nil
+		}
+	}
+
+	err = storage.DeleteRecords(ctx, leaderKey, records)
+	if err != nil {
+		return err
+	}
+	log.Info("pruned operation records", map[string]any{
+		"count":   len(records),
+		"last_id": records[len(records)-1].ID,
+	})
+	return nil
+}
+
+// archiveRecords appends records to the file in JSON Lines format.
+func archiveRecords(path string, records []*cke.Record) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, r := range records {
+		err := enc.Encode(r)
+		if err != nil {
+			return err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
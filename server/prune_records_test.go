@@ -0,0 +1,50 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybozu-go/cke"
+)
+
+func TestArchiveRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+
+	err := archiveRecords(path, []*cke.Record{
+		cke.NewRecord(1, "op1", []string{"10.0.0.1"}),
+		cke.NewRecord(2, "op2", []string{"10.0.0.2"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// records are appended to the existing file.
+	err = archiveRecords(path, []*cke.Record{cke.NewRecord(3, "op3", nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var ids []int64
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		r := new(cke.Record)
+		if err := json.Unmarshal(sc.Bytes(), r); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, r.ID)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+		t.Errorf("unexpected archived records: %v", ids)
+	}
+}
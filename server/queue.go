@@ -0,0 +1,333 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cybozu-go/log"
+
+	"github.com/cybozu-go/cke"
+)
+
+const maxRequestBodySize = 1 << 20
+
+type rebootQueueRequest struct {
+	Nodes []string `json:"nodes"`
+}
+
+type repairQueueRequest struct {
+	Operation   string `json:"operation"`
+	MachineType string `json:"machine_type"`
+	Address     string `json:"address"`
+	Serial      string `json:"serial"`
+}
+
+// authenticatedUser returns the common name of the verified client certificate.
+// Clients are authenticated only when the request came through the TLS listener,
+// the client certificate was verified against the CKE server CA, and the
+// certificate was issued for the API as indicated by cke.APIClientOU.
+func authenticatedUser(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	subject := r.TLS.VerifiedChains[0][0].Subject
+	if !slices.Contains(subject.OrganizationalUnit, cke.APIClientOU) {
+		return "", false
+	}
+	if subject.CommonName == "" {
+		return "", false
+	}
+	return subject.CommonName, true
+}
+
+// authorize renders 403 and returns false if the request is not authenticated.
+func authorize(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		renderError(r.Context(), w, APIErrForbidden)
+		return "", false
+	}
+	return user, true
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		renderError(r.Context(), w, BadRequest(err.Error()))
+		return false
+	}
+	return true
+}
+
+// recordWrite logs a change made via the write API and stores it as an operation record.
+// Failures to store the record are only logged because the change has already been made.
+func (s Server) recordWrite(ctx context.Context, user, action string, targets []string, fields map[string]any) {
+	if fields == nil {
+		fields = make(map[string]any)
+	}
+	fields["user"] = user
+	fields["action"] = action
+	log.Info("queue updated via API", fields)
+
+	detail, err := json.Marshal(fields)
+	if err != nil {
+		log.Error("failed to marshal API write", map[string]any{
+			log.FnError: err,
+		})
+		return
+	}
+	if err := s.storage().RegisterAuditRecord(ctx, cke.NewAuditRecord(user, action, targets, string(detail))); err != nil {
+		log.Error("failed to record API write", map[string]any{
+			log.FnError: err,
+			"user":      user,
+			"action":    action,
+		})
+	}
+}
+
+func (s Server) handleRebootQueueSubtree(w http.ResponseWriter, r *http.Request, sub string) {
+	switch {
+	case sub == "enable" && r.Method == http.MethodPost:
+		s.handleQueueSwitch(w, r, "reboot-queue", true, s.storage().EnableRebootQueue)
+	case sub == "disable" && r.Method == http.MethodPost:
+		s.handleQueueSwitch(w, r, "reboot-queue", false, s.storage().EnableRebootQueue)
+	case r.Method == http.MethodDelete:
+		s.handleRebootQueueCancel(w, r, sub)
+	default:
+		renderError(r.Context(), w, APIErrBadMethod)
+	}
+}
+
+func (s Server) handleRepairQueueSubtree(w http.ResponseWriter, r *http.Request, sub string) {
+	switch {
+	case sub == "enable" && r.Method == http.MethodPost:
+		s.handleQueueSwitch(w, r, "repair-queue", true, s.storage().EnableRepairQueue)
+	case sub == "disable" && r.Method == http.MethodPost:
+		s.handleQueueSwitch(w, r, "repair-queue", false, s.storage().EnableRepairQueue)
+	case r.Method == http.MethodDelete:
+		s.handleRepairQueueDelete(w, r, sub)
+	default:
+		renderError(r.Context(), w, APIErrBadMethod)
+	}
+}
+
+func (s Server) handleQueueSwitch(w http.ResponseWriter, r *http.Request, queue string, enable bool, fn func(context.Context, bool) error) {
+	user, ok := authorize(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	if err := fn(ctx, enable); err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	s.recordWrite(ctx, user, queue+"-switch", nil, map[string]any{
+		"enable": enable,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s Server) handleRebootQueueAdd(w http.ResponseWriter, r *http.Request) {
+	user, ok := authorize(w, r)
+	if !ok {
+		return
+	}
+
+	var req rebootQueueRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if len(req.Nodes) == 0 {
+		renderError(r.Context(), w, BadRequest("nodes must not be empty"))
+		return
+	}
+
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	storage := s.storage()
+	cluster, err := storage.GetCluster(ctx)
+	if err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	for _, node := range req.Nodes {
+		if !cke.NewRebootQueueEntry(node).ClusterMember(cluster) {
+			renderError(r.Context(), w, BadRequest(node+" is not a valid node IP address"))
+			return
+		}
+	}
+
+	entries := make([]*cke.RebootQueueEntry, len(req.Nodes))
+	for i, node := range req.Nodes {
+		entry := cke.NewRebootQueueEntry(node)
+		if err := storage.RegisterRebootsEntry(ctx, entry); err != nil {
+			renderError(r.Context(), w, InternalServerError(err))
+			return
+		}
+		s.recordWrite(ctx, user, "reboot-queue-add", []string{entry.Node}, map[string]any{
+			"index": entry.Index,
+			"node":  entry.Node,
+		})
+		entries[i] = entry
+	}
+
+	renderJSON(w, entries, http.StatusCreated)
+}
+
+func (s Server) handleRebootQueueCancel(w http.ResponseWriter, r *http.Request, sub string) {
+	user, ok := authorize(w, r)
+	if !ok {
+		return
+	}
+
+	index, err := strconv.ParseInt(sub, 10, 64)
+	if err != nil {
+		renderError(r.Context(), w, APIErrNotFound)
+		return
+	}
+
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	storage := s.storage()
+	entry, err := storage.GetRebootsEntry(ctx, index)
+	switch err {
+	case nil:
+	case cke.ErrNotFound:
+		renderError(r.Context(), w, APIErrNotFound)
+		return
+	default:
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+
+	entry.Status = cke.RebootStatusCancelled
+	err = storage.UpdateRebootsEntry(ctx, entry)
+	switch err {
+	case nil:
+	case cke.ErrNotFound:
+		renderError(r.Context(), w, APIErrNotFound)
+		return
+	default:
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	s.recordWrite(ctx, user, "reboot-queue-cancel", []string{entry.Node}, map[string]any{
+		"index": entry.Index,
+		"node":  entry.Node,
+	})
+
+	renderJSON(w, entry, http.StatusOK)
+}
+
+func (s Server) handleRepairQueueAdd(w http.ResponseWriter, r *http.Request) {
+	user, ok := authorize(w, r)
+	if !ok {
+		return
+	}
+
+	var req repairQueueRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Operation == "" || req.MachineType == "" || req.Address == "" {
+		renderError(r.Context(), w, BadRequest("operation, machine_type and address are required"))
+		return
+	}
+
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	storage := s.storage()
+	cluster, err := storage.GetCluster(ctx)
+	if err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+
+	entry := cke.NewRepairQueueEntry(req.Operation, req.MachineType, req.Address, req.Serial)
+	if _, err := entry.GetMatchingRepairOperation(cluster); err != nil {
+		renderError(r.Context(), w, BadRequest(err.Error()))
+		return
+	}
+	if err := storage.RegisterRepairsEntry(ctx, entry); err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+	s.recordWrite(ctx, user, "repair-queue-add", []string{entry.Address}, map[string]any{
+		"index":        entry.Index,
+		"address":      entry.Address,
+		"operation":    entry.Operation,
+		"machine_type": entry.MachineType,
+	})
+
+	renderJSON(w, entry, http.StatusCreated)
+}
+
+func (s Server) handleRepairQueueDelete(w http.ResponseWriter, r *http.Request, sub string) {
+	user, ok := authorize(w, r)
+	if !ok {
+		return
+	}
+
+	index, err := strconv.ParseInt(sub, 10, 64)
+	if err != nil {
+		renderError(r.Context(), w, APIErrNotFound)
+		return
+	}
+
+	ctx, cancel := s.withTimeout(r)
+	defer cancel()
+
+	storage := s.storage()
+	entry, err := storage.GetRepairsEntry(ctx, index)
+	switch err {
+	case nil:
+	case cke.ErrNotFound:
+		renderError(r.Context(), w, APIErrNotFound)
+		return
+	default:
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+
+	if !entry.Deleted {
+		entry.Deleted = true
+		err = storage.UpdateRepairsEntry(ctx, entry)
+		switch err {
+		case nil:
+		case cke.ErrNotFound:
+			renderError(r.Context(), w, APIErrNotFound)
+			return
+		default:
+			renderError(r.Context(), w, InternalServerError(err))
+			return
+		}
+		s.recordWrite(ctx, user, "repair-queue-delete", []string{entry.Address}, map[string]any{
+			"index":   entry.Index,
+			"address": entry.Address,
+		})
+	}
+
+	renderJSON(w, entry, http.StatusOK)
+}
+
+func splitSubtree(path, prefix string) (string, bool) {
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+	sub := path[len(prefix):]
+	if sub == "" || strings.Contains(sub, "/") {
+		return "", false
+	}
+	return sub, true
+}
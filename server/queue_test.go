@@ -0,0 +1,77 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cybozu-go/cke"
+)
+
+func TestAuthenticatedUser(t *testing.T) {
+	r := httptest.NewRequest("POST", "/reboots", nil)
+	if _, ok := authenticatedUser(r); ok {
+		t.Error("request without TLS must not be authenticated")
+	}
+
+	r.TLS = &tls.ConnectionState{}
+	if _, ok := authenticatedUser(r); ok {
+		t.Error("request without verified certificates must not be authenticated")
+	}
+
+	// certificates of etcd are also issued by the server CA.
+	r.TLS.VerifiedChains = [][]*x509.Certificate{{
+		{Subject: pkix.Name{CommonName: "10.0.0.1"}},
+	}}
+	if _, ok := authenticatedUser(r); ok {
+		t.Error("request with a certificate not for the API must not be authenticated")
+	}
+
+	r.TLS.VerifiedChains = [][]*x509.Certificate{{
+		{Subject: pkix.Name{CommonName: "node-lifecycle", OrganizationalUnit: []string{cke.APIClientOU}}},
+	}}
+	user, ok := authenticatedUser(r)
+	if !ok {
+		t.Fatal("request with a verified certificate must be authenticated")
+	}
+	if user != "node-lifecycle" {
+		t.Error("unexpected user name:", user)
+	}
+}
+
+func TestWriteAPIForbidden(t *testing.T) {
+	s := Server{}
+	cases := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/reboots"},
+		{http.MethodDelete, "/reboots/1"},
+		{http.MethodPost, "/reboots/enable"},
+		{http.MethodPost, "/reboots/disable"},
+		{http.MethodPost, "/repairs"},
+		{http.MethodDelete, "/repairs/1"},
+		{http.MethodPost, "/repairs/enable"},
+		{http.MethodPost, "/repairs/disable"},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.path, strings.NewReader("{}"))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403, actual %d", c.method, c.path, w.Code)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/reboots/enable", nil)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT /reboots/enable: expected 405, actual %d", w.Code)
+	}
+}
@@ -26,13 +26,61 @@ type health struct {
 }
 
 func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodGet && r.URL.Path == "/version" {
-		s.handleVersion(w, r)
-	} else if r.Method == http.MethodGet && r.URL.Path == "/health" {
-		s.handleHealth(w, r)
-	} else {
+	p := r.URL.Path
+	if sub, ok := splitSubtree(p, "/reboots/"); ok {
+		s.handleRebootQueueSubtree(w, r, sub)
+		return
+	}
+	if sub, ok := splitSubtree(p, "/repairs/"); ok {
+		s.handleRepairQueueSubtree(w, r, sub)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		switch p {
+		case "/reboots":
+			s.handleRebootQueueAdd(w, r)
+			return
+		case "/repairs":
+			s.handleRepairQueueAdd(w, r)
+			return
+		}
+	}
+
+	h := s.getHandler(p)
+	switch {
+	case h == nil:
 		renderError(r.Context(), w, APIErrNotFound)
+	case r.Method != http.MethodGet:
+		renderError(r.Context(), w, APIErrBadMethod)
+	default:
+		h(w, r)
+	}
+}
+
+// getHandler returns the handler of GET requests for path p, or nil if p is unknown.
+func (s Server) getHandler(p string) http.HandlerFunc {
+	switch p {
+	case "/version":
+		return s.handleVersion
+	case "/health":
+		return s.handleHealth
+	case "/status":
+		return s.handleStatus
+	case "/reboots":
+		return s.handleRebootQueue
+	case "/repairs":
+		return s.handleRepairQueue
+	case "/constraints":
+		return s.handleConstraints
+	case "/resources":
+		return s.handleResources
+	case "/records":
+		return s.handleRecords
+	case "/records/stream":
+		return s.handleRecordStream
 	}
+	return nil
 }
 
 func (s Server) handleVersion(w http.ResponseWriter, r *http.Request) {
@@ -2,6 +2,7 @@ package server
 
 import (
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/cybozu-go/log"
@@ -20,6 +21,11 @@ import (
 
 // DecideOps returns the next operations to do and the operation phase.
 // This returns nil when no operations need to be done.
+//
+// Phases listed in cs.SuspendedPhases do not run operations.
+// Phases up to k8s-start are prerequisites of the later ones, so DecideOps
+// stops there if such a phase is suspended.  The later phases are skipped.
+// In both cases PhaseSuspended is returned if no operations are decided.
 func DecideOps(c *cke.Cluster, cs *cke.ClusterStatus, constraints *cke.Constraints, resources []cke.ResourceDefinition, config *Config) ([]cke.Operator, cke.OperationPhase) {
 	nf := NewNodeFilter(c, cs)
 
@@ -30,6 +36,9 @@ func DecideOps(c *cke.Cluster, cs *cke.ClusterStatus, constraints *cke.Constrain
 			log.Warn("cannot upgrade for unreachable nodes", nil)
 			return nil, cke.PhaseUpgradeAborted
 		}
+		if isSuspended(cs, cke.PhaseUpgrade) {
+			return nil, cke.PhaseSuspended
+		}
 		return []cke.Operator{op.UpgradeOp(cs.ConfigVersion, nf.ControlPlaneNodes())}, cke.PhaseUpgrade
 	}
 
@@ -37,6 +46,9 @@ func DecideOps(c *cke.Cluster, cs *cke.ClusterStatus, constraints *cke.Constrain
 	// - CKE tools image is pulled on all nodes.
 	// - Rivers runs on all nodes and will proxy requests only to control plane nodes.
 	if ops := riversOps(c, nf, config.MaxConcurrentUpdates); len(ops) > 0 {
+		if isSuspended(cs, cke.PhaseRivers) {
+			return nil, cke.PhaseSuspended
+		}
 		return ops, cke.PhaseRivers
 	}
 
@@ -47,67 +59,128 @@ func DecideOps(c *cke.Cluster, cs *cke.ClusterStatus, constraints *cke.Constrain
 			log.Warn("cannot bootstrap etcd for unreachable nodes", nil)
 			return nil, cke.PhaseEtcdBootAborted
 		}
+		if isSuspended(cs, cke.PhaseEtcdBoot) {
+			return nil, cke.PhaseSuspended
+		}
 		return []cke.Operator{etcd.BootOp(nf.ControlPlaneNodes(), c.Options.Etcd)}, cke.PhaseEtcdBoot
 	}
 
 	// 3. Start etcd containers.
 	if nodes := nf.SSHConnected(nf.EtcdStopped(nf.ControlPlaneNodes())); len(nodes) > 0 {
+		if isSuspended(cs, cke.PhaseEtcdStart) {
+			return nil, cke.PhaseSuspended
+		}
 		return []cke.Operator{etcd.StartOp(nodes, c.Options.Etcd)}, cke.PhaseEtcdStart
 	}
 
 	// 4. Wait for etcd cluster to become ready
 	if !cs.Etcd.IsHealthy {
+		if isSuspended(cs, cke.PhaseEtcdWait) {
+			return nil, cke.PhaseSuspended
+		}
 		return []cke.Operator{etcd.WaitClusterOp(nf.ControlPlaneNodes())}, cke.PhaseEtcdWait
 	}
 
 	// 5. Run or restart kubernetes components.
 	if ops := k8sOps(c, nf, cs, config.MaxConcurrentUpdates); len(ops) > 0 {
+		if isSuspended(cs, cke.PhaseK8sStart) {
+			return nil, cke.PhaseSuspended
+		}
 		return ops, cke.PhaseK8sStart
 	}
 
+	// The following phases are independent of each other.
+	// Suspended ones are skipped and the next phase is examined.
+	var skipped bool
+
 	// 6. Maintain etcd cluster, only when all CPs are SSH reachable.
 	if len(nf.SSHNotConnected(nf.ControlPlaneNodes())) == 0 {
 		if o := etcdMaintOp(c, nf); o != nil {
-			return []cke.Operator{o}, cke.PhaseEtcdMaintain
+			if isSuspended(cs, cke.PhaseEtcdMaintain) {
+				skipped = true
+			} else {
+				return []cke.Operator{o}, cke.PhaseEtcdMaintain
+			}
 		}
 	}
 
 	// 7. Maintain k8s resources.
 	if ops := k8sMaintOps(c, cs, resources, nf); len(ops) > 0 {
-		return ops, cke.PhaseK8sMaintain
+		if isSuspended(cs, cke.PhaseK8sMaintain) {
+			skipped = true
+		} else {
+			return ops, cke.PhaseK8sMaintain
+		}
+	}
+
+	// The following phases use kube-apiserver.  Even if k8s-maintain is suspended,
+	// they must wait for all kube-apiservers to become healthy.
+	if slices.Contains(cs.SuspendedPhases, cke.PhaseK8sMaintain) {
+		if len(nf.APIServerUnhealthy(nf.ControlPlaneNodes())) > 0 || nf.HealthyAPIServer() == nil {
+			return nil, cke.PhaseSuspended
+		}
 	}
 
 	// 8. Stop and delete control plane services running on non control plane nodes.
 	if ops := cleanOps(c, nf); len(ops) > 0 {
-		return ops, cke.PhaseStopCP
+		if isSuspended(cs, cke.PhaseStopCP) {
+			skipped = true
+		} else {
+			return ops, cke.PhaseStopCP
+		}
 	}
 
 	// 9. Uncordon nodes if nodes are cordoned by CKE.
 	if o := rebootUncordonOp(cs, nf); o != nil {
-		return []cke.Operator{o}, cke.PhaseUncordonNodes
+		if isSuspended(cs, cke.PhaseUncordonNodes) {
+			skipped = true
+		} else {
+			return []cke.Operator{o}, cke.PhaseUncordonNodes
+		}
 	}
 
 	// 10. Repair machines if repair requests have been arrived to the repair queue, and the number of unreachable nodes is less than a threshold.
 	if ops, phaseRepair := repairOps(c, cs, constraints, nf); phaseRepair {
-		if !nf.EtcdIsGoodForRepair(constraints.ControlPlaneCount) {
-			log.Warn("cannot repair machines because etcd cluster is not responding, is out of sync without a control plane failure, or the control plane is degraded by more than one node", nil)
-			return nil, cke.PhaseRepairMachines
+		if isSuspended(cs, cke.PhaseRepairMachines) {
+			skipped = true
+		} else {
+			if !nf.EtcdIsGoodForRepair(constraints.ControlPlaneCount) {
+				log.Warn("cannot repair machines because etcd cluster is not responding, is out of sync without a control plane failure, or the control plane is degraded by more than one node", nil)
+				return nil, cke.PhaseRepairMachines
+			}
+			return ops, cke.PhaseRepairMachines
 		}
-		return ops, cke.PhaseRepairMachines
 	}
 
 	// 11. Reboot nodes if reboot request has been arrived to the reboot queue, and the number of unreachable nodes is less than a threshold.
 	if ops := rebootOps(c, cs, constraints, nf); len(ops) > 0 {
-		if !nf.EtcdIsGood() {
-			log.Warn("cannot reboot nodes because etcd cluster is not responding and in-sync", nil)
-			return nil, cke.PhaseRebootNodes
+		if isSuspended(cs, cke.PhaseRebootNodes) {
+			skipped = true
+		} else {
+			if !nf.EtcdIsGood() {
+				log.Warn("cannot reboot nodes because etcd cluster is not responding and in-sync", nil)
+				return nil, cke.PhaseRebootNodes
+			}
+			return ops, cke.PhaseRebootNodes
 		}
-		return ops, cke.PhaseRebootNodes
 	}
 
+	if skipped {
+		return nil, cke.PhaseSuspended
+	}
 	return nil, cke.PhaseCompleted
 }
 
+func isSuspended(cs *cke.ClusterStatus, phase cke.OperationPhase) bool {
+	if !slices.Contains(cs.SuspendedPhases, phase) {
+		return false
+	}
+	log.Info("operations are suspended", map[string]any{
+		"phase": phase,
+	})
+	return true
+}
+
 func riversOps(c *cke.Cluster, nf *NodeFilter, maxConcurrentUpdates int) (ops []cke.Operator) {
 	if nodes := nf.SSHConnected(nf.RiversStopped(nf.AllNodes())); len(nodes) > 0 {
 		max := min(len(nodes), maxConcurrentUpdates)
@@ -142,7 +215,7 @@ func apiserverOps(c *cke.Cluster, nf *NodeFilter, cs *cke.ClusterStatus) (ops []
 			ops = append(ops, masterEndpointOps(c, cs, nf, nil)...)
 		}
 		kubeletConfig := k8s.GenerateKubeletConfiguration(c.Options.Kubelet, "0.0.0.0", nil)
-		ops = append(ops, k8s.APIServerRestartOp(nodes, c.ServiceSubnet, c.Options.APIServer, kubeletConfig.ClusterDomain))
+		ops = append(ops, k8s.APIServerRestartOp(nodes, c.GetServiceSubnets(), c.FeatureGates, c.Options.APIServer, cs.Encryption, kubeletConfig.ClusterDomain))
 	}
 	if len(ops) > 0 {
 		return ops, true
@@ -166,7 +239,7 @@ func apiserverOps(c *cke.Cluster, nf *NodeFilter, cs *cke.ClusterStatus) (ops []
 		target := nodes[0] // just one
 		ops = append(ops, masterEndpointOps(c, cs, nf, []string{target.Address})...)
 		kubeletConfig := k8s.GenerateKubeletConfiguration(c.Options.Kubelet, "0.0.0.0", nil)
-		ops = append(ops, k8s.APIServerRestartOp([]*cke.Node{target}, c.ServiceSubnet, c.Options.APIServer, kubeletConfig.ClusterDomain))
+		ops = append(ops, k8s.APIServerRestartOp([]*cke.Node{target}, c.GetServiceSubnets(), c.FeatureGates, c.Options.APIServer, cs.Encryption, kubeletConfig.ClusterDomain))
 		return ops, true
 	}
 
@@ -176,7 +249,27 @@ func apiserverOps(c *cke.Cluster, nf *NodeFilter, cs *cke.ClusterStatus) (ops []
 	return ops, false
 }
 
+// kmsPluginOps returns operators to run the KMS plugin on control plane nodes.
+// The plugin must be running before kube-apiserver refers to it.
+func kmsPluginOps(c *cke.Cluster, nf *NodeFilter, cs *cke.ClusterStatus) (ops []cke.Operator) {
+	if !cke.KMSPluginEnabled(c.Options.APIServer, cs.Encryption) {
+		return nil
+	}
+
+	var nodes []*cke.Node
+	nodes = append(nodes, nf.SSHConnected(nf.KMSPluginStopped(nf.ControlPlaneNodes()))...)
+	nodes = append(nodes, nf.SSHConnected(nf.KMSPluginOutdated(nf.ControlPlaneNodes()))...)
+	if len(nodes) > 0 {
+		ops = append(ops, k8s.KMSPluginRestartOp(nodes, c.Options.APIServer.KMS))
+	}
+	return ops
+}
+
 func k8sOps(c *cke.Cluster, nf *NodeFilter, cs *cke.ClusterStatus, maxConcurrentUpdates int) (ops []cke.Operator) {
+	if ops := kmsPluginOps(c, nf, cs); len(ops) > 0 {
+		return ops
+	}
+
 	apiserverOps, skipOtherOps := apiserverOps(c, nf, cs)
 	if skipOtherOps {
 		return apiserverOps
@@ -185,39 +278,60 @@ func k8sOps(c *cke.Cluster, nf *NodeFilter, cs *cke.ClusterStatus, maxConcurrent
 
 	// Other CP components
 	if nodes := nf.SSHConnected(nf.ControllerManagerStopped(nf.ControlPlaneNodes())); len(nodes) > 0 {
-		ops = append(ops, k8s.ControllerManagerBootOp(nodes, c.Name, c.ServiceSubnet, c.Options.ControllerManager))
+		ops = append(ops, k8s.ControllerManagerBootOp(nodes, c.Name, c.GetServiceSubnets(), c.FeatureGates, c.Options.ControllerManager))
 	}
 	if nodes := nf.SSHConnected(nf.ControllerManagerOutdated(nf.ControlPlaneNodes())); len(nodes) > 0 {
-		ops = append(ops, k8s.ControllerManagerRestartOp(nodes, c.Name, c.ServiceSubnet, c.Options.ControllerManager))
+		ops = append(ops, k8s.ControllerManagerRestartOp(nodes, c.Name, c.GetServiceSubnets(), c.FeatureGates, c.Options.ControllerManager))
 	}
 	if nodes := nf.SSHConnected(nf.SchedulerStopped(nf.ControlPlaneNodes())); len(nodes) > 0 {
-		ops = append(ops, k8s.SchedulerBootOp(nodes, c.Name, c.Options.Scheduler))
+		ops = append(ops, k8s.SchedulerBootOp(nodes, c.Name, c.FeatureGates, c.Options.Scheduler))
 	}
 	if nodes := nf.SSHConnected(nf.SchedulerOutdated(nf.ControlPlaneNodes(), c.Options.Scheduler)); len(nodes) > 0 {
-		ops = append(ops, k8s.SchedulerRestartOp(nodes, c.Name, c.Options.Scheduler))
+		ops = append(ops, k8s.SchedulerRestartOp(nodes, c.Name, c.FeatureGates, c.Options.Scheduler))
 	}
 
 	// For all nodes
 	apiServer := nf.HealthyAPIServer()
+	// kubelet and kube-proxy ops are split by node groups as their params may differ.
 	if nodes := nf.SSHConnected(nf.KubeletUnrecognized(nf.AllNodes())); len(nodes) > 0 {
 		max := min(len(nodes), maxConcurrentUpdates)
-		ops = append(ops, k8s.KubeletRestartOp(nodes[:max], c.Name, c.Options.Kubelet, cs.NodeStatuses))
+		for _, group := range splitByNodeGroups(c, nodes[:max]) {
+			ops = append(ops, k8s.KubeletRestartOp(group, c.Name, c.KubeletParamsFor(group[0]), cs.NodeStatuses))
+		}
 	}
 	if nodes := nf.SSHConnected(nf.KubeletStopped(nf.AllNodes())); len(nodes) > 0 {
 		max := min(len(nodes), maxConcurrentUpdates)
-		ops = append(ops, k8s.KubeletBootOp(nodes[:max], nf.RegisteredNodes(nodes[:max]), apiServer, c.Name, c.Options.Kubelet, cs.NodeStatuses))
+		for _, group := range splitByNodeGroups(c, nodes[:max]) {
+			ops = append(ops, k8s.KubeletBootOp(group, nf.RegisteredNodes(group), apiServer, c.Name, c.KubeletParamsFor(group[0]), cs.NodeStatuses))
+		}
 	}
 	if nodes := nf.SSHConnected(nf.KubeletOutdated(nf.AllNodes())); len(nodes) > 0 && c.Options.Kubelet.InPlaceUpdate {
 		max := min(len(nodes), maxConcurrentUpdates)
-		ops = append(ops, k8s.KubeletRestartOp(nodes[:max], c.Name, c.Options.Kubelet, cs.NodeStatuses))
+		for _, group := range splitByNodeGroups(c, nodes[:max]) {
+			ops = append(ops, k8s.KubeletRestartOp(group, c.Name, c.KubeletParamsFor(group[0]), cs.NodeStatuses))
+		}
 	}
 	if nodes := nf.SSHConnected(nf.ProxyStopped(nf.AllNodes())); len(nodes) > 0 {
 		max := min(len(nodes), maxConcurrentUpdates)
-		ops = append(ops, k8s.KubeProxyBootOp(nodes[:max], c.Name, "", c.Options.Proxy))
+		for _, group := range splitByNodeGroups(c, nodes[:max]) {
+			ops = append(ops, k8s.KubeProxyBootOp(group, c.Name, "", c.ProxyParamsFor(group[0])))
+		}
 	}
-	if nodes := nf.SSHConnected(nf.ProxyOutdated(nf.AllNodes(), c.Options.Proxy)); len(nodes) > 0 {
+	if nodes := nf.SSHConnected(nf.ProxyOutdated(nf.AllNodes())); len(nodes) > 0 {
 		max := min(len(nodes), maxConcurrentUpdates)
-		ops = append(ops, k8s.KubeProxyRestartOp(nodes[:max], c.Name, "", c.Options.Proxy))
+		for _, group := range splitByNodeGroups(c, nodes[:max]) {
+			// the rules of the old mode need to be cleaned up when switching modes.
+			switched := nf.ProxyModeChanged(group)
+			if len(switched) > 0 {
+				ops = append(ops, k8s.KubeProxySwitchModeOp(switched, c.Name, "", c.ProxyParamsFor(group[0])))
+			}
+			others := slices.DeleteFunc(slices.Clone(group), func(n *cke.Node) bool {
+				return slices.Contains(switched, n)
+			})
+			if len(others) > 0 {
+				ops = append(ops, k8s.KubeProxyRestartOp(others, c.Name, "", c.ProxyParamsFor(group[0])))
+			}
+		}
 	}
 	if nodes := nf.SSHConnected(nf.ProxyRunningUnexpectedly(nf.AllNodes())); len(nodes) > 0 {
 		max := min(len(nodes), maxConcurrentUpdates)
@@ -276,7 +390,7 @@ func k8sMaintOps(c *cke.Cluster, cs *cke.ClusterStatus, resources []cke.Resource
 		return []cke.Operator{op.KubeWaitOp(apiServer)}
 	}
 
-	ops = append(ops, decideResourceOps(apiServer, c.TrustedRESTMappings, ks, resources, ks.IsReady(c))...)
+	ops = append(ops, decideResourceOps(apiServer, c.TrustedRESTMappings, c.Images, ks, resources, ks.IsReady(c))...)
 
 	ops = append(ops, decideClusterDNSOps(apiServer, c, ks)...)
 
@@ -292,9 +406,58 @@ func k8sMaintOps(c *cke.Cluster, cs *cke.ClusterStatus, resources []cke.Resource
 		ops = append(ops, op.KubeNodeRemoveOp(apiServer, nodes))
 	}
 
+	if o := encryptionRotationOp(c, cs, nf); o != nil {
+		ops = append(ops, o)
+	}
+
 	return ops
 }
 
+// encryptionRotationOp returns an operator to proceed the encryption key rotation.
+// Each step of the rotation requires that all API servers run with the keys
+// configured in the previous step.
+func encryptionRotationOp(c *cke.Cluster, cs *cke.ClusterStatus, nf *NodeFilter) cke.Operator {
+	st := cs.Encryption
+	if st == nil || !st.Rotation.InProgress() {
+		return nil
+	}
+
+	cps := nf.ControlPlaneNodes()
+	if len(nf.SSHNotConnected(cps)) > 0 || len(nf.APIServerUnhealthy(cps)) > 0 || len(nf.APIServerOutdated(cps)) > 0 {
+		return nil
+	}
+
+	switch st.Rotation.Phase {
+	case cke.EncryptionRotationAddKey:
+		return op.EncryptionPromoteKeyOp(st)
+	case cke.EncryptionRotationPromoteKey, cke.EncryptionRotationReencrypt:
+		return op.EncryptionReencryptOp(nf.HealthyAPIServer(), st, c.Options.APIServer.GetEncryptionResources())
+	case cke.EncryptionRotationDropKeys:
+		return op.EncryptionDropKeysOp(st)
+	}
+	return nil
+}
+
+// splitByNodeGroups splits nodes into batches of nodes belonging to the same node groups.
+// Nodes in a batch share the same effective component params.
+func splitByNodeGroups(c *cke.Cluster, nodes []*cke.Node) [][]*cke.Node {
+	var keys []string
+	batches := make(map[string][]*cke.Node)
+	for _, n := range nodes {
+		key := strings.Join(c.NodeGroupsFor(n), ",")
+		if _, ok := batches[key]; !ok {
+			keys = append(keys, key)
+		}
+		batches[key] = append(batches[key], n)
+	}
+
+	result := make([][]*cke.Node, len(keys))
+	for i, key := range keys {
+		result[i] = batches[key]
+	}
+	return result
+}
+
 func decideClusterDNSOps(apiServer *cke.Node, c *cke.Cluster, ks cke.KubernetesClusterStatus) (ops []cke.Operator) {
 	desiredDNSServers := c.DNSServers
 	if ks.DNSService != nil {
@@ -352,39 +515,41 @@ func decideNodeDNSOps(apiServer *cke.Node, c *cke.Cluster, ks cke.KubernetesClus
 }
 
 type endpointParams struct {
-	namespace   string
-	name        string
-	readyIPs    []string
-	notReadyIPs []string
-	port        int32
-	portName    string
-	serviceName string
+	namespace     string
+	name          string
+	readyNodes    []*cke.Node
+	notReadyNodes []*cke.Node
+	port          int32
+	portName      string
+	serviceName   string
 }
 
 func masterEndpointOps(c *cke.Cluster, cs *cke.ClusterStatus, nf *NodeFilter, markedAsNotReadyIPs []string) []cke.Operator {
-	var readyIPs, notReadyIPs []string
+	var readyNodes, notReadyNodes []*cke.Node
 
 	for _, n := range nf.APIServerHealthy(nf.ControlPlaneNodes()) {
 		if rebootProcessing(cs, n.Address) || slices.Contains(markedAsNotReadyIPs, n.Address) {
-			notReadyIPs = append(notReadyIPs, n.Address)
+			notReadyNodes = append(notReadyNodes, n)
 		} else {
-			readyIPs = append(readyIPs, n.Address)
+			readyNodes = append(readyNodes, n)
 		}
 	}
-	for _, n := range nf.APIServerUnhealthy(nf.ControlPlaneNodes()) {
-		notReadyIPs = append(notReadyIPs, n.Address)
-	}
+	notReadyNodes = append(notReadyNodes, nf.APIServerUnhealthy(nf.ControlPlaneNodes())...)
 
 	ep := &endpointParams{}
 	ep.namespace = metav1.NamespaceDefault
 	ep.name = "kubernetes"
-	ep.readyIPs = readyIPs
-	ep.notReadyIPs = notReadyIPs
+	ep.readyNodes = readyNodes
+	ep.notReadyNodes = notReadyNodes
 	ep.portName = "https"
 	ep.port = 6443
 	ep.serviceName = "kubernetes"
 
-	return decideEpEpsOps(ep, cs.Kubernetes.MasterEndpoints, cs.Kubernetes.MasterEndpointSlice, nf.HealthyAPIServer())
+	actualEPS := map[discoveryv1.AddressType]*discoveryv1.EndpointSlice{
+		discoveryv1.AddressTypeIPv4: cs.Kubernetes.MasterEndpointSlice,
+		discoveryv1.AddressTypeIPv6: cs.Kubernetes.MasterEndpointSliceIPv6,
+	}
+	return decideEpEpsOps(ep, cs.Kubernetes.MasterEndpoints, actualEPS, nf.HealthyAPIServer())
 }
 
 func etcdEndpointOps(c *cke.Cluster, cs *cke.ClusterStatus, nf *NodeFilter, markedAsNotReadyIPs []string) (ops []cke.Operator) {
@@ -396,42 +561,50 @@ func etcdEndpointOps(c *cke.Cluster, cs *cke.ClusterStatus, nf *NodeFilter, mark
 		ops = append(ops, svcOp)
 	}
 
-	var readyIPs, notReadyIPs []string
+	var readyNodes, notReadyNodes []*cke.Node
 	for _, n := range nf.ControlPlaneNodes() {
 		if rebootProcessing(cs, n.Address) || slices.Contains(markedAsNotReadyIPs, n.Address) {
-			notReadyIPs = append(notReadyIPs, n.Address)
+			notReadyNodes = append(notReadyNodes, n)
 		} else {
-			readyIPs = append(readyIPs, n.Address)
+			readyNodes = append(readyNodes, n)
 		}
 	}
 
 	ep := &endpointParams{}
 	ep.namespace = metav1.NamespaceSystem
 	ep.name = op.EtcdEndpointsName
-	ep.readyIPs = readyIPs
-	ep.notReadyIPs = notReadyIPs
+	ep.readyNodes = readyNodes
+	ep.notReadyNodes = notReadyNodes
 	ep.port = 2379
 	ep.serviceName = op.EtcdServiceName
 
-	ops = append(ops, decideEpEpsOps(ep, cs.Kubernetes.EtcdEndpoints, cs.Kubernetes.EtcdEndpointSlice, nf.HealthyAPIServer())...)
+	actualEPS := map[discoveryv1.AddressType]*discoveryv1.EndpointSlice{
+		discoveryv1.AddressTypeIPv4: cs.Kubernetes.EtcdEndpointSlice,
+		discoveryv1.AddressTypeIPv6: cs.Kubernetes.EtcdEndpointSliceIPv6,
+	}
+	ops = append(ops, decideEpEpsOps(ep, cs.Kubernetes.EtcdEndpoints, actualEPS, nf.HealthyAPIServer())...)
 
 	return ops
 }
 
+// decideEpEpsOps decides operations for Endpoints and EndpointSlices.
+// Endpoints contains the primary addresses of the nodes, whereas an EndpointSlice
+// is maintained for each address family of the primary and secondary addresses.
+//
 //nolint:staticcheck // code for Endpoints will be removed later
-func decideEpEpsOps(expect *endpointParams, actualEP *corev1.Endpoints, actualEPS *discoveryv1.EndpointSlice, apiserver *cke.Node) []cke.Operator {
+func decideEpEpsOps(expect *endpointParams, actualEP *corev1.Endpoints, actualEPS map[discoveryv1.AddressType]*discoveryv1.EndpointSlice, apiserver *cke.Node) []cke.Operator {
 	var ops []cke.Operator
 
-	readyAddresses := make([]corev1.EndpointAddress, len(expect.readyIPs))
-	for i, ip := range expect.readyIPs {
+	readyAddresses := make([]corev1.EndpointAddress, len(expect.readyNodes))
+	for i, n := range expect.readyNodes {
 		readyAddresses[i] = corev1.EndpointAddress{
-			IP: ip,
+			IP: n.Address,
 		}
 	}
-	notReadyAddresses := make([]corev1.EndpointAddress, len(expect.notReadyIPs))
-	for i, ip := range expect.notReadyIPs {
+	notReadyAddresses := make([]corev1.EndpointAddress, len(expect.notReadyNodes))
+	for i, n := range expect.notReadyNodes {
 		notReadyAddresses[i] = corev1.EndpointAddress{
-			IP: ip,
+			IP: n.Address,
 		}
 	}
 
@@ -461,40 +634,53 @@ func decideEpEpsOps(expect *endpointParams, actualEP *corev1.Endpoints, actualEP
 	}
 
 	ready := make(map[string]bool)
-	for _, ip := range expect.readyIPs {
-		ready[ip] = true
+	addrs := make(map[discoveryv1.AddressType][]string)
+	for _, n := range expect.readyNodes {
+		for _, a := range n.Addresses() {
+			ready[a] = true
+			addrs[cke.IPAddressType(a)] = append(addrs[cke.IPAddressType(a)], a)
+		}
 	}
-	for _, ip := range expect.notReadyIPs {
-		ready[ip] = false
+	for _, n := range expect.notReadyNodes {
+		for _, a := range n.Addresses() {
+			ready[a] = false
+			addrs[cke.IPAddressType(a)] = append(addrs[cke.IPAddressType(a)], a)
+		}
 	}
-	addrs := slices.Concat(expect.readyIPs, expect.notReadyIPs)
-	slices.Sort(addrs)
-	addrs = slices.Compact(addrs)
 
-	eps := &discoveryv1.EndpointSlice{}
-	eps.Namespace = expect.namespace
-	eps.Name = expect.name
-	eps.Labels = map[string]string{
-		"endpointslice.kubernetes.io/managed-by": "cke.cybozu.com",
-		"kubernetes.io/service-name":             expect.serviceName,
-	}
-	eps.AddressType = discoveryv1.AddressTypeIPv4
-	eps.Endpoints = make([]discoveryv1.Endpoint, len(addrs))
-	for i, ip := range addrs {
-		eps.Endpoints[i] = discoveryv1.Endpoint{
-			Addresses:  []string{ip},
-			Conditions: discoveryv1.EndpointConditions{Ready: new(ready[ip])},
+	for _, addressType := range []discoveryv1.AddressType{discoveryv1.AddressTypeIPv4, discoveryv1.AddressTypeIPv6} {
+		typeAddrs := addrs[addressType]
+		if len(typeAddrs) == 0 && actualEPS[addressType] == nil {
+			continue
+		}
+		slices.Sort(typeAddrs)
+		typeAddrs = slices.Compact(typeAddrs)
+
+		eps := &discoveryv1.EndpointSlice{}
+		eps.Namespace = expect.namespace
+		eps.Name = op.EndpointSliceName(expect.name, addressType)
+		eps.Labels = map[string]string{
+			"endpointslice.kubernetes.io/managed-by": "cke.cybozu.com",
+			"kubernetes.io/service-name":             expect.serviceName,
+		}
+		eps.AddressType = addressType
+		eps.Endpoints = make([]discoveryv1.Endpoint, len(typeAddrs))
+		for i, ip := range typeAddrs {
+			eps.Endpoints[i] = discoveryv1.Endpoint{
+				Addresses:  []string{ip},
+				Conditions: discoveryv1.EndpointConditions{Ready: new(ready[ip])},
+			}
+		}
+		eps.Ports = []discoveryv1.EndpointPort{
+			{
+				Name: &expect.portName,
+				Port: &expect.port,
+			},
+		}
+		epsOp := decideEpsOp(eps, actualEPS[addressType], apiserver)
+		if epsOp != nil {
+			ops = append(ops, epsOp)
 		}
-	}
-	eps.Ports = []discoveryv1.EndpointPort{
-		{
-			Name: &expect.portName,
-			Port: &expect.port,
-		},
-	}
-	epsOp := decideEpsOp(eps, actualEPS, apiserver)
-	if epsOp != nil {
-		ops = append(ops, epsOp)
 	}
 
 	return ops
@@ -628,8 +814,9 @@ func decideEtcdServiceOps(apiServer *cke.Node, svc *corev1.Service) cke.Operator
 	return nil
 }
 
-func decideResourceOps(apiServer *cke.Node, trustedMappings []cke.TrustedRESTMapping, ks cke.KubernetesClusterStatus, resources []cke.ResourceDefinition, isReady bool) (ops []cke.Operator) {
+func decideResourceOps(apiServer *cke.Node, trustedMappings []cke.TrustedRESTMapping, images *cke.ImageOverrides, ks cke.KubernetesClusterStatus, resources []cke.ResourceDefinition, isReady bool) (ops []cke.Operator) {
 	for _, res := range static.Resources {
+		res = images.ResolveResource(res)
 		// To avoid thundering herd problem. Deployments need to be created only after enough nodes become ready.
 		if res.Kind == cke.KindDeployment && !isReady {
 			continue
@@ -652,10 +839,18 @@ func decideResourceOps(apiServer *cke.Node, trustedMappings []cke.TrustedRESTMap
 }
 
 func cleanOps(c *cke.Cluster, nf *NodeFilter) (ops []cke.Operator) {
-	var apiServers, controllerManagers, schedulers, etcds, etcdRivers []*cke.Node
+	var apiServers, controllerManagers, schedulers, etcds, etcdRivers, kmsPlugins []*cke.Node
 
+	// The KMS plugin is stopped also on control plane nodes once no API servers refer to it.
+	kmsEnabled := cke.KMSPluginEnabled(c.Options.APIServer, nf.status.Encryption)
 	for _, n := range c.Nodes {
-		if !nf.status.NodeStatuses[n.Address].SSHConnected || n.ControlPlane {
+		if !nf.status.NodeStatuses[n.Address].SSHConnected {
+			continue
+		}
+		if nf.nodeStatus(n).KMSPlugin.Running && (!n.ControlPlane || !kmsEnabled) {
+			kmsPlugins = append(kmsPlugins, n)
+		}
+		if n.ControlPlane {
 			continue
 		}
 
@@ -692,6 +887,9 @@ func cleanOps(c *cke.Cluster, nf *NodeFilter) (ops []cke.Operator) {
 	if len(etcdRivers) > 0 {
 		ops = append(ops, op.EtcdRiversStopOp(etcdRivers))
 	}
+	if len(kmsPlugins) > 0 {
+		ops = append(ops, op.KMSPluginStopOp(kmsPlugins))
+	}
 	return ops
 }
 
@@ -834,7 +1032,7 @@ func repairOps(c *cke.Cluster, cs *cke.ClusterStatus, constraints *cke.Constrain
 				continue
 			}
 			if !(step.NeedDrain && entry.IsInCluster()) {
-				ops = append(ops, op.RepairExecuteOp(entry, step, c))
+				ops = append(ops, op.RepairExecuteOp(nf.HealthyAPIServer(), entry, step, c))
 				continue
 			}
 			// DrainBackOffExpire has been confirmed, so start drain now.
@@ -845,7 +1043,7 @@ func repairOps(c *cke.Cluster, cs *cke.ClusterStatus, constraints *cke.Constrain
 				continue
 			}
 			if rqs.DrainCompleted[entry.Address] {
-				ops = append(ops, op.RepairExecuteOp(entry, step, c))
+				ops = append(ops, op.RepairExecuteOp(nf.HealthyAPIServer(), entry, step, c))
 				continue
 			}
 			if entry.LastTransitionTime.Before(evictionStartLimit) {
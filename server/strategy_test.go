@@ -11,6 +11,8 @@ import (
 	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+	kcmv1alpha1 "k8s.io/kube-controller-manager/config/v1alpha1"
 	proxyv1alpha1 "k8s.io/kube-proxy/config/v1alpha1"
 	schedulerv1 "k8s.io/kube-scheduler/config/v1"
 	kubeletv1beta1 "k8s.io/kubelet/config/v1beta1"
@@ -30,6 +32,10 @@ const (
 	testDefaultDNSDomain     = "cluster.local"
 	testDefaultDNSAddr       = "10.0.0.53"
 	testMaxConcurrentUpdates = 5
+
+	testKubernetesImageOverride      = "registry.example.com/kubernetes@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	testCoreDNSImageOverride         = "registry.example.com/coredns@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	testUnboundExporterImageOverride = "registry.example.com/unbound_exporter@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
 )
 
 var (
@@ -98,6 +104,18 @@ func (d testData) NodeStatus(n *cke.Node) *cke.NodeStatus {
 	return d.Status.NodeStatuses[n.Address]
 }
 
+func testEncryptionStatus(phase cke.EncryptionRotationPhase) *cke.EncryptionStatus {
+	oldKey := cke.EncryptionKeyInfo{Name: "old", Provider: cke.EncryptionProviderAESCBC}
+	newKey := cke.EncryptionKeyInfo{Name: "new", Provider: cke.EncryptionProviderAESGCM}
+	return &cke.EncryptionStatus{
+		Keys: []cke.EncryptionKeyInfo{oldKey, newKey},
+		Rotation: &cke.EncryptionKeyRotation{
+			Phase:  phase,
+			NewKey: newKey,
+		},
+	}
+}
+
 func newData() testData {
 	cluster := &cke.Cluster{
 		Name: testClusterName,
@@ -208,6 +226,16 @@ func (d testData) withEtcdRivers() testData {
 	return d
 }
 
+func (d testData) withKMSPlugin() testData {
+	for _, n := range d.ControlPlane() {
+		st := &d.NodeStatus(n).KMSPlugin
+		st.Running = true
+		st.Image = cke.ToolsImage.Name()
+		st.BuiltInParams = k8s.KMSPluginParams()
+	}
+	return d
+}
+
 func (d testData) withInitFailedEtcd() testData {
 	for _, n := range d.ControlPlane() {
 		d.NodeStatus(n).Etcd.HasData = true
@@ -267,7 +295,7 @@ func (d testData) withAPIServer(serviceSubnet, domain string) testData {
 		st.Running = true
 		st.IsHealthy = true
 		st.Image = cke.KubernetesImage.Name()
-		st.BuiltInParams = k8s.APIServerParams(n.Address, serviceSubnet, false, "", "", domain)
+		st.BuiltInParams = k8s.APIServerParams(n.Address, []string{serviceSubnet}, nil, cke.APIServerParams{}, d.Status.Encryption, domain)
 	}
 	return d
 }
@@ -284,7 +312,7 @@ func (d testData) withControllerManager(name, serviceSubnet string) testData {
 		st.Running = true
 		st.IsHealthy = true
 		st.Image = cke.KubernetesImage.Name()
-		st.BuiltInParams = k8s.ControllerManagerParams(name, serviceSubnet)
+		st.BuiltInParams = k8s.ControllerManagerParams(name, []string{serviceSubnet}, nil, d.Cluster.Options.ControllerManager)
 	}
 	return d
 }
@@ -295,7 +323,7 @@ func (d testData) withScheduler() testData {
 		st.Running = true
 		st.IsHealthy = true
 		st.Image = cke.KubernetesImage.Name()
-		st.BuiltInParams = k8s.SchedulerParams()
+		st.BuiltInParams = k8s.SchedulerParams(nil)
 
 		st.Config = &schedulerv1.KubeSchedulerConfiguration{}
 		st.Config.Parallelism = new(int32(999))
@@ -707,6 +735,16 @@ func (d testData) withRebootCancelled(entries []*cke.RebootQueueEntry) testData
 	return d
 }
 
+func (d testData) withSuspendedPhases(phases ...cke.OperationPhase) testData {
+	d.Status.SuspendedPhases = phases
+	return d
+}
+
+func (d testData) withNodeGroup(g cke.NodeGroup) testData {
+	d.Cluster.NodeGroups = append(d.Cluster.NodeGroups, g)
+	return d
+}
+
 func (d testData) withDisableProxy() testData {
 	d.Cluster.Options.Proxy.Disable = true
 	return d
@@ -1042,6 +1080,304 @@ func TestDecideOps(t *testing.T) {
 			},
 			ExpectedPhase: cke.PhaseK8sMaintain,
 		},
+		{
+			Name: "RestartAPIServerAuthorization",
+			Input: newData().withAllServices().with(func(d testData) {
+				d.Cluster.Options.APIServer.Authorization = &cke.AuthorizationParams{
+					Authorizers: []cke.Authorizer{
+						{Type: cke.AuthorizerNode, Name: "node"},
+						{Type: cke.AuthorizerWebhook, Name: "external", Webhook: &cke.AuthorizationWebhook{Server: "https://authz.example.com"}},
+						{Type: cke.AuthorizerRBAC, Name: "rbac"},
+					},
+				}
+			}),
+			ExpectedOps: []opData{
+				{"update-kubernetes-endpoints", 1},
+				{"update-kubernetes-endpointslice", 1},
+				{"kube-apiserver-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "RestartAPIServerAdmissionPlugins",
+			Input: newData().withAllServices().with(func(d testData) {
+				cfg := &unstructured.Unstructured{}
+				cfg.SetAPIVersion("pod-security.admission.config.k8s.io/v1")
+				cfg.SetKind("PodSecurityConfiguration")
+				cfg.Object["defaults"] = map[string]any{"enforce": "baseline"}
+				d.Cluster.Options.APIServer.AdmissionPlugins = []cke.AdmissionPluginConfig{{Name: "PodSecurity", Configuration: cfg}}
+			}),
+			ExpectedOps: []opData{
+				{"update-kubernetes-endpoints", 1},
+				{"update-kubernetes-endpointslice", 1},
+				{"kube-apiserver-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "RestartAPIServerAuditWebhook",
+			Input: newData().withAllServices().with(func(d testData) {
+				d.Cluster.Options.APIServer.AuditLogPolicy = "policy"
+				d.Cluster.Options.APIServer.AuditWebhook = &cke.AuditWebhookParams{Server: "https://audit.example.com"}
+			}),
+			ExpectedOps: []opData{
+				{"update-kubernetes-endpoints", 1},
+				{"update-kubernetes-endpointslice", 1},
+				{"kube-apiserver-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "RestartFeatureGates",
+			Input: newData().withAllServices().with(func(d testData) {
+				d.Cluster.FeatureGates = map[string]bool{"InPlacePodVerticalScaling": false}
+			}),
+			ExpectedOps: []opData{
+				// kube-apiservers are restarted first.
+				{"update-kubernetes-endpoints", 1},
+				{"update-kubernetes-endpointslice", 1},
+				{"kube-apiserver-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "RestartFeatureGatesAfterAPIServer",
+			Input: newData().withAllServices().with(func(d testData) {
+				d.Cluster.FeatureGates = map[string]bool{"InPlacePodVerticalScaling": false}
+				for _, n := range d.ControlPlane() {
+					st := &d.NodeStatus(n).APIServer
+					st.BuiltInParams = k8s.APIServerParams(n.Address, []string{testServiceSubnet}, d.Cluster.FeatureGates, cke.APIServerParams{}, d.Status.Encryption, testDefaultDNSDomain)
+				}
+			}),
+			ExpectedOps: []opData{
+				{"kube-controller-manager-restart", 3},
+				{"kube-scheduler-restart", 3},
+				{"kubelet-restart", 5},
+				{"kube-proxy-restart", 5},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "RestartImageOverrides",
+			Input: newData().withAllServices().with(func(d testData) {
+				d.Cluster.Images = &cke.ImageOverrides{
+					Overrides: map[string]string{cke.KubernetesImage.Name(): testKubernetesImageOverride},
+				}
+			}),
+			ExpectedOps: []opData{
+				// kube-apiservers are restarted first.
+				{"update-kubernetes-endpoints", 1},
+				{"update-kubernetes-endpointslice", 1},
+				{"kube-apiserver-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "RestartImageOverridesAfterAPIServer",
+			Input: newData().withAllServices().with(func(d testData) {
+				d.Cluster.Images = &cke.ImageOverrides{
+					Overrides: map[string]string{cke.KubernetesImage.Name(): testKubernetesImageOverride},
+				}
+				for _, n := range d.ControlPlane() {
+					d.NodeStatus(n).APIServer.Image = testKubernetesImageOverride
+				}
+			}),
+			ExpectedOps: []opData{
+				{"kube-controller-manager-restart", 3},
+				{"kube-scheduler-restart", 3},
+				{"kubelet-restart", 5},
+				{"kube-proxy-restart", 5},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "ImageOverridesCompleted",
+			Input: newData().with(func(d testData) {
+				d.Cluster.Images = &cke.ImageOverrides{
+					Overrides: map[string]string{cke.KubernetesImage.Name(): testKubernetesImageOverride},
+				}
+			}).withK8sResourceReady().with(func(d testData) {
+				for _, n := range d.Cluster.Nodes {
+					st := d.NodeStatus(n)
+					st.Kubelet.Image = testKubernetesImageOverride
+					st.Proxy.Image = testKubernetesImageOverride
+					if n.ControlPlane {
+						st.APIServer.Image = testKubernetesImageOverride
+						st.ControllerManager.Image = testKubernetesImageOverride
+						st.Scheduler.Image = testKubernetesImageOverride
+					}
+				}
+			}),
+			ExpectedPhase: cke.PhaseCompleted,
+		},
+		{
+			Name: "ImageOverridesDNSResources",
+			Input: newData().withK8sResourceReady().with(func(d testData) {
+				d.Cluster.Images = &cke.ImageOverrides{
+					Overrides: map[string]string{
+						cke.CoreDNSImage.Name():         testCoreDNSImageOverride,
+						cke.UnboundExporterImage.Name(): testUnboundExporterImageOverride,
+					},
+				}
+			}),
+			ExpectedOps: []opData{
+				// cluster-dns and node-dns
+				{"resource-apply", 1},
+				{"resource-apply", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sMaintain,
+		},
+		{
+			Name: "ImageOverridesDNSResourcesCompleted",
+			Input: newData().withK8sResourceReady().with(func(d testData) {
+				d.Cluster.Images = &cke.ImageOverrides{
+					Overrides: map[string]string{
+						cke.CoreDNSImage.Name():         testCoreDNSImageOverride,
+						cke.UnboundExporterImage.Name(): testUnboundExporterImageOverride,
+					},
+				}
+				ks := &d.Status.Kubernetes
+				ks.ResourceStatuses["Deployment/kube-system/cluster-dns"].Annotations[cke.AnnotationResourceImage] = testCoreDNSImageOverride
+				ks.ResourceStatuses["DaemonSet/kube-system/node-dns"].Annotations[cke.AnnotationResourceImage] = cke.UnboundImage.Name() + "," + testUnboundExporterImageOverride
+			}),
+			ExpectedPhase: cke.PhaseCompleted,
+		},
+		{
+			Name: "RestartAPIServerAuthenticationConfig",
+			Input: newData().withAllServices().with(func(d testData) {
+				cfg := &unstructured.Unstructured{}
+				cfg.SetGroupVersionKind(apiserverv1.SchemeGroupVersion.WithKind("AuthenticationConfiguration"))
+				cfg.Object["jwt"] = []any{map[string]any{
+					"issuer":        map[string]any{"url": "https://issuer.example.com", "audiences": []any{"cke"}},
+					"claimMappings": map[string]any{"username": map[string]any{"claim": "sub"}},
+				}}
+				d.Cluster.Options.APIServer.AuthenticationConfig = cfg
+			}),
+			ExpectedOps: []opData{
+				// kube-apiservers are restarted one by one.
+				{"update-kubernetes-endpoints", 1},
+				{"update-kubernetes-endpointslice", 1},
+				{"kube-apiserver-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "EncryptionRotationAddKey",
+			Input: newData().withAllServices().with(func(d testData) {
+				d.Status.Encryption = testEncryptionStatus(cke.EncryptionRotationAddKey)
+			}),
+			ExpectedOps: []opData{
+				// kube-apiservers are restarted with the new key.
+				{"update-kubernetes-endpoints", 1},
+				{"update-kubernetes-endpointslice", 1},
+				{"kube-apiserver-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "EncryptionRotationPromoteKey",
+			Input: newData().with(func(d testData) {
+				d.Status.Encryption = testEncryptionStatus(cke.EncryptionRotationAddKey)
+			}).withK8sResourceReady(),
+			ExpectedOps: []opData{
+				{"encryption-promote-key", 0},
+			},
+			ExpectedPhase: cke.PhaseK8sMaintain,
+		},
+		{
+			Name: "EncryptionRotationReencrypt",
+			Input: newData().with(func(d testData) {
+				d.Status.Encryption = testEncryptionStatus(cke.EncryptionRotationPromoteKey)
+			}).withK8sResourceReady(),
+			ExpectedOps: []opData{
+				{"encryption-reencrypt", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sMaintain,
+		},
+		{
+			Name: "EncryptionRotationDropKeys",
+			Input: newData().with(func(d testData) {
+				d.Status.Encryption = testEncryptionStatus(cke.EncryptionRotationDropKeys)
+			}).withK8sResourceReady(),
+			ExpectedOps: []opData{
+				{"encryption-drop-keys", 0},
+			},
+			ExpectedPhase: cke.PhaseK8sMaintain,
+		},
+		{
+			Name: "EncryptionRotationWaitAPIServer",
+			Input: newData().with(func(d testData) {
+				d.Status.Encryption = testEncryptionStatus(cke.EncryptionRotationAddKey)
+			}).withK8sResourceReady().with(func(d testData) {
+				d.NodeStatus(d.ControlPlane()[0]).APIServer.IsHealthy = false
+			}),
+			ExpectedOps: []opData{
+				// the rotation does not proceed while an API server is unhealthy.
+				{"update-kubernetes-endpoints", 1},
+				{"update-kubernetes-endpointslice", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "EncryptionRotationCompleted",
+			Input: newData().with(func(d testData) {
+				d.Status.Encryption = testEncryptionStatus(cke.EncryptionRotationCompleted)
+			}).withK8sResourceReady(),
+			ExpectedPhase: cke.PhaseCompleted,
+		},
+		{
+			Name: "KMSPluginStart",
+			Input: newData().withAllServices().with(func(d testData) {
+				d.Cluster.Options.APIServer.KMS = &cke.KMSParams{}
+			}),
+			ExpectedOps: []opData{
+				{"kms-plugin-restart", 3},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "KMSPluginRestartAPIServer",
+			Input: newData().withAllServices().withKMSPlugin().with(func(d testData) {
+				d.Cluster.Options.APIServer.KMS = &cke.KMSParams{}
+			}),
+			ExpectedOps: []opData{
+				// kube-apiservers are restarted to mount the socket directory.
+				{"update-kubernetes-endpoints", 1},
+				{"update-kubernetes-endpointslice", 1},
+				{"kube-apiserver-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "KMSPluginOutdated",
+			Input: newData().withAllServices().withKMSPlugin().with(func(d testData) {
+				d.Cluster.Options.APIServer.KMS = &cke.KMSParams{
+					ServiceParams: cke.ServiceParams{ExtraArguments: []string{"--timeout=5s"}},
+				}
+				d.NodeStatus(d.ControlPlane()[0]).KMSPlugin.Image = "ghcr.io/cybozu-go/cke-tools:old"
+			}),
+			ExpectedOps: []opData{
+				{"kms-plugin-restart", 3},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name:  "KMSPluginStop",
+			Input: newData().withK8sResourceReady().withKMSPlugin(),
+			ExpectedOps: []opData{
+				{"stop-kms-plugin", 3},
+			},
+			ExpectedPhase: cke.PhaseStopCP,
+		},
+		{
+			Name: "KMSPluginKeepForDecryption",
+			Input: newData().with(func(d testData) {
+				d.Status.Encryption = &cke.EncryptionStatus{
+					Keys: []cke.EncryptionKeyInfo{{Name: "vault-20260102T030405Z", Provider: cke.EncryptionProviderKMS}},
+				}
+			}).withK8sResourceReady().withKMSPlugin(),
+			ExpectedPhase: cke.PhaseCompleted,
+		},
 		{
 			Name: "SkipK8sOps",
 			Input: newData().withK8sResourceReady().withRepairConfig().withRepairEntries([]*cke.RepairQueueEntry{
@@ -1164,6 +1500,19 @@ func TestDecideOps(t *testing.T) {
 			},
 			ExpectedPhase: cke.PhaseK8sStart,
 		},
+		{
+			Name: "RestartControllerManagerConfig",
+			Input: newData().withAllServices().with(func(d testData) {
+				cfg := &unstructured.Unstructured{}
+				cfg.SetGroupVersionKind(kcmv1alpha1.SchemeGroupVersion.WithKind("KubeControllerManagerConfiguration"))
+				cfg.Object["Generic"] = map[string]any{"Controllers": []any{"*", "bootstrapsigner"}}
+				d.Cluster.Options.ControllerManager.Config = cfg
+			}),
+			ExpectedOps: []opData{
+				{"kube-controller-manager-restart", 3},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
 		{
 			Name: "RestartScheduler",
 			Input: newData().withAllServices().with(func(d testData) {
@@ -1363,6 +1712,51 @@ func TestDecideOps(t *testing.T) {
 			},
 			ExpectedPhase: cke.PhaseK8sMaintain,
 		},
+		{
+			Name: "RestartKubeletNodeGroup",
+			Input: newData().withAllServices().withNodeGroup(cke.NodeGroup{
+				Name:         "storage",
+				NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"label1": "value"}},
+				Kubelet: &cke.KubeletOverride{
+					ServiceParams: cke.ServiceParams{ExtraArguments: []string{"--v=4"}},
+				},
+			}),
+			ExpectedOps: []opData{
+				// Only the node in the group is outdated.
+				{"kubelet-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "RestartKubeletNodeGroup2",
+			Input: newData().withAllServices().withKubelet("foo.local", "10.0.0.53", false).withNodeGroup(cke.NodeGroup{
+				Name:  "edge",
+				Nodes: []string{nodeNames[1], nodeNames[4]},
+				Kubelet: &cke.KubeletOverride{
+					ServiceParams: cke.ServiceParams{ExtraArguments: []string{"--v=4"}},
+				},
+			}),
+			ExpectedOps: []opData{
+				// Nodes are split by node groups.
+				{"kubelet-restart", 3},
+				{"kubelet-restart", 2},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "RestartProxyNodeGroup",
+			Input: newData().withAllServices().withNodeGroup(cke.NodeGroup{
+				Name:  "edge",
+				Nodes: []string{nodeNames[4]},
+				Proxy: &cke.ProxyOverride{
+					ServiceParams: cke.ServiceParams{ExtraEnvvar: map[string]string{"FOO": "bar"}},
+				},
+			}),
+			ExpectedOps: []opData{
+				{"kube-proxy-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
 		{
 			Name: "RestartProxy",
 			Input: newData().withAllServices().with(func(d testData) {
@@ -1438,11 +1832,40 @@ func TestDecideOps(t *testing.T) {
 				d.NodeStatus(d.ControlPlane()[0]).Proxy.Config.Mode = cke.ProxyModeIPVS
 				d.NodeStatus(d.NonCPWorkers()[0]).Proxy.Config.Mode = cke.ProxyModeIPVS
 			}),
+			ExpectedOps: []opData{
+				// the rules of IPVS mode should be cleaned up.
+				{"kube-proxy-switch-mode", 2},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "RestartProxy8",
+			Input: newData().withAllServices().with(func(d testData) {
+				// iptables mode is the default.
+				d.NodeStatus(d.ControlPlane()[0]).Proxy.Config.Mode = cke.ProxyModeIptables
+				d.NodeStatus(d.NonCPWorkers()[0]).Proxy.ExtraParams.ExtraArguments = []string{"foo"}
+			}),
 			ExpectedOps: []opData{
 				{"kube-proxy-restart", 2},
 			},
 			ExpectedPhase: cke.PhaseK8sStart,
 		},
+		{
+			Name: "SwitchProxyMode",
+			Input: newData().withAllServices().with(func(d testData) {
+				cfg := &unstructured.Unstructured{}
+				cfg.SetGroupVersionKind(proxyv1alpha1.SchemeGroupVersion.WithKind("KubeProxyConfiguration"))
+				cfg.Object["mode"] = string(cke.ProxyModeNFTables)
+				d.Cluster.Options.Proxy.Config = cfg
+				d.NodeStatus(d.ControlPlane()[0]).Proxy.Config.Mode = cke.ProxyModeNFTables
+				d.NodeStatus(d.ControlPlane()[1]).Proxy.Config = nil
+			}),
+			ExpectedOps: []opData{
+				{"kube-proxy-switch-mode", 4},
+				{"kube-proxy-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
 		{
 			Name:  "StopProxy",
 			Input: newData().withAllServices().withDisableProxy(),
@@ -1590,6 +2013,31 @@ func TestDecideOps(t *testing.T) {
 			ExpectedOps:   []opData{{"update-kubernetes-endpointslice", 1}},
 			ExpectedPhase: cke.PhaseK8sStart,
 		},
+		{
+			Name: "MasterEndpointSliceIPv6Create",
+			Input: newData().with(func(d testData) {
+				for i, n := range d.ControlPlane() {
+					n.SecondaryAddress = []string{"fd00::11", "fd00::12", "fd00::13"}[i]
+				}
+			}).withK8sResourceReady().with(func(d testData) {
+				for _, n := range d.ControlPlane() {
+					d.NodeStatus(n).Proxy.Config.BindAddress = n.Address
+				}
+			}),
+			ExpectedOps:   []opData{{"create-kubernetes-ipv6-endpointslice", 1}},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
+		{
+			Name: "RestartKubeletAndProxyDualStack",
+			Input: newData().withK8sResourceReady().with(func(d testData) {
+				d.Cluster.Nodes[4].SecondaryAddress = "fd00::15"
+			}),
+			ExpectedOps: []opData{
+				{"kubelet-restart", 1},
+				{"kube-proxy-restart", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sStart,
+		},
 		{
 			Name: "EtcdServiceUpdate",
 			Input: newData().withK8sResourceReady().with(func(d testData) {
@@ -3512,6 +3960,68 @@ func TestDecideOps(t *testing.T) {
 			},
 			ExpectedPhase: cke.PhaseRebootNodes,
 		},
+		{
+			Name:          "SuspendRivers",
+			Input:         newData().withSuspendedPhases(cke.PhaseRivers),
+			ExpectedOps:   nil,
+			ExpectedPhase: cke.PhaseSuspended,
+		},
+		{
+			Name: "SuspendK8sStart",
+			Input: newData().withHealthyEtcd().withRivers().withEtcdRivers().
+				withSuspendedPhases(cke.PhaseK8sStart, cke.PhaseRebootNodes),
+			ExpectedOps:   nil,
+			ExpectedPhase: cke.PhaseSuspended,
+		},
+		{
+			Name: "SuspendEtcdMaintain",
+			Input: newData().withK8sResourceReady().with(func(d testData) {
+				d.Status.Etcd.Members["10.0.0.100"] = &etcdserverpb.Member{Name: "10.0.0.100", ID: 3}
+				d.Status.Etcd.InSyncMembers["10.0.0.100"] = false
+			}).withSuspendedPhases(cke.PhaseEtcdMaintain),
+			ExpectedOps:   nil,
+			ExpectedPhase: cke.PhaseSuspended,
+		},
+		{
+			Name: "SuspendEtcdMaintainWithK8sMaintain",
+			Input: newData().withAllServices().with(func(d testData) {
+				d.Status.Etcd.Members["10.0.0.100"] = &etcdserverpb.Member{Name: "10.0.0.100", ID: 3}
+				d.Status.Etcd.InSyncMembers["10.0.0.100"] = false
+			}).withSuspendedPhases(cke.PhaseEtcdMaintain),
+			ExpectedOps: []opData{
+				// k8s-maintain proceeds even if etcd-maintain is suspended.
+				{"wait-kubernetes", 1},
+			},
+			ExpectedPhase: cke.PhaseK8sMaintain,
+		},
+		{
+			Name: "SuspendRebootNodes",
+			Input: newData().withK8sResourceReady().withRebootConfig().withRebootEntries([]*cke.RebootQueueEntry{
+				{Index: 1, Node: nodeNames[4], Status: cke.RebootStatusQueued},
+			}).withNextCandidates([]*cke.RebootQueueEntry{
+				{Index: 1, Node: nodeNames[4], Status: cke.RebootStatusQueued},
+			}).withSuspendedPhases(cke.PhaseRebootNodes),
+			ExpectedOps:   nil,
+			ExpectedPhase: cke.PhaseSuspended,
+		},
+		{
+			Name: "SuspendK8sMaintainWithUnhealthyAPIServers",
+			Input: newData().withK8sResourceReady().withRebootConfig().withRebootEntries([]*cke.RebootQueueEntry{
+				{Index: 1, Node: nodeNames[4], Status: cke.RebootStatusQueued},
+			}).withNextCandidates([]*cke.RebootQueueEntry{
+				{Index: 1, Node: nodeNames[4], Status: cke.RebootStatusQueued},
+			}).withAPIServerUnhealthy(0).withAPIServerUnhealthy(1).withAPIServerUnhealthy(2).
+				withSuspendedPhases(cke.PhaseK8sMaintain),
+			// nodes are not rebooted without a healthy kube-apiserver.
+			ExpectedOps:   nil,
+			ExpectedPhase: cke.PhaseSuspended,
+		},
+		{
+			Name:          "SuspendUnrelatedPhase",
+			Input:         newData().withK8sResourceReady().withSuspendedPhases(cke.PhaseEtcdMaintain, cke.PhaseRebootNodes),
+			ExpectedOps:   nil,
+			ExpectedPhase: cke.PhaseCompleted,
+		},
 	}
 
 	for _, c := range cases {
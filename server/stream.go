@@ -0,0 +1,153 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/cybozu-go/log"
+
+	"github.com/cybozu-go/cke"
+)
+
+const streamHeartbeatInterval = 30 * time.Second
+
+// recordFilter selects operation records to be streamed.
+// Empty fields match any records.
+type recordFilter struct {
+	Operation string
+	Target    string
+	Status    cke.RecordStatus
+}
+
+func (f recordFilter) match(r *cke.Record) bool {
+	if f.Operation != "" && r.Operation != f.Operation {
+		return false
+	}
+	if f.Status != "" && r.Status != f.Status {
+		return false
+	}
+	if f.Target != "" && r.Command.Target != f.Target && !slices.Contains(r.Targets, f.Target) {
+		return false
+	}
+	return true
+}
+
+// parseStreamQuery parses the query parameters for the record stream.
+// from is the ID of the first record to be streamed.  It is taken from "from" parameter,
+// or the next ID of "Last-Event-ID" header as the client has already received the event.
+// If neither is given, from is zero and the stream starts from the latest count records.
+func parseStreamQuery(r *http.Request) (filter recordFilter, from, count int64, apiErr *APIError) {
+	q := r.URL.Query()
+	filter = recordFilter{
+		Operation: q.Get("operation"),
+		Target:    q.Get("target"),
+		Status:    cke.RecordStatus(q.Get("status")),
+	}
+	switch filter.Status {
+	case "", cke.StatusNew, cke.StatusRunning, cke.StatusCancelled, cke.StatusCompleted, cke.StatusRejected:
+	default:
+		e := BadRequest("unknown status: " + string(filter.Status))
+		return filter, 0, 0, &e
+	}
+
+	cursor := r.Header.Get("Last-Event-ID")
+	resume := cursor != ""
+	if !resume {
+		cursor = q.Get("from")
+	}
+	if cursor != "" {
+		n, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || n <= 0 {
+			e := BadRequest("record ID must be a positive integer")
+			return filter, 0, 0, &e
+		}
+		from = n
+		if resume {
+			from++
+		}
+	}
+
+	if v := q.Get("count"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			e := BadRequest("count must be a positive integer")
+			return filter, 0, 0, &e
+		}
+		count = min(n, maxRecordsCount)
+	}
+
+	return filter, from, count, nil
+}
+
+// handleRecordStream streams operation records as Server-Sent Events.
+// Each event has the record ID as its ID so that clients can resume the stream.
+func (s Server) handleRecordStream(w http.ResponseWriter, r *http.Request) {
+	filter, from, count, apiErr := parseStreamQuery(r)
+	if apiErr != nil {
+		renderError(r.Context(), w, *apiErr)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		renderError(r.Context(), w, InternalServerError(fmt.Errorf("streaming is not supported")))
+		return
+	}
+
+	ctx := r.Context()
+	var ch cke.RecordChan
+	var err error
+	if from > 0 {
+		ch, err = s.storage().WatchRecordsFrom(ctx, from)
+	} else {
+		ch, err = s.storage().WatchRecords(ctx, count)
+	}
+	if err != nil {
+		renderError(r.Context(), w, InternalServerError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(streamHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case rec, ok := <-ch:
+			if !ok {
+				return
+			}
+			if !filter.match(rec) {
+				continue
+			}
+			data, err := json.Marshal(rec)
+			if err != nil {
+				log.Error("failed to marshal record", map[string]any{
+					log.FnError: err,
+					"id":        rec.ID,
+				})
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: record\ndata: %s\n\n", rec.ID, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
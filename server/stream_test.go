@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cybozu-go/cke"
+)
+
+func TestRecordFilter(t *testing.T) {
+	r := &cke.Record{
+		Operation: "reboot-drain-start",
+		Status:    cke.StatusRunning,
+		Targets:   []string{"10.0.0.1", "10.0.0.2"},
+		Command:   cke.Command{Name: "drain", Target: "10.0.0.1"},
+	}
+
+	cases := []struct {
+		name   string
+		filter recordFilter
+		expect bool
+	}{
+		{"empty", recordFilter{}, true},
+		{"operation", recordFilter{Operation: "reboot-drain-start"}, true},
+		{"other operation", recordFilter{Operation: "kubelet-restart"}, false},
+		{"status", recordFilter{Status: cke.StatusRunning}, true},
+		{"other status", recordFilter{Status: cke.StatusCompleted}, false},
+		{"target", recordFilter{Target: "10.0.0.2"}, true},
+		{"other target", recordFilter{Target: "10.0.0.3"}, false},
+		{"all", recordFilter{Operation: "reboot-drain-start", Target: "10.0.0.1", Status: cke.StatusRunning}, true},
+	}
+
+	for _, c := range cases {
+		if c.filter.match(r) != c.expect {
+			t.Errorf("%s: expected %v", c.name, c.expect)
+		}
+	}
+}
+
+func TestParseStreamQuery(t *testing.T) {
+	r := httptest.NewRequest("GET", "/records/stream?operation=etcd-start&status=completed&from=10", nil)
+	filter, from, _, apiErr := parseStreamQuery(r)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if filter.Operation != "etcd-start" || filter.Status != cke.StatusCompleted {
+		t.Error("unexpected filter", filter)
+	}
+	if from != 10 {
+		t.Error("unexpected from", from)
+	}
+
+	// reconnecting clients send the ID of the last received event.
+	r.Header.Set("Last-Event-ID", "20")
+	_, from, _, apiErr = parseStreamQuery(r)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if from != 21 {
+		t.Error("the stream should resume after Last-Event-ID", from)
+	}
+
+	r = httptest.NewRequest("GET", "/records/stream", nil)
+	r.Header.Set("Last-Event-ID", "0")
+	if _, _, _, apiErr := parseStreamQuery(r); apiErr == nil {
+		t.Error("invalid Last-Event-ID should be rejected")
+	}
+
+	r = httptest.NewRequest("GET", "/records/stream?status=unknown", nil)
+	if _, _, _, apiErr := parseStreamQuery(r); apiErr == nil {
+		t.Error("unknown status should be rejected")
+	}
+}
@@ -44,13 +44,23 @@ func startWatcher(ctx context.Context, etcd *clientv3.Client, ch chan<- struct{}
 		}
 
 		for _, ev := range resp.Events {
+			key := string(ev.Kv.Key)
+
+			// Resume operations immediately when the freeze is cleared.
+			if ev.Type == clientv3.EventTypeDelete && key == cke.KeyFreeze {
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+				continue
+			}
+
 			if ev.Type != clientv3.EventTypePut {
 				continue
 			}
 
-			key := string(ev.Kv.Key)
 			switch {
-			case key == cke.KeyCluster || strings.HasPrefix(key, cke.KeyResourcePrefix):
+			case key == cke.KeyCluster || key == cke.KeySuspendedPhases || key == cke.KeyApproval || strings.HasPrefix(key, cke.KeyResourcePrefix):
 				select {
 				case ch <- struct{}{}:
 				default:
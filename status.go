@@ -35,13 +35,15 @@ type KubernetesClusterStatus struct {
 	ClusterDNS          ClusterDNSStatus
 	NodeDNS             NodeDNSStatus
 	//nolint:staticcheck // code for Endpoints will be removed later
-	MasterEndpoints     *corev1.Endpoints
-	MasterEndpointSlice *discoveryv1.EndpointSlice
-	EtcdService         *corev1.Service
+	MasterEndpoints         *corev1.Endpoints
+	MasterEndpointSlice     *discoveryv1.EndpointSlice
+	MasterEndpointSliceIPv6 *discoveryv1.EndpointSlice
+	EtcdService             *corev1.Service
 	//nolint:staticcheck // code for Endpoints will be removed later
-	EtcdEndpoints     *corev1.Endpoints
-	EtcdEndpointSlice *discoveryv1.EndpointSlice
-	ResourceStatuses  map[string]ResourceStatus
+	EtcdEndpoints         *corev1.Endpoints
+	EtcdEndpointSlice     *discoveryv1.EndpointSlice
+	EtcdEndpointSliceIPv6 *discoveryv1.EndpointSlice
+	ResourceStatuses      map[string]ResourceStatus
 }
 
 // ResourceStatus represents the status of registered K8s resources
@@ -91,6 +93,13 @@ type ClusterStatus struct {
 	Kubernetes  KubernetesClusterStatus
 	RepairQueue RepairQueueStatus
 	RebootQueue RebootQueueStatus
+
+	// SuspendedPhases is the list of phases that must not run operations.
+	SuspendedPhases []OperationPhase
+
+	// Encryption is the state of encryption keys.  nil if keys are not managed
+	// by the rotation workflow yet.
+	Encryption *EncryptionStatus
 }
 
 // NodeStatus status of a node.
@@ -99,6 +108,7 @@ type NodeStatus struct {
 	Etcd              EtcdStatus
 	Rivers            ServiceStatus
 	EtcdRivers        ServiceStatus
+	KMSPlugin         ServiceStatus
 	APIServer         KubeComponentStatus
 	ControllerManager KubeComponentStatus
 	Scheduler         SchedulerStatus
@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/clientv3util"
@@ -24,20 +26,31 @@ type RecordChan <-chan *Record
 
 // etcd keys and prefixes
 const (
+	KeyApproval                 = "approval"
+	KeyApprovalID               = "approval-id"
+	KeyApprovalPolicy           = "approval-policy"
 	KeyAutoRepairDisabled       = "auto-repair/disabled"
 	KeyAutoRepairQueryVariables = "auto-repair/query-variables"
 	KeyCA                       = "ca/"
 	KeyConfigVersion            = "config-version"
 	KeyCluster                  = "cluster"
+	KeyClusterHistory           = "cluster-history/"
+	KeyClusterHistoryID         = "cluster-history-id"
 	KeyClusterRevision          = "cluster-revision"
 	KeyConstraints              = "constraints"
+	KeyEncryption               = "encryption"
+	KeyFreeze                   = "freeze"
 	KeyLeader                   = "leader/"
+	KeyNotifierConfig           = "notifier/config"
 	KeyRebootsDisabled          = "reboots/disabled"
 	KeyRebootsRunning           = "reboots/running"
 	KeyRebootsPrefix            = "reboots/data/"
 	KeyRebootsWriteIndex        = "reboots/write-index"
+	KeyRecordRetention          = "record-retention"
 	KeyRecords                  = "records/"
 	KeyRecordID                 = "records"
+	KeyRecordIndex              = "record-index/"
+	KeyRecordIndexBuilt         = "record-index-built"
 	KeyRepairsDisabled          = "repairs/disabled"
 	KeyRepairsPrefix            = "repairs/data/"
 	KeyRepairsWriteIndex        = "repairs/write-index"
@@ -49,11 +62,13 @@ const (
 	KeyServiceAccountCert       = "service-account/certificate"
 	KeyServiceAccountKey        = "service-account/key"
 	KeyStatus                   = "status"
+	KeySuspendedPhases          = "suspended-phases"
 	KeyVault                    = "vault"
 )
 
 const (
-	maxRecords          = 1000
+	recordPageSize      = 100
+	maxTxnOps           = 128 // the default of etcd --max-txn-ops
 	recordChanLength    = 100
 	initialDisplayCount = 20
 )
@@ -63,6 +78,8 @@ var (
 	ErrNotFound = errors.New("not found")
 	// ErrNoLeader is returned when the session lost leadership.
 	ErrNoLeader = errors.New("lost leadership")
+	// ErrConflict is returned when the data has been modified concurrently.
+	ErrConflict = errors.New("conflict")
 )
 
 func (s Storage) getStringValue(ctx context.Context, key string) (string, error) {
@@ -105,37 +122,98 @@ func (s Storage) PutConfigVersion(ctx context.Context, leaderKey string) error {
 }
 
 // PutCluster stores *Cluster into etcd.
+// The configuration is recorded in the history without author and message.
 func (s Storage) PutCluster(ctx context.Context, c *Cluster) error {
-	data, err := json.Marshal(c)
-	if err != nil {
-		return err
-	}
+	return s.PutClusterWithHistory(ctx, c, "", "")
+}
 
-	_, err = s.Put(ctx, KeyCluster, string(data))
-	return err
+// PutClusterWithHistory stores *Cluster into etcd and records it in the history
+// with author and message.  The oldest entries exceeding MaxClusterHistory are removed.
+func (s Storage) PutClusterWithHistory(ctx context.Context, c *Cluster, author, message string) error {
+	return s.putClusterWithHistory(ctx, c, author, message, "")
 }
 
 // PutClusterWithTemplateRevision stores *Cluster into etcd along with a revision number.
 func (s Storage) PutClusterWithTemplateRevision(ctx context.Context, c *Cluster, rev int64, leaderKey string) error {
+	message := fmt.Sprintf("generated from sabakan template revision %d", rev)
+	return s.putClusterWithHistory(ctx, c, "sabakan", message, leaderKey,
+		clientv3.OpPut(KeyClusterRevision, strconv.FormatInt(rev, 10)))
+}
+
+func clusterHistoryKey(rev int64) string {
+	return fmt.Sprintf("%s%016x", KeyClusterHistory, rev)
+}
+
+// putClusterWithHistory stores *Cluster and its history entry in a transaction.
+// If leaderKey is not empty, the transaction succeeds only when the key exists.
+func (s Storage) putClusterWithHistory(ctx context.Context, c *Cluster, author, message, leaderKey string, extraOps ...clientv3.Op) error {
 	data, err := json.Marshal(c)
 	if err != nil {
 		return err
 	}
 
-	resp, err := s.Txn(ctx).
-		If(clientv3util.KeyExists(leaderKey)).
-		Then(
-			clientv3.OpPut(KeyCluster, string(data)),
-			clientv3.OpPut(KeyClusterRevision, strconv.FormatInt(rev, 10)),
-		).Commit()
+RETRY:
+	resp, err := s.Get(ctx, KeyClusterHistoryID)
 	if err != nil {
 		return err
 	}
+	rev := int64(1)
+	var modRev int64
+	if resp.Count != 0 {
+		rev, err = strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
+		if err != nil {
+			return err
+		}
+		modRev = resp.Kvs[0].ModRevision
+	}
 
-	if !resp.Succeeded {
-		return ErrNoLeader
+	entry := &ClusterHistoryEntry{
+		Revision: rev,
+		Time:     time.Now().UTC(),
+		Author:   author,
+		Message:  message,
+		Cluster:  c,
 	}
-	return nil
+	entryData, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	cmps := []clientv3.Cmp{
+		clientv3.Compare(clientv3.ModRevision(KeyClusterHistoryID), "=", modRev),
+	}
+	if leaderKey != "" {
+		cmps = append(cmps, clientv3util.KeyExists(leaderKey))
+	}
+	ops := []clientv3.Op{
+		clientv3.OpPut(KeyCluster, string(data)),
+		clientv3.OpPut(clusterHistoryKey(rev), string(entryData)),
+		clientv3.OpPut(KeyClusterHistoryID, strconv.FormatInt(rev+1, 10)),
+	}
+	if rev > MaxClusterHistory {
+		// remove the oldest entries to keep at most MaxClusterHistory entries.
+		ops = append(ops, clientv3.OpDelete(KeyClusterHistory, clientv3.WithRange(clusterHistoryKey(rev-MaxClusterHistory+1))))
+	}
+	ops = append(ops, extraOps...)
+
+	txnResp, err := s.Txn(ctx).If(cmps...).Then(ops...).Commit()
+	if err != nil {
+		return err
+	}
+	if txnResp.Succeeded {
+		return nil
+	}
+
+	if leaderKey != "" {
+		resp, err := s.Get(ctx, leaderKey)
+		if err != nil {
+			return err
+		}
+		if resp.Count == 0 {
+			return ErrNoLeader
+		}
+	}
+	goto RETRY
 }
 
 // GetCluster loads *Cluster from etcd.
@@ -198,6 +276,48 @@ func (s Storage) GetClusterWithRevision(ctx context.Context) (*Cluster, int64, e
 	return c, rev, nil
 }
 
+// GetClusterHistory loads the history of cluster configurations.
+// The returned entries are sorted by revision in decreasing order.
+func (s Storage) GetClusterHistory(ctx context.Context) ([]*ClusterHistoryEntry, error) {
+	resp, err := s.Get(ctx, KeyClusterHistory,
+		clientv3.WithPrefix(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*ClusterHistoryEntry, len(resp.Kvs))
+	for i, kv := range resp.Kvs {
+		e := new(ClusterHistoryEntry)
+		err = json.Unmarshal(kv.Value, e)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = e
+	}
+	return entries, nil
+}
+
+// GetClusterHistoryEntry loads the cluster configuration of the given revision from the history.
+// If the revision is not found, this returns ErrNotFound.
+func (s Storage) GetClusterHistoryEntry(ctx context.Context, rev int64) (*ClusterHistoryEntry, error) {
+	resp, err := s.Get(ctx, clusterHistoryKey(rev))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	e := new(ClusterHistoryEntry)
+	err = json.Unmarshal(resp.Kvs[0].Value, e)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
 // PutConstraints stores *Constraints into etcd.
 func (s Storage) PutConstraints(ctx context.Context, c *Constraints) error {
 	data, err := json.Marshal(c)
@@ -230,6 +350,38 @@ func (s Storage) GetConstraints(ctx context.Context) (*Constraints, error) {
 	return c, nil
 }
 
+// PutEncryptionStatus stores *EncryptionStatus into etcd.
+func (s Storage) PutEncryptionStatus(ctx context.Context, st *EncryptionStatus) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Put(ctx, KeyEncryption, string(data))
+	return err
+}
+
+// GetEncryptionStatus loads *EncryptionStatus from etcd.
+// If the status has not been stored, this returns ErrNotFound.
+func (s Storage) GetEncryptionStatus(ctx context.Context) (*EncryptionStatus, error) {
+	resp, err := s.Get(ctx, KeyEncryption)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	st := new(EncryptionStatus)
+	err = json.Unmarshal(resp.Kvs[0].Value, st)
+	if err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
 // PutVaultConfig stores *VaultConfig into etcd.
 func (s Storage) PutVaultConfig(ctx context.Context, c *VaultConfig) error {
 	data, err := json.Marshal(c)
@@ -275,6 +427,33 @@ func recordKey(r *Record) string {
 	return fmt.Sprintf("%s%016x", KeyRecords, r.ID)
 }
 
+// recordIndexPrefix returns the key prefix of the index entries for records
+// whose field has value.  value is escaped so that it does not contain "/".
+func recordIndexPrefix(field, value string) string {
+	return KeyRecordIndex + field + "/" + url.PathEscape(value) + "/"
+}
+
+func recordIndexKey(field, value string, id int64) string {
+	return fmt.Sprintf("%s%016x", recordIndexPrefix(field, value), id)
+}
+
+// recordIndexKeys returns the keys of the index entries for r.
+func recordIndexKeys(r *Record) []string {
+	keys := []string{
+		recordIndexKey("operation", r.Operation, r.ID),
+		recordIndexKey("status", string(r.Status), r.ID),
+	}
+	seen := make(map[string]bool)
+	for _, t := range r.Targets {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		keys = append(keys, recordIndexKey("target", t, r.ID))
+	}
+	return keys
+}
+
 // GetServiceAccountCert loads x509 certificate for service account.
 // The format is PEM.
 func (s Storage) GetServiceAccountCert(ctx context.Context) (string, error) {
@@ -337,6 +516,43 @@ func (s Storage) GetRecords(ctx context.Context, count int64) ([]*Record, error)
 	return records, nil
 }
 
+// GetRecordsBefore loads at most count *Record whose IDs are less than before.
+// If before is not positive, this is the same as GetRecords.
+// The returned records are sorted by record ID in decreasing order.
+func (s Storage) GetRecordsBefore(ctx context.Context, before, count int64) ([]*Record, error) {
+	if before <= 0 {
+		return s.GetRecords(ctx, count)
+	}
+
+	opts := []clientv3.OpOption{
+		clientv3.WithRange(recordKey(&Record{ID: before})),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend),
+	}
+	if count > 0 {
+		opts = append(opts, clientv3.WithLimit(count))
+	}
+	resp, err := s.Get(ctx, KeyRecords, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	records := make([]*Record, len(resp.Kvs))
+	for i, kv := range resp.Kvs {
+		r := new(Record)
+		err = json.Unmarshal(kv.Value, r)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = r
+	}
+
+	return records, nil
+}
+
 // WatchRecords watches new operation records.
 // The watched records will be returned through the returned channel.
 func (s Storage) WatchRecords(ctx context.Context, initialCount int64) (RecordChan, error) {
@@ -344,12 +560,25 @@ func (s Storage) WatchRecords(ctx context.Context, initialCount int64) (RecordCh
 		initialCount = initialDisplayCount
 	}
 
-	getOpts := []clientv3.OpOption{
+	return s.watchRecords(ctx, KeyRecords,
 		clientv3.WithPrefix(),
 		clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend),
 		clientv3.WithLimit(initialCount),
-	}
-	getResp, err := s.Get(ctx, KeyRecords, getOpts...)
+	)
+}
+
+// WatchRecordsFrom watches operation records whose IDs are equal to or greater than from.
+// Existing records are returned first, then new records and updates are
+// returned through the returned channel.
+func (s Storage) WatchRecordsFrom(ctx context.Context, from int64) (RecordChan, error) {
+	return s.watchRecords(ctx, recordKey(&Record{ID: from}),
+		clientv3.WithRange(clientv3.GetPrefixRangeEnd(KeyRecords)),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+	)
+}
+
+func (s Storage) watchRecords(ctx context.Context, key string, getOpts ...clientv3.OpOption) (RecordChan, error) {
+	getResp, err := s.Get(ctx, key, getOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -380,7 +609,11 @@ func (s Storage) WatchRecords(ctx context.Context, initialCount int64) (RecordCh
 		}()
 
 		for _, r := range getRecords {
-			recordCh <- r
+			select {
+			case recordCh <- r:
+			case <-ctx.Done():
+				return
+			}
 		}
 
 		for watchResp := range watchCh {
@@ -395,7 +628,11 @@ func (s Storage) WatchRecords(ctx context.Context, initialCount int64) (RecordCh
 				if err != nil {
 					return
 				}
-				recordCh <- r
+				select {
+				case recordCh <- r:
+				case <-ctx.Done():
+					return
+				}
 			}
 		}
 	}()
@@ -403,27 +640,86 @@ func (s Storage) WatchRecords(ctx context.Context, initialCount int64) (RecordCh
 	return recordCh, nil
 }
 
-// RegisterRecord stores *Record if the leaderKey exists
+// RegisterRecord stores *Record if the leaderKey exists.
+// If r.ID has been taken by a record of the write API, r.ID is reassigned.
 func (s Storage) RegisterRecord(ctx context.Context, leaderKey string, r *Record) error {
-	nextID := strconv.FormatInt(r.ID+1, 10)
-	data, err := json.Marshal(r)
+	return s.registerRecord(ctx, leaderKey, r)
+}
+
+// RegisterAuditRecord stores *Record of a change made via the write API.
+// Unlike RegisterRecord, this does not require leadership, and assigns r.ID.
+func (s Storage) RegisterAuditRecord(ctx context.Context, r *Record) error {
+	id, err := s.NextRecordID(ctx)
 	if err != nil {
 		return err
 	}
-	resp, err := s.Txn(ctx).
-		If(clientv3util.KeyExists(leaderKey)).
-		Then(
+	r.ID = id
+	return s.registerRecord(ctx, "", r)
+}
+
+// registerRecord stores r without overwriting existing records.
+// The leadership is checked only if leaderKey is not empty.
+func (s Storage) registerRecord(ctx context.Context, leaderKey string, r *Record) error {
+	for {
+		nextID := strconv.FormatInt(r.ID+1, 10)
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+
+		cmps := []clientv3.Cmp{clientv3util.KeyMissing(recordKey(r))}
+		if leaderKey != "" {
+			cmps = append(cmps, clientv3util.KeyExists(leaderKey))
+		}
+		ops := []clientv3.Op{
 			clientv3.OpPut(recordKey(r), string(data)),
-			clientv3.OpPut(KeyRecordID, nextID)).
-		Commit()
-	if err != nil {
-		return err
-	}
-	if !resp.Succeeded {
-		return ErrNoLeader
-	}
+			clientv3.OpPut(KeyRecordID, nextID),
+		}
+		for _, key := range recordIndexKeys(r) {
+			ops = append(ops, clientv3.OpPut(key, ""))
+		}
+		// Index entries exceeding the limit of etcd are put just after the record.
+		rest := ops[min(len(ops), maxTxnOps):]
+		ops = ops[:min(len(ops), maxTxnOps)]
+		resp, err := s.Txn(ctx).
+			If(cmps...).
+			Then(ops...).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if resp.Succeeded {
+			for len(rest) > 0 {
+				n := min(len(rest), maxTxnOps)
+				_, err := s.Txn(ctx).Then(rest[:n]...).Commit()
+				if err != nil {
+					return err
+				}
+				rest = rest[n:]
+			}
+			return nil
+		}
+
+		if leaderKey != "" {
+			gresp, err := s.Get(ctx, leaderKey, clientv3.WithCountOnly())
+			if err != nil {
+				return err
+			}
+			if gresp.Count == 0 {
+				return ErrNoLeader
+			}
+		}
 
-	return s.maintRecords(ctx, leaderKey, maxRecords)
+		// the ID has been taken by another writer.
+		id, err := s.NextRecordID(ctx)
+		if err != nil {
+			return err
+		}
+		if id <= r.ID {
+			id = r.ID + 1
+		}
+		r.ID = id
+	}
 }
 
 // UpdateRecord updates existing record
@@ -432,9 +728,17 @@ func (s Storage) UpdateRecord(ctx context.Context, leaderKey string, r *Record)
 	if err != nil {
 		return err
 	}
+	// The index entry of the status is replaced as the status may have been changed.
+	ops := []clientv3.Op{clientv3.OpPut(recordKey(r), string(data))}
+	for _, st := range recordStatuses {
+		if st != r.Status {
+			ops = append(ops, clientv3.OpDelete(recordIndexKey("status", string(st), r.ID)))
+		}
+	}
+	ops = append(ops, clientv3.OpPut(recordIndexKey("status", string(r.Status), r.ID), ""))
 	resp, err := s.Txn(ctx).
 		If(clientv3util.KeyExists(leaderKey)).
-		Then(clientv3.OpPut(recordKey(r), string(data))).
+		Then(ops...).
 		Commit()
 	if err != nil {
 		return err
@@ -462,33 +766,288 @@ func (s Storage) NextRecordID(ctx context.Context) (int64, error) {
 	return id, nil
 }
 
-func (s Storage) maintRecords(ctx context.Context, leaderKey string, max int64) error {
+// GetRecordsToPrune loads records that exceed the retention policy.
+// The latest record is never returned as it may be running.
+// The returned records are sorted by record ID in increasing order.
+func (s Storage) GetRecordsToPrune(ctx context.Context, rr *RecordRetention, now time.Time) ([]*Record, error) {
 	resp, err := s.Get(ctx, KeyRecords,
 		clientv3.WithPrefix(),
 		clientv3.WithKeysOnly(),
 		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(resp.Kvs)
+	excess := n - int(rr.GetMaxRecords())
+
+	var records []*Record
+	for i := 0; i < n-1; i += recordPageSize {
+		end := min(i+recordPageSize, n-1)
+		gresp, err := s.Get(ctx, string(resp.Kvs[i].Key),
+			clientv3.WithRange(string(resp.Kvs[end].Key)),
+			clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, kv := range gresp.Kvs {
+			r := new(Record)
+			err = json.Unmarshal(kv.Value, r)
+			if err != nil {
+				return nil, err
+			}
+			if len(records) >= excess && !rr.Expired(r, now) {
+				return records, nil
+			}
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+// DeleteRecords deletes records and their index entries.
+func (s Storage) DeleteRecords(ctx context.Context, leaderKey string, records []*Record) error {
+	// Index entries are deleted before the record so that they are
+	// deleted by the next try if this fails halfway.
+	var ops []clientv3.Op
+	for _, r := range records {
+		for _, key := range recordIndexKeys(r) {
+			ops = append(ops, clientv3.OpDelete(key))
+		}
+		// BuildRecordIndex may have indexed a status that was changed concurrently.
+		for _, st := range recordStatuses {
+			if st != r.Status {
+				ops = append(ops, clientv3.OpDelete(recordIndexKey("status", string(st), r.ID)))
+			}
+		}
+		ops = append(ops, clientv3.OpDelete(recordKey(r)))
+	}
+
+	for len(ops) > 0 {
+		n := min(len(ops), maxTxnOps)
+		resp, err := s.Txn(ctx).
+			If(clientv3util.KeyExists(leaderKey)).
+			Then(ops[:n]...).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if !resp.Succeeded {
+			return ErrNoLeader
+		}
+		ops = ops[n:]
+	}
+	return nil
+}
+
+// BuildRecordIndex puts the index entries of the records stored by older
+// versions of CKE if the leaderKey exists.  This does nothing once the index
+// has been built.
+func (s Storage) BuildRecordIndex(ctx context.Context, leaderKey string) error {
+	resp, err := s.Get(ctx, KeyRecordIndexBuilt, clientv3.WithCountOnly())
 	if err != nil {
 		return err
 	}
+	if resp.Count != 0 {
+		return nil
+	}
 
-	if len(resp.Kvs) <= int(max) {
+	commit := func(ops []clientv3.Op) error {
+		resp, err := s.Txn(ctx).
+			If(clientv3util.KeyExists(leaderKey)).
+			Then(ops...).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if !resp.Succeeded {
+			return ErrNoLeader
+		}
 		return nil
 	}
 
-	startKey := string(resp.Kvs[0].Key)
-	endKey := string(resp.Kvs[len(resp.Kvs)-int(max)].Key)
+	var before int64
+	for {
+		page, err := s.GetRecordsBefore(ctx, before, recordPageSize)
+		if err != nil {
+			return err
+		}
+
+		var ops []clientv3.Op
+		for _, r := range page {
+			for _, key := range recordIndexKeys(r) {
+				ops = append(ops, clientv3.OpPut(key, ""))
+			}
+		}
+		for len(ops) > 0 {
+			n := min(len(ops), maxTxnOps)
+			if err := commit(ops[:n]); err != nil {
+				return err
+			}
+			ops = ops[n:]
+		}
 
-	tresp, err := s.Txn(ctx).
-		If(clientv3util.KeyExists(leaderKey)).
-		Then(clientv3.OpDelete(startKey, clientv3.WithRange(endKey))).
-		Commit()
-	if !tresp.Succeeded {
-		return ErrNoLeader
+		if len(page) < recordPageSize || page[len(page)-1].ID <= 1 {
+			break
+		}
+		before = page[len(page)-1].ID
+	}
+
+	return commit([]clientv3.Op{clientv3.OpPut(KeyRecordIndexBuilt, "true")})
+}
+
+// SearchRecords loads at most count *Record that match f.
+// If count is not positive, all matching records are returned.
+// The returned records are sorted by record ID in decreasing order.
+//
+// If f specifies Target, Operation, or Status, records are looked up by
+// the index of the first specified one in this order.  Otherwise, or if
+// the index has not been built by BuildRecordIndex, all records are scanned.
+// Either way, records are visited from the latest one, and as records are
+// created in order of time, the search stops at the first record started
+// before f.Since.
+func (s Storage) SearchRecords(ctx context.Context, f *RecordFilter, count int64) ([]*Record, error) {
+	resp, err := s.Get(ctx, KeyRecordIndexBuilt, clientv3.WithCountOnly())
+	if err != nil {
+		return nil, err
+	}
+
+	var prefix string
+	switch {
+	case resp.Count == 0:
+	case f.Target != "":
+		prefix = recordIndexPrefix("target", f.Target)
+	case f.Operation != "":
+		prefix = recordIndexPrefix("operation", f.Operation)
+	case f.Status != "":
+		prefix = recordIndexPrefix("status", string(f.Status))
+	}
+
+	var records []*Record
+	end := clientv3.GetPrefixRangeEnd(prefix)
+	var before int64
+	for {
+		var page []*Record
+		var err error
+		if prefix == "" {
+			page, err = s.GetRecordsBefore(ctx, before, recordPageSize)
+		} else {
+			var next string
+			page, next, err = s.getIndexedRecords(ctx, prefix, end)
+			end = next
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range page {
+			if !f.Since.IsZero() && r.StartAt.Before(f.Since) {
+				return records, nil
+			}
+			if !f.Match(r) {
+				continue
+			}
+			records = append(records, r)
+			if count > 0 && int64(len(records)) == count {
+				return records, nil
+			}
+		}
+
+		if prefix != "" {
+			if end == "" {
+				return records, nil
+			}
+			continue
+		}
+		if len(page) < recordPageSize || page[len(page)-1].ID <= 1 {
+			return records, nil
+		}
+		before = page[len(page)-1].ID
+	}
+}
+
+// getIndexedRecords loads a page of records indexed by the keys having prefix
+// and less than end, in decreasing order of ID.  Index entries of deleted
+// records are skipped.  It also returns the end for the next page, which is
+// empty if there are no more entries.
+func (s Storage) getIndexedRecords(ctx context.Context, prefix, end string) ([]*Record, string, error) {
+	resp, err := s.Get(ctx, prefix,
+		clientv3.WithRange(end),
+		clientv3.WithKeysOnly(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend),
+		clientv3.WithLimit(recordPageSize),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, "", nil
+	}
+
+	ops := make([]clientv3.Op, len(resp.Kvs))
+	for i, kv := range resp.Kvs {
+		id := strings.TrimPrefix(string(kv.Key), prefix)
+		ops[i] = clientv3.OpGet(KeyRecords + id)
+	}
+	tresp, err := s.Txn(ctx).Then(ops...).Commit()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var records []*Record
+	for _, r := range tresp.Responses {
+		kvs := r.GetResponseRange().Kvs
+		if len(kvs) == 0 {
+			continue
+		}
+		record := new(Record)
+		if err := json.Unmarshal(kvs[0].Value, record); err != nil {
+			return nil, "", err
+		}
+		records = append(records, record)
+	}
+
+	next := ""
+	if resp.More {
+		next = string(resp.Kvs[len(resp.Kvs)-1].Key)
+	}
+	return records, next, nil
+}
+
+// SetRecordRetention stores *RecordRetention into etcd.
+func (s Storage) SetRecordRetention(ctx context.Context, rr *RecordRetention) error {
+	data, err := json.Marshal(rr)
+	if err != nil {
+		return err
 	}
+
+	_, err = s.Put(ctx, KeyRecordRetention, string(data))
 	return err
 }
 
+// GetRecordRetention loads *RecordRetention from etcd.
+// If not found, this returns ErrNotFound.
+func (s Storage) GetRecordRetention(ctx context.Context) (*RecordRetention, error) {
+	resp, err := s.Get(ctx, KeyRecordRetention)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	rr := new(RecordRetention)
+	err = json.Unmarshal(resp.Kvs[0].Value, rr)
+	if err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
 // GetLeaderHostname returns the current leader's host name.
 // It returns non-nil error when there is no leader.
 func (s Storage) GetLeaderHostname(ctx context.Context) (string, error) {
@@ -1111,6 +1670,300 @@ func (s Storage) DeleteRepairsEntry(ctx context.Context, leaderKey string, index
 	return nil
 }
 
+// SetFreeze stores *Freeze into etcd.
+func (s Storage) SetFreeze(ctx context.Context, f *Freeze) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Put(ctx, KeyFreeze, string(data))
+	return err
+}
+
+// GetFreeze loads *Freeze from etcd.
+// If no freeze is set, this returns ErrNotFound.
+// Note that the returned freeze may have already expired.
+func (s Storage) GetFreeze(ctx context.Context) (*Freeze, error) {
+	resp, err := s.Get(ctx, KeyFreeze)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	f := new(Freeze)
+	err = json.Unmarshal(resp.Kvs[0].Value, f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ClearFreeze removes the freeze from etcd.
+func (s Storage) ClearFreeze(ctx context.Context) error {
+	_, err := s.Delete(ctx, KeyFreeze)
+	return err
+}
+
+// SetSuspendedPhases stores the list of suspended operation phases.
+func (s Storage) SetSuspendedPhases(ctx context.Context, phases []OperationPhase) error {
+	data, err := json.Marshal(phases)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Put(ctx, KeySuspendedPhases, string(data))
+	return err
+}
+
+// GetSuspendedPhases loads the list of suspended operation phases.
+// If no phases have been suspended, this returns an empty list.
+func (s Storage) GetSuspendedPhases(ctx context.Context) ([]OperationPhase, error) {
+	resp, err := s.Get(ctx, KeySuspendedPhases)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var phases []OperationPhase
+	err = json.Unmarshal(resp.Kvs[0].Value, &phases)
+	if err != nil {
+		return nil, err
+	}
+	return phases, nil
+}
+
+// SetApprovalPolicy stores *ApprovalPolicy into etcd.
+func (s Storage) SetApprovalPolicy(ctx context.Context, p *ApprovalPolicy) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Put(ctx, KeyApprovalPolicy, string(data))
+	return err
+}
+
+// GetApprovalPolicy loads *ApprovalPolicy from etcd.
+// If no policy has been stored, this returns ErrNotFound.
+func (s Storage) GetApprovalPolicy(ctx context.Context) (*ApprovalPolicy, error) {
+	resp, err := s.Get(ctx, KeyApprovalPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	p := new(ApprovalPolicy)
+	err = json.Unmarshal(resp.Kvs[0].Value, p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetApproval loads the current *Approval from etcd.
+// If no approval exists, this returns ErrNotFound.
+func (s Storage) GetApproval(ctx context.Context) (*Approval, error) {
+	resp, err := s.Get(ctx, KeyApproval)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	a := new(Approval)
+	err = json.Unmarshal(resp.Kvs[0].Value, a)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// RegisterApproval stores *Approval replacing the current one if the leaderKey exists.
+// "ID" of the approval is assigned in this method. The given value is ignored.
+func (s Storage) RegisterApproval(ctx context.Context, leaderKey string, a *Approval) error {
+	resp, err := s.Get(ctx, KeyApprovalID)
+	if err != nil {
+		return err
+	}
+	a.ID = 1
+	if resp.Count != 0 {
+		id, err := strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
+		if err != nil {
+			return err
+		}
+		a.ID = id
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	nextID := strconv.FormatInt(a.ID+1, 10)
+	txnResp, err := s.Txn(ctx).
+		If(clientv3util.KeyExists(leaderKey)).
+		Then(
+			clientv3.OpPut(KeyApproval, string(data)),
+			clientv3.OpPut(KeyApprovalID, nextID)).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !txnResp.Succeeded {
+		return ErrNoLeader
+	}
+	return nil
+}
+
+// DecideApproval approves or rejects the pending approval specified by id.
+// If the approval is not found, this returns ErrNotFound.
+func (s Storage) DecideApproval(ctx context.Context, id int64, approve bool, user string) (*Approval, error) {
+RETRY:
+	resp, err := s.Get(ctx, KeyApproval)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Count == 0 {
+		return nil, ErrNotFound
+	}
+
+	a := new(Approval)
+	err = json.Unmarshal(resp.Kvs[0].Value, a)
+	if err != nil {
+		return nil, err
+	}
+	if a.ID != id {
+		return nil, ErrNotFound
+	}
+	if err := a.Decide(approve, user); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	txnResp, err := s.Txn(ctx).
+		If(
+			clientv3.Compare(clientv3.ModRevision(KeyApproval), "=", resp.Kvs[0].ModRevision),
+		).
+		Then(
+			clientv3.OpPut(KeyApproval, string(data)),
+		).
+		Commit()
+	if err != nil {
+		return nil, err
+	}
+	if !txnResp.Succeeded {
+		goto RETRY
+	}
+	return a, nil
+}
+
+// UpdateApproval updates the current approval if the leaderKey exists.
+// If the current approval is not a.ID, this returns ErrNotFound.
+// If the approval is modified concurrently, this returns ErrConflict.
+func (s Storage) UpdateApproval(ctx context.Context, leaderKey string, a *Approval) error {
+	resp, err := s.Get(ctx, KeyApproval)
+	if err != nil {
+		return err
+	}
+	if resp.Count == 0 {
+		return ErrNotFound
+	}
+	current := new(Approval)
+	if err := json.Unmarshal(resp.Kvs[0].Value, current); err != nil {
+		return err
+	}
+	if current.ID != a.ID {
+		return ErrNotFound
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	txnResp, err := s.Txn(ctx).
+		If(
+			clientv3util.KeyExists(leaderKey),
+			clientv3.Compare(clientv3.ModRevision(KeyApproval), "=", resp.Kvs[0].ModRevision),
+		).
+		Then(clientv3.OpPut(KeyApproval, string(data))).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if txnResp.Succeeded {
+		return nil
+	}
+
+	resp, err = s.Get(ctx, leaderKey)
+	if err != nil {
+		return err
+	}
+	if resp.Count == 0 {
+		return ErrNoLeader
+	}
+	return ErrConflict
+}
+
+// DeleteApproval removes the current approval if the leaderKey exists.
+func (s Storage) DeleteApproval(ctx context.Context, leaderKey string) error {
+	resp, err := s.Txn(ctx).
+		If(clientv3util.KeyExists(leaderKey)).
+		Then(clientv3.OpDelete(KeyApproval)).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return ErrNoLeader
+	}
+	return nil
+}
+
+// SetNotifierConfig stores *NotifierConfig into etcd.
+func (s Storage) SetNotifierConfig(ctx context.Context, c *NotifierConfig) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Put(ctx, KeyNotifierConfig, string(data))
+	return err
+}
+
+// GetNotifierConfig loads *NotifierConfig from etcd.
+// If no configuration has been stored, this returns ErrNotFound.
+func (s Storage) GetNotifierConfig(ctx context.Context) (*NotifierConfig, error) {
+	resp, err := s.Get(ctx, KeyNotifierConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	c := new(NotifierConfig)
+	err = json.Unmarshal(resp.Kvs[0].Value, c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
 // SetStatus stores the server status.
 func (s Storage) SetStatus(ctx context.Context, lease clientv3.LeaseID, st *ServerStatus) error {
 	data, err := json.Marshal(st)
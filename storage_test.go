@@ -2,6 +2,7 @@ package cke
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -91,6 +92,109 @@ func testStorageCluster(t *testing.T) {
 	}
 }
 
+func testStorageClusterHistory(t *testing.T) {
+	t.Parallel()
+
+	client := newEtcdClient(t)
+	defer client.Close()
+	storage := Storage{client}
+	ctx := context.Background()
+
+	entries, err := storage.GetClusterHistory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Error("history should be empty", entries)
+	}
+	_, err = storage.GetClusterHistoryEntry(ctx, 1)
+	if err != ErrNotFound {
+		t.Error("entry should not be found", err)
+	}
+
+	c := &Cluster{Name: "cluster1"}
+	err = storage.PutClusterWithHistory(ctx, c, "alice", "initial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.PutCluster(ctx, &Cluster{Name: "cluster2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := storage.GetClusterHistoryEntry(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Revision != 1 || e.Author != "alice" || e.Message != "initial" || e.Time.IsZero() {
+		t.Errorf("unexpected entry: %#v", e)
+	}
+	if !cmp.Equal(e.Cluster, c) {
+		t.Error("unexpected cluster", cmp.Diff(c, e.Cluster))
+	}
+
+	s, err := concurrency.NewSession(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	el := concurrency.NewElection(s, KeyLeader)
+	err = el.Campaign(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderKey := el.Key()
+
+	err = storage.PutClusterWithTemplateRevision(ctx, &Cluster{Name: "cluster3"}, 10, leaderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.PutClusterWithTemplateRevision(ctx, &Cluster{Name: "cluster4"}, 11, leaderKey+"/no")
+	if err != ErrNoLeader {
+		t.Error("PutClusterWithTemplateRevision should fail without leadership", err)
+	}
+
+	entries, err = storage.GetClusterHistory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Cluster.Name)
+	}
+	if !cmp.Equal(names, []string{"cluster3", "cluster2", "cluster1"}) {
+		t.Error("unexpected history", names)
+	}
+	if entries[0].Revision != 3 || entries[0].Author != "sabakan" {
+		t.Errorf("unexpected entry: %#v", entries[0])
+	}
+
+	for i := 4; i <= MaxClusterHistory+2; i++ {
+		err = storage.PutCluster(ctx, &Cluster{Name: fmt.Sprintf("cluster%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err = storage.GetClusterHistory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != MaxClusterHistory {
+		t.Fatal("history should be bounded", len(entries))
+	}
+	if entries[0].Revision != MaxClusterHistory+2 || entries[len(entries)-1].Revision != 3 {
+		t.Error("unexpected revisions", entries[0].Revision, entries[len(entries)-1].Revision)
+	}
+
+	got, err := storage.GetCluster(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != fmt.Sprintf("cluster%d", MaxClusterHistory+2) {
+		t.Error("unexpected current cluster", got.Name)
+	}
+}
+
 func testStorageConstraints(t *testing.T) {
 	t.Parallel()
 
@@ -122,6 +226,42 @@ func testStorageConstraints(t *testing.T) {
 	}
 }
 
+func testStorageEncryptionStatus(t *testing.T) {
+	t.Parallel()
+
+	client := newEtcdClient(t)
+	defer client.Close()
+	storage := Storage{client}
+	ctx := context.Background()
+
+	_, err := storage.GetEncryptionStatus(ctx)
+	if err != ErrNotFound {
+		t.Fatal("encryption status found.")
+	}
+
+	now := time.Now().UTC().Round(time.Second)
+	st := &EncryptionStatus{
+		Keys: []EncryptionKeyInfo{{Name: "key1", Provider: EncryptionProviderAESCBC}},
+	}
+	err = st.StartRotation(EncryptionKeyInfo{Name: "key2", Provider: EncryptionProviderSecretbox}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.PutEncryptionStatus(ctx, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := storage.GetEncryptionStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cmp.Equal(st, got) {
+		t.Fatalf("got invalid encryption status: %s", cmp.Diff(st, got))
+	}
+}
+
 func checkLeaderKey(ctx context.Context, s Storage, leaderKey string) (bool, error) {
 	resp, err := s.Get(ctx, leaderKey, clientv3.WithKeysOnly())
 	if err != nil {
@@ -347,6 +487,73 @@ func testStorageRecord(t *testing.T) {
 	}
 }
 
+func testStorageAuditRecord(t *testing.T) {
+	t.Parallel()
+
+	client := newEtcdClient(t)
+	defer client.Close()
+	storage := Storage{client}
+	ctx := context.Background()
+
+	s, err := concurrency.NewSession(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	e := concurrency.NewElection(s, KeyLeader)
+	err = e.Campaign(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderKey := e.Key()
+
+	err = storage.RegisterRecord(ctx, leaderKey, NewRecord(1, "my-operation-1", []string{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the leader has decided the ID of the next operation record.
+	id, err := storage.NextRecordID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit := NewAuditRecord("alice", "reboot-queue-add", []string{"10.0.0.1"}, `{"index":1}`)
+	err = storage.RegisterAuditRecord(ctx, audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audit.ID != id {
+		t.Error("unexpected audit record ID", audit.ID)
+	}
+
+	r := NewRecord(id, "my-operation-2", []string{})
+	err = storage.RegisterRecord(ctx, leaderKey, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != id+1 {
+		t.Error("record ID should be reassigned", r.ID)
+	}
+
+	got, err := storage.GetRecords(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatal("unexpected records", got)
+	}
+	if !cmp.Equal(got[1], audit) {
+		t.Error("audit record is overwritten", cmp.Diff(got[1], audit))
+	}
+	if got[1].User != "alice" || got[1].Status != StatusCompleted {
+		t.Error("unexpected audit record", got[1])
+	}
+	if !cmp.Equal(got[0], r) {
+		t.Error("unexpected record", cmp.Diff(got[0], r))
+	}
+}
+
 func testStorageMaint(t *testing.T) {
 	t.Parallel()
 
@@ -375,10 +582,14 @@ func testStorageMaint(t *testing.T) {
 		}
 	}
 
-	err = storage.maintRecords(ctx, leaderKey, 100)
+	now := time.Now()
+	pruned, err := storage.GetRecordsToPrune(ctx, &RecordRetention{MaxRecords: 100}, now)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if len(pruned) != 0 {
+		t.Error(`len(pruned) != 0`)
+	}
 
 	records, err := storage.GetRecords(ctx, 100)
 	if err != nil {
@@ -389,7 +600,14 @@ func testStorageMaint(t *testing.T) {
 		t.Error(`len(records) != 10`)
 	}
 
-	err = storage.maintRecords(ctx, leaderKey, 8)
+	pruned, err = storage.GetRecordsToPrune(ctx, &RecordRetention{MaxRecords: 8}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 2 || pruned[0].ID != 1 || pruned[1].ID != 2 {
+		t.Fatal(`records 1 and 2 should be pruned`, pruned)
+	}
+	err = storage.DeleteRecords(ctx, leaderKey, pruned)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -406,6 +624,305 @@ func testStorageMaint(t *testing.T) {
 	if records[7].ID != 3 {
 		t.Error(`records[7].ID != 3`)
 	}
+
+	records, err = storage.GetRecordsBefore(ctx, 7, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 {
+		t.Fatal(`len(records) != 2`)
+	}
+	if records[0].ID != 6 || records[1].ID != 5 {
+		t.Error(`records are not 6 and 5`, records[0].ID, records[1].ID)
+	}
+
+	records, err = storage.GetRecordsBefore(ctx, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0].ID != 10 {
+		t.Error(`GetRecordsBefore(0) did not return the latest records`)
+	}
+
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch, err := storage.WatchRecordsFrom(wctx, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []int64{9, 10} {
+		r := <-ch
+		if r.ID != expected {
+			t.Fatalf("expected record %d, actual %d", expected, r.ID)
+		}
+	}
+	err = storage.RegisterRecord(ctx, leaderKey, NewRecord(11, "my-operation", []string{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := <-ch; r.ID != 11 {
+		t.Fatalf("expected record 11, actual %d", r.ID)
+	}
+}
+
+func testStorageRecordRetention(t *testing.T) {
+	t.Parallel()
+
+	client := newEtcdClient(t)
+	defer client.Close()
+	storage := Storage{client}
+	ctx := context.Background()
+
+	_, err := storage.GetRecordRetention(ctx)
+	if err != ErrNotFound {
+		t.Error("retention should not be found", err)
+	}
+
+	rr := &RecordRetention{MaxRecords: 100, MaxAgeSeconds: 3600}
+	err = storage.SetRecordRetention(ctx, rr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := storage.GetRecordRetention(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *rr {
+		t.Errorf("unexpected retention: %#v", got)
+	}
+
+	s, err := concurrency.NewSession(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	e := concurrency.NewElection(s, KeyLeader)
+	err = e.Campaign(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderKey := e.Key()
+
+	// records 1-5 are two hours old, and records 6-10 are new.
+	now := time.Now().UTC()
+	for i := int64(1); i <= 10; i++ {
+		r := NewRecord(i, "my-operation", []string{"10.0.0.1"})
+		if i <= 5 {
+			r.StartAt = now.Add(-2 * time.Hour)
+		}
+		err = storage.RegisterRecord(ctx, leaderKey, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruned, err := storage.GetRecordsToPrune(ctx, rr, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 5 || pruned[4].ID != 5 {
+		t.Error("expired records should be pruned", pruned)
+	}
+
+	pruned, err = storage.GetRecordsToPrune(ctx, &RecordRetention{MaxRecords: 3, MaxAgeSeconds: 3600}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 7 || pruned[6].ID != 7 {
+		t.Error("records exceeding max_records should be pruned", pruned)
+	}
+
+	// the latest record is always kept.
+	pruned, err = storage.GetRecordsToPrune(ctx, &RecordRetention{MaxAgeSeconds: 1}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 9 {
+		t.Error("the latest record should not be pruned", len(pruned))
+	}
+}
+
+func testStorageSearchRecords(t *testing.T) {
+	t.Parallel()
+
+	client := newEtcdClient(t)
+	defer client.Close()
+	storage := Storage{client}
+	ctx := context.Background()
+
+	s, err := concurrency.NewSession(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	e := concurrency.NewElection(s, KeyLeader)
+	err = e.Campaign(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderKey := e.Key()
+
+	// register more records than a page to test pagination.
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := int64(1); i <= 250; i++ {
+		op := "op-a"
+		if i%2 == 0 {
+			op = "op-b"
+		}
+		r := NewRecord(i, op, []string{fmt.Sprintf("10.0.0.%d", i%10)})
+		r.StartAt = base.Add(time.Duration(i) * time.Minute)
+		if i%5 == 0 {
+			r.Complete()
+		}
+		if i <= 20 {
+			// records stored by older versions of CKE have no index.
+			data, err := json.Marshal(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = client.Put(ctx, recordKey(r), string(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		err = storage.RegisterRecord(ctx, leaderKey, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		filter   RecordFilter
+		count    int64
+		expected []int64
+	}{
+		{
+			name:     "count",
+			count:    3,
+			expected: []int64{250, 249, 248},
+		},
+		{
+			name:     "operation and target",
+			filter:   RecordFilter{Operation: "op-a", Target: "10.0.0.3"},
+			count:    3,
+			expected: []int64{243, 233, 223},
+		},
+		{
+			name:     "status across pages",
+			filter:   RecordFilter{Status: StatusCompleted, Until: base.Add(101 * time.Minute)},
+			count:    2,
+			expected: []int64{100, 95},
+		},
+		{
+			name:     "since",
+			filter:   RecordFilter{Operation: "op-b", Since: base.Add(245 * time.Minute)},
+			expected: []int64{250, 248, 246},
+		},
+		{
+			name:     "no match",
+			filter:   RecordFilter{Target: "10.0.0.100"},
+			expected: nil,
+		},
+		{
+			name:     "old records",
+			filter:   RecordFilter{Target: "10.0.0.1", Until: base.Add(22 * time.Minute)},
+			expected: []int64{21, 11, 1},
+		},
+	}
+
+	// records are scanned until the index is built.
+	for _, built := range []bool{false, true} {
+		if built {
+			err := storage.BuildRecordIndex(ctx, leaderKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Get(ctx, recordIndexPrefix("operation", "op-a"), clientv3.WithPrefix(), clientv3.WithCountOnly())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Count != 125 {
+				t.Error("old records are not indexed", resp.Count)
+			}
+		}
+
+		for _, tc := range testCases {
+			t.Run(fmt.Sprintf("%s/built=%v", tc.name, built), func(t *testing.T) {
+				records, err := storage.SearchRecords(ctx, &tc.filter, tc.count)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var ids []int64
+				for _, r := range records {
+					ids = append(ids, r.ID)
+				}
+				if !cmp.Equal(ids, tc.expected) {
+					t.Error("unexpected records", cmp.Diff(ids, tc.expected))
+				}
+			})
+		}
+	}
+
+	search := func(f RecordFilter, count int64) []int64 {
+		t.Helper()
+		records, err := storage.SearchRecords(ctx, &f, count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int64
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	// the index entries of many targets exceed the limit of a transaction.
+	var targets []string
+	for i := 0; i < 200; i++ {
+		targets = append(targets, fmt.Sprintf("10.1.%d.%d", i/100, i%100))
+	}
+	r := NewRecord(251, "op-c", targets)
+	err = storage.RegisterRecord(ctx, leaderKey, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := search(RecordFilter{Target: "10.1.1.99"}, 0); !cmp.Equal(ids, []int64{251}) {
+		t.Error("record with many targets is not found", ids)
+	}
+
+	// the status index follows the update.
+	records, err := storage.GetRecordsBefore(ctx, 250, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records[0].Complete()
+	err = storage.UpdateRecord(ctx, leaderKey, records[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := search(RecordFilter{Status: StatusCompleted}, 2); !cmp.Equal(ids, []int64{250, 249}) {
+		t.Error("updated record is not found by the new status", ids)
+	}
+	if ids := search(RecordFilter{Status: StatusNew, Since: base.Add(249 * time.Minute)}, 0); !cmp.Equal(ids, []int64{251}) {
+		t.Error("updated record is found by the old status", ids)
+	}
+
+	err = storage.DeleteRecords(ctx, leaderKey, []*Record{r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, prefix := range []string{KeyRecordIndex + "target/10.1.", recordIndexPrefix("operation", "op-c")} {
+		resp, err := client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Count != 0 {
+			t.Error("index entries are not deleted", prefix, resp.Count)
+		}
+	}
 }
 
 func testStorageResource(t *testing.T) {
@@ -1054,16 +1571,262 @@ func testStatus(t *testing.T) {
 	}
 }
 
+func testStorageFreeze(t *testing.T) {
+	t.Parallel()
+
+	client := newEtcdClient(t)
+	defer client.Close()
+	storage := Storage{client}
+	ctx := context.Background()
+
+	_, err := storage.GetFreeze(ctx)
+	if err != ErrNotFound {
+		t.Fatal("freeze found.")
+	}
+
+	f := &Freeze{
+		Reason:    "datacenter incident",
+		Owner:     "alice",
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	err = storage.SetFreeze(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := storage.GetFreeze(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(f, got) {
+		t.Error("unexpected freeze:", cmp.Diff(f, got))
+	}
+
+	err = storage.ClearFreeze(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = storage.GetFreeze(ctx)
+	if err != ErrNotFound {
+		t.Error("freeze was not cleared")
+	}
+}
+
+func testStorageSuspendedPhases(t *testing.T) {
+	t.Parallel()
+
+	client := newEtcdClient(t)
+	defer client.Close()
+	storage := Storage{client}
+	ctx := context.Background()
+
+	phases, err := storage.GetSuspendedPhases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(phases) != 0 {
+		t.Error("phases should be empty:", phases)
+	}
+
+	expected := []OperationPhase{PhaseEtcdMaintain, PhaseRebootNodes}
+	err = storage.SetSuspendedPhases(ctx, expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	phases, err = storage.GetSuspendedPhases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(expected, phases) {
+		t.Error("unexpected phases:", cmp.Diff(expected, phases))
+	}
+
+	err = storage.SetSuspendedPhases(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phases, err = storage.GetSuspendedPhases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(phases) != 0 {
+		t.Error("phases should be empty:", phases)
+	}
+}
+
+func testStorageApproval(t *testing.T) {
+	t.Parallel()
+
+	client := newEtcdClient(t)
+	defer client.Close()
+	storage := Storage{client}
+	ctx := context.Background()
+
+	_, err := storage.GetApprovalPolicy(ctx)
+	if err != ErrNotFound {
+		t.Fatal("approval policy found.")
+	}
+	policy := &ApprovalPolicy{
+		Operations: []string{"etcd-add-member"},
+		Phases:     []OperationPhase{PhaseRebootNodes},
+	}
+	err = storage.SetApprovalPolicy(ctx, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotPolicy, err := storage.GetApprovalPolicy(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(policy, gotPolicy) {
+		t.Error("unexpected policy:", cmp.Diff(policy, gotPolicy))
+	}
+
+	_, err = storage.GetApproval(ctx)
+	if err != ErrNotFound {
+		t.Fatal("approval found.")
+	}
+	_, err = storage.DecideApproval(ctx, 1, true, "alice")
+	if err != ErrNotFound {
+		t.Error("non-existent approval was decided:", err)
+	}
+
+	session, err := concurrency.NewSession(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	e := concurrency.NewElection(session, KeyLeader)
+	err = e.Campaign(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderKey := e.Key()
+
+	a := &Approval{
+		Status: ApprovalPending,
+		Phase:  PhaseEtcdMaintain,
+		Operations: []ApprovalOperation{
+			{Name: "etcd-add-member", Targets: []string{"10.0.0.11"}},
+		},
+	}
+	err = storage.RegisterApproval(ctx, leaderKey, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ID != 1 {
+		t.Error("unexpected ID:", a.ID)
+	}
+
+	_, err = storage.DecideApproval(ctx, 2, true, "alice")
+	if err != ErrNotFound {
+		t.Error("approval of wrong ID was decided:", err)
+	}
+	decided, err := storage.DecideApproval(ctx, 1, true, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := storage.GetApproval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(decided, got) {
+		t.Error("unexpected approval:", cmp.Diff(decided, got))
+	}
+	if got.Status != ApprovalApproved || got.DecidedBy != "alice" {
+		t.Error("approval was not approved:", got)
+	}
+	_, err = storage.DecideApproval(ctx, 1, false, "bob")
+	if err == nil {
+		t.Error("approval was decided twice")
+	}
+
+	a2 := &Approval{Status: ApprovalPending, Phase: PhaseRebootNodes}
+	err = storage.RegisterApproval(ctx, leaderKey, a2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a2.ID != 2 {
+		t.Error("unexpected ID:", a2.ID)
+	}
+
+	rejected, err := storage.DecideApproval(ctx, 2, false, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejected.Recorded = true
+	err = storage.UpdateApproval(ctx, leaderKey, rejected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = storage.GetApproval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(rejected, got) {
+		t.Error("approval was not updated:", cmp.Diff(rejected, got))
+	}
+	err = storage.UpdateApproval(ctx, leaderKey, a)
+	if err != ErrNotFound {
+		t.Error("approval of wrong ID was updated:", err)
+	}
+
+	// the approval is modified between the read and the update.
+	kv := &racingKV{KV: client.KV, key: KeyApproval}
+	client.KV = kv
+	err = storage.UpdateApproval(ctx, leaderKey, rejected)
+	client.KV = kv.KV
+	if err != ErrConflict {
+		t.Error("concurrent modification was not detected:", err)
+	}
+
+	err = storage.DeleteApproval(ctx, leaderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = storage.GetApproval(ctx)
+	if err != ErrNotFound {
+		t.Error("approval was not deleted")
+	}
+}
+
+// racingKV modifies key just after the first Get of the key.
+type racingKV struct {
+	clientv3.KV
+	key  string
+	done bool
+}
+
+func (k *racingKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	resp, err := k.KV.Get(ctx, key, opts...)
+	if err != nil || key != k.key || k.done || len(resp.Kvs) == 0 {
+		return resp, err
+	}
+	k.done = true
+	_, err = k.KV.Put(ctx, key, string(resp.Kvs[0].Value))
+	return resp, err
+}
+
 func TestStorage(t *testing.T) {
 	t.Run("ConfigVersion", testConfigVersion)
 	t.Run("Cluster", testStorageCluster)
+	t.Run("ClusterHistory", testStorageClusterHistory)
 	t.Run("Constraints", testStorageConstraints)
+	t.Run("EncryptionStatus", testStorageEncryptionStatus)
 	t.Run("Record", testStorageRecord)
+	t.Run("AuditRecord", testStorageAuditRecord)
 	t.Run("Maint", testStorageMaint)
+	t.Run("RecordRetention", testStorageRecordRetention)
+	t.Run("SearchRecords", testStorageSearchRecords)
 	t.Run("Resource", testStorageResource)
 	t.Run("Sabakan", testStorageSabakan)
 	t.Run("AutoRepair", testStorageAutoRepair)
 	t.Run("Reboot", testStorageReboot)
 	t.Run("Repair", testStorageRepair)
 	t.Run("Status", testStatus)
+	t.Run("Freeze", testStorageFreeze)
+	t.Run("SuspendedPhases", testStorageSuspendedPhases)
+	t.Run("Approval", testStorageApproval)
 }
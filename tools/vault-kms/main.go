@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cybozu-go/log"
+	"github.com/cybozu-go/well"
+	vault "github.com/hashicorp/vault/api"
+	kmsservice "k8s.io/kms/pkg/service"
+)
+
+var (
+	flgListen      = flag.String("listen", "/run/cke-kms/kms.sock", "UNIX domain socket to listen on")
+	flgVaultConfig = flag.String("vault-config", "/etc/kubernetes/kms/vault.json", "Vault connection config in JSON")
+	flgTransit     = flag.String("transit-path", "cke/transit", "Mount path of the transit secrets engine")
+	flgKey         = flag.String("key", "kubernetes", "Name of the transit key")
+	flgTimeout     = flag.String("timeout", "10s", "Timeout for gRPC connections")
+)
+
+// vaultConfig is the same format as VaultConfig of CKE.
+type vaultConfig struct {
+	Endpoint string `json:"endpoint"`
+	CACert   string `json:"ca-cert"`
+	RoleID   string `json:"role-id"`
+	SecretID string `json:"secret-id"`
+}
+
+func newVaultClient(path string) (*vault.Client, *vaultConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := &vaultConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, nil, err
+	}
+
+	vcfg := vault.DefaultConfig()
+	vcfg.Address = cfg.Endpoint
+	if cfg.CACert != "" {
+		err := vcfg.ConfigureTLS(&vault.TLSConfig{CACertBytes: []byte(cfg.CACert)})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	vc, err := vault.NewClient(vcfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vc, cfg, nil
+}
+
+// login logs in to Vault with AppRole and keeps renewing the token until ctx is done.
+// When the token cannot be renewed any longer, this logs in again.
+func login(ctx context.Context, vc *vault.Client, cfg *vaultConfig) error {
+	for {
+		secret, err := vc.Logical().WriteWithContext(ctx, "auth/approle/login", map[string]any{
+			"role_id":   cfg.RoleID,
+			"secret_id": cfg.SecretID,
+		})
+		if err != nil {
+			log.Error("failed to login to vault", map[string]any{
+				log.FnError: err,
+			})
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		vc.SetToken(secret.Auth.ClientToken)
+
+		watcher, err := vc.NewLifetimeWatcher(&vault.LifetimeWatcherInput{Secret: secret})
+		if err != nil {
+			return err
+		}
+		go watcher.Start()
+		select {
+		case <-ctx.Done():
+			watcher.Stop()
+			return nil
+		case err := <-watcher.DoneCh():
+			log.Warn("vault token is no longer renewed", map[string]any{
+				log.FnError: err,
+			})
+		}
+	}
+}
+
+type transitService struct {
+	vc   *vault.Client
+	path string
+	key  string
+}
+
+var _ kmsservice.Service = transitService{}
+
+func (s transitService) Encrypt(ctx context.Context, uid string, data []byte) (*kmsservice.EncryptResponse, error) {
+	secret, err := s.vc.Logical().WriteWithContext(ctx, s.path+"/encrypt/"+s.key, map[string]any{
+		"plaintext": base64.StdEncoding.EncodeToString(data),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, ok := secret.Data["ciphertext"].(string)
+	if !ok {
+		return nil, errors.New("no ciphertext in the response")
+	}
+	version, err := keyVersion(secret.Data["key_version"])
+	if err != nil {
+		return nil, err
+	}
+	return &kmsservice.EncryptResponse{
+		Ciphertext: []byte(ciphertext),
+		KeyID:      version,
+	}, nil
+}
+
+func (s transitService) Decrypt(ctx context.Context, uid string, req *kmsservice.DecryptRequest) ([]byte, error) {
+	secret, err := s.vc.Logical().WriteWithContext(ctx, s.path+"/decrypt/"+s.key, map[string]any{
+		"ciphertext": string(req.Ciphertext),
+	})
+	if err != nil {
+		return nil, err
+	}
+	plaintext, ok := secret.Data["plaintext"].(string)
+	if !ok {
+		return nil, errors.New("no plaintext in the response")
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
+
+// Status returns the latest version of the transit key as the key ID.
+// kube-apiserver generates a new DEK when the transit key is rotated.
+func (s transitService) Status(ctx context.Context) (*kmsservice.StatusResponse, error) {
+	secret, err := s.vc.Logical().ReadWithContext(ctx, s.path+"/keys/"+s.key)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("transit key %s is not found", s.key)
+	}
+	version, err := keyVersion(secret.Data["latest_version"])
+	if err != nil {
+		return nil, err
+	}
+	return &kmsservice.StatusResponse{
+		Version: "v2",
+		Healthz: "ok",
+		KeyID:   version,
+	}, nil
+}
+
+func keyVersion(v any) (string, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return "", fmt.Errorf("unexpected key version: %v", v)
+	}
+	if _, err := strconv.Atoi(string(n)); err != nil {
+		return "", fmt.Errorf("unexpected key version: %v", v)
+	}
+	return string(n), nil
+}
+
+func run() error {
+	timeout, err := time.ParseDuration(*flgTimeout)
+	if err != nil {
+		return err
+	}
+
+	vc, cfg, err := newVaultClient(*flgVaultConfig)
+	if err != nil {
+		return err
+	}
+	well.Go(func(ctx context.Context) error {
+		return login(ctx, vc, cfg)
+	})
+
+	// remove the socket left by the previous run.
+	if err := os.Remove(*flgListen); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	s := kmsservice.NewGRPCService(*flgListen, timeout, transitService{vc: vc, path: *flgTransit, key: *flgKey})
+	well.Go(func(ctx context.Context) error {
+		return s.ListenAndServe()
+	})
+	well.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		s.Shutdown()
+		return nil
+	})
+
+	well.Stop()
+	return well.Wait()
+}
+
+func main() {
+	flag.Parse()
+	well.LogConfig{}.Apply()
+
+	err := run()
+	if err != nil && !well.IsSignaled(err) {
+		log.ErrorExit(err)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+	kmsservice "k8s.io/kms/pkg/service"
+)
+
+// fakeTransit is a fake of the transit secrets engine of Vault.
+// It stores plaintexts and returns their indices as ciphertexts.
+type fakeTransit struct {
+	mu         sync.Mutex
+	version    int
+	plaintexts []string
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var req map[string]string
+	if r.Method != http.MethodGet {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var data map[string]any
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/cke/transit/keys/kubernetes":
+		data = map[string]any{"latest_version": f.version}
+	case r.Method != http.MethodGet && r.URL.Path == "/v1/cke/transit/encrypt/kubernetes":
+		f.plaintexts = append(f.plaintexts, req["plaintext"])
+		data = map[string]any{
+			"ciphertext":  fmt.Sprintf("vault:v%d:%d", f.version, len(f.plaintexts)-1),
+			"key_version": f.version,
+		}
+	case r.Method != http.MethodGet && r.URL.Path == "/v1/cke/transit/decrypt/kubernetes":
+		var v, i int
+		_, err := fmt.Sscanf(req["ciphertext"], "vault:v%d:%d", &v, &i)
+		if err != nil || i >= len(f.plaintexts) {
+			http.Error(w, `{"errors":["invalid ciphertext"]}`, http.StatusBadRequest)
+			return
+		}
+		data = map[string]any{"plaintext": f.plaintexts[i]}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func newTestService(t *testing.T, f *fakeTransit) transitService {
+	t.Helper()
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	cfg := vault.DefaultConfig()
+	cfg.Address = srv.URL
+	vc, err := vault.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vc.SetToken("test")
+	return transitService{vc: vc, path: "cke/transit", key: "kubernetes"}
+}
+
+func TestTransitService(t *testing.T) {
+	f := &fakeTransit{version: 1}
+	s := newTestService(t, f)
+	ctx := context.Background()
+
+	plaintexts := []string{"secret", "", strings.Repeat("\x00\xff", 100)}
+	var encrypted []*kmsservice.EncryptResponse
+	for _, p := range plaintexts {
+		resp, err := s.Encrypt(ctx, "uid", []byte(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.KeyID != "1" {
+			t.Error("unexpected key ID", resp.KeyID)
+		}
+		if strings.Contains(string(resp.Ciphertext), p) && p != "" {
+			t.Error("ciphertext contains the plaintext", string(resp.Ciphertext))
+		}
+		encrypted = append(encrypted, resp)
+	}
+
+	for i, resp := range encrypted {
+		data, err := s.Decrypt(ctx, "uid", &kmsservice.DecryptRequest{
+			Ciphertext: resp.Ciphertext,
+			KeyID:      resp.KeyID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != plaintexts[i] {
+			t.Errorf("round trip of %q returned %q", plaintexts[i], data)
+		}
+	}
+
+	_, err := s.Decrypt(ctx, "uid", &kmsservice.DecryptRequest{Ciphertext: []byte("vault:v1:100")})
+	if err == nil {
+		t.Error("decrypting an unknown ciphertext should fail")
+	}
+}
+
+func TestTransitServiceStatus(t *testing.T) {
+	f := &fakeTransit{version: 1}
+	s := newTestService(t, f)
+	ctx := context.Background()
+
+	status, err := s.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != "v2" || status.Healthz != "ok" || status.KeyID != "1" {
+		t.Error("unexpected status", status)
+	}
+
+	// the key ID follows the rotation of the transit key.
+	f.mu.Lock()
+	f.version = 2
+	f.mu.Unlock()
+	status, err = s.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.KeyID != "2" {
+		t.Error("key ID is not updated after rotation", status.KeyID)
+	}
+
+	resp, err := s.Encrypt(ctx, "uid", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.KeyID != "2" {
+		t.Error("unexpected key ID after rotation", resp.KeyID)
+	}
+}
+
+func TestKeyVersion(t *testing.T) {
+	testCases := []struct {
+		input   any
+		want    string
+		succeed bool
+	}{
+		{json.Number("3"), "3", true},
+		{json.Number("1.5"), "", false},
+		{"3", "", false},
+		{nil, "", false},
+	}
+
+	for _, tc := range testCases {
+		got, err := keyVersion(tc.input)
+		if tc.succeed != (err == nil) {
+			t.Errorf("keyVersion(%v) returned error %v", tc.input, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("keyVersion(%v) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}